@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+)
+
+// apiClient calls the gateway's admin endpoints.
+type apiClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newAPIClient(g globals) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimRight(g.apiURL, "/"),
+		token:   g.token,
+		client:  &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+func (c *apiClient) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s %s: HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+func (c *apiClient) clear(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodPost, "/cache/clear", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *apiClient) export(ctx context.Context, w io.Writer, withEmbeddings bool) error {
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/admin/cache/export?embeddings=%t", withEmbeddings), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d bytes\n", n)
+	return nil
+}
+
+func (c *apiClient) importSnapshot(ctx context.Context, r io.Reader) (cache.ImportResult, error) {
+	var result cache.ImportResult
+	resp, err := c.do(ctx, http.MethodPost, "/admin/cache/import", r)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, fmt.Errorf("invalid import response: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"semantic-cache-gateway/internal/calibrate"
+	"semantic-cache-gateway/internal/config"
+)
+
+// runCalibrate embeds labeled prompt pairs with the configured embedding
+// model and recommends a SIMILARITY_THRESHOLD. It does not need Redis.
+func runCalibrate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	input := fs.String("i", "-", "CSV or JSONL file of prompt_a, prompt_b, should_match (- for stdin)")
+	minThreshold := fs.Float64("min", 0.70, "lowest threshold to evaluate")
+	maxThreshold := fs.Float64("max", 0.99, "highest threshold to evaluate")
+	step := fs.Float64("step", 0.01, "threshold step")
+	minPrecision := fs.Float64("min-precision", 0, "only recommend thresholds with at least this precision")
+	write := fs.String("write", "", "write the recommended SIMILARITY_THRESHOLD into this .env file")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	fs.Parse(args)
+
+	if *step <= 0 || *minThreshold > *maxThreshold {
+		return errors.New("-step must be positive and -min must not exceed -max")
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	pairs, err := calibrate.ReadPairs(r)
+	if err != nil {
+		return fmt.Errorf("failed to read pairs: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	embed, err := newQueryEmbedder(cfg)
+	if err != nil {
+		return err
+	}
+	scored, err := calibrate.Score(ctx, embed, pairs)
+	if err != nil {
+		return err
+	}
+
+	metrics := calibrate.Sweep(scored, calibrate.Thresholds(*minThreshold, *maxThreshold, *step))
+	best, ok := calibrate.Recommend(metrics, *minPrecision)
+
+	if *asJSON {
+		out := struct {
+			EmbeddingModel string              `json:"embedding_model"`
+			Pairs          int                 `json:"pairs"`
+			Current        float64             `json:"current_threshold"`
+			Recommended    *calibrate.Metrics  `json:"recommended"`
+			Thresholds     []calibrate.Metrics `json:"thresholds"`
+		}{EmbeddingModel: cfg.EmbeddingModel, Pairs: len(pairs), Current: cfg.SimilarityThreshold, Thresholds: metrics}
+		if ok {
+			out.Recommended = &best
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%d pairs embedded with %s\n\n", len(pairs), cfg.EmbeddingModel)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "THRESHOLD\tTP\tFP\tFN\tTN\tPRECISION\tRECALL\tF1\t")
+		for _, m := range metrics {
+			fmt.Fprintf(tw, "%.2f\t%d\t%d\t%d\t%d\t%.3f\t%.3f\t%.3f\t\n",
+				m.Threshold, m.TP, m.FP, m.FN, m.TN, m.Precision, m.Recall, m.F1)
+		}
+		tw.Flush()
+		fmt.Println()
+		if ok {
+			fmt.Printf("recommended SIMILARITY_THRESHOLD=%.2f (precision %.3f, recall %.3f, F1 %.3f; current %.2f)\n",
+				best.Threshold, best.Precision, best.Recall, best.F1, cfg.SimilarityThreshold)
+		}
+	}
+
+	if !ok {
+		return fmt.Errorf("no threshold reaches precision %.3f", *minPrecision)
+	}
+	if *write != "" {
+		if err := calibrate.WriteEnvFile(*write, "SIMILARITY_THRESHOLD", strconv.FormatFloat(best.Threshold, 'f', -1, 64)); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "wrote SIMILARITY_THRESHOLD=%v to %s\n", best.Threshold, *write)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+)
+
+// errStop ends a scan early once enough entries have been printed.
+var errStop = errors.New("stop")
+
+func runList(ctx context.Context, g globals, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	limit := fs.Int("limit", 50, "maximum entries to show (0 for all)")
+	asJSON := fs.Bool("json", false, "print entries as NDJSON without embeddings")
+	fs.Parse(args)
+	return listEntries(ctx, g, "", *limit, *asJSON)
+}
+
+func runSearch(ctx context.Context, g globals, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	limit := fs.Int("limit", 50, "maximum entries to show (0 for all)")
+	asJSON := fs.Bool("json", false, "print entries as NDJSON without embeddings")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("search text is required")
+	}
+	return listEntries(ctx, g, strings.Join(fs.Args(), " "), *limit, *asJSON)
+}
+
+// listEntries prints entries whose query text contains text (case-insensitive).
+func listEntries(ctx context.Context, g globals, text string, limit int, asJSON bool) error {
+	_, svc, err := openCache(g)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	text = strings.ToLower(text)
+	var entries []*cache.CacheEntry
+	err = svc.Scan(ctx, func(entry *cache.CacheEntry) error {
+		if text != "" && !strings.Contains(strings.ToLower(entry.QueryText), text) {
+			return nil
+		}
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) >= limit {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			entry.Embedding = nil
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt > entries[j].CreatedAt })
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tHITS\tAGE\tEXPIRES\tQUERY")
+	now := time.Now()
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n",
+			entry.ID, entry.HitCount, age(now, entry.CreatedAt), expiry(now, entry.ExpiresAt), truncate(entry.QueryText, 60))
+	}
+	return tw.Flush()
+}
+
+func runGet(ctx context.Context, g globals, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: cachectl get <id>")
+	}
+	_, svc, err := openCache(g)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	entry, err := svc.Get(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("entry %s not found", args[0])
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entry)
+}
+
+func runInfo(ctx context.Context, g globals, args []string) error {
+	_, svc, err := openCache(g)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	info, err := svc.IndexInfo(ctx)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(info))
+	for key := range info {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", key, formatValue(info[key]))
+	}
+	return tw.Flush()
+}
+
+func runProbe(ctx context.Context, g globals, args []string) error {
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	k := fs.Int("k", 5, "number of neighbors to show")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("prompt is required")
+	}
+	prompt := strings.Join(fs.Args(), " ")
+
+	cfg, svc, err := openCache(g)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	embed, err := newQueryEmbedder(cfg)
+	if err != nil {
+		return err
+	}
+	vec, err := embed(ctx, prompt)
+	if err != nil {
+		return fmt.Errorf("failed to embed prompt: %w", err)
+	}
+	neighbors, err := svc.SearchTopK(ctx, vec, *k, cache.SearchFilter{})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("threshold: %.4f\n", cfg.SimilarityThreshold)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tSIMILARITY\tMATCH\tID\tQUERY")
+	for i, n := range neighbors {
+		match := ""
+		if n.Similarity > cfg.SimilarityThreshold {
+			match = "yes"
+		}
+		fmt.Fprintf(tw, "%d\t%.4f\t%s\t%s\t%s\n", i+1, n.Similarity, match, n.Entry.ID, truncate(n.Entry.QueryText, 60))
+	}
+	return tw.Flush()
+}
+
+func runDelete(ctx context.Context, g globals, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	pattern := fs.String("pattern", "", "delete all entries whose key matches this glob, e.g. 'cache:ab*'")
+	fs.Parse(args)
+	if (*pattern == "") == (fs.NArg() == 0) {
+		return errors.New("give either entry IDs or -pattern")
+	}
+
+	_, svc, err := openCache(g)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	var deleted int64
+	if *pattern != "" {
+		deleted, err = svc.DeleteMatching(ctx, *pattern)
+	} else {
+		deleted, err = svc.Delete(ctx, fs.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "deleted %d entries\n", deleted)
+	return nil
+}
+
+func runClear(ctx context.Context, g globals, args []string) error {
+	if g.apiURL != "" {
+		return newAPIClient(g).clear(ctx)
+	}
+	_, svc, err := openCache(g)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+	return svc.Clear(ctx)
+}
+
+func runExport(ctx context.Context, g globals, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "-", "output file (- for stdout)")
+	withEmbeddings := fs.Bool("embeddings", true, "include embeddings in the snapshot")
+	fs.Parse(args)
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if g.apiURL != "" {
+		return newAPIClient(g).export(ctx, w, *withEmbeddings)
+	}
+
+	cfg, svc, err := openCache(g)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	count, err := cache.Export(ctx, svc, w, cache.ExportOptions{
+		IncludeEmbeddings: *withEmbeddings,
+		EmbeddingModel:    cfg.EmbeddingModel,
+		Dimensions:        cfg.EmbeddingDimensions,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d entries\n", count)
+	return nil
+}
+
+func runImport(ctx context.Context, g globals, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	input := fs.String("i", "-", "input file (- for stdin)")
+	fs.Parse(args)
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	result, err := importSnapshot(ctx, g, r)
+	fmt.Fprintf(os.Stderr, "imported %d entries (%d re-embedded, %d skipped)\n", result.Imported, result.Reembedded, result.Skipped)
+	return err
+}
+
+// importSnapshot restores a snapshot through the admin API or directly into Redis.
+func importSnapshot(ctx context.Context, g globals, r io.Reader) (cache.ImportResult, error) {
+	if g.apiURL != "" {
+		return newAPIClient(g).importSnapshot(ctx, r)
+	}
+
+	cfg, svc, err := openCache(g)
+	if err != nil {
+		return cache.ImportResult{}, err
+	}
+	defer svc.Close()
+
+	embed, err := newQueryEmbedder(cfg)
+	if err != nil {
+		return cache.ImportResult{}, err
+	}
+	return cache.Import(ctx, svc, r, cache.ImportOptions{
+		EmbeddingModel:   cfg.EmbeddingModel,
+		Dimensions:       cfg.EmbeddingDimensions,
+		EmbeddingVersion: cfg.EmbeddingVersion,
+		Embed:            embed,
+	})
+}
+
+func runReindex(ctx context.Context, g globals, args []string) error {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	dimensions := fs.Int("dimensions", 0, "vector dimensions of the new index")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	fs.Parse(args)
+	if *dimensions < 1 {
+		return errors.New("-dimensions is required")
+	}
+
+	if !*yes {
+		fmt.Fprintf(os.Stderr, "Drop and recreate the cache index with %d dimensions? Entries with other dimensions stop matching. [y/N] ", *dimensions)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return errors.New("aborted")
+		}
+	}
+
+	_, svc, err := openCache(g)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	if err := svc.RecreateIndex(ctx, *dimensions); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "recreated index with %d dimensions\n", *dimensions)
+	return nil
+}
+
+// age formats the time since a Unix timestamp.
+func age(now time.Time, unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	return now.Sub(time.Unix(unix, 0)).Round(time.Second).String()
+}
+
+// expiry formats the time until a Unix timestamp, or "stale" once passed.
+func expiry(now time.Time, unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	if d := time.Unix(unix, 0).Sub(now); d > 0 {
+		return d.Round(time.Second).String()
+	}
+	return "stale"
+}
+
+// truncate shortens s to at most n runes on a single line.
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-3]) + "..."
+	}
+	return s
+}
+
+// formatValue renders an FT.INFO value compactly.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case []interface{}, map[interface{}]interface{}:
+		data, err := json.Marshal(normalize(v))
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// normalize converts Redis replies into JSON-encodable values.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[fmt.Sprint(key)] = normalize(value)
+		}
+		return out
+	default:
+		return v
+	}
+}
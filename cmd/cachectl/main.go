@@ -0,0 +1,227 @@
+// Command cachectl inspects and manages the semantic cache.
+//
+// Most commands talk to Redis directly, using the same environment variables
+// as the gateway (REDIS_URL, EMBEDDING_*, CACHE_VECTOR_TYPE, ...). With -api,
+// export, import and clear go through the gateway's admin API instead, so
+// they also work where Redis is not reachable:
+//
+//	cachectl list -limit 20
+//	cachectl probe -k 5 "What is the capital of France?"
+//	cachectl -api http://localhost:8080 -token $ADMIN_TOKEN export -o snapshot.ndjson
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/config"
+	"semantic-cache-gateway/internal/embedding"
+	"semantic-cache-gateway/internal/handler"
+	"semantic-cache-gateway/internal/logger"
+	"semantic-cache-gateway/internal/models"
+	"semantic-cache-gateway/internal/templates"
+)
+
+const usage = `usage: cachectl [global flags] <command> [flags]
+
+commands:
+  list      [-limit N] [-json]                  list cache entries
+  search    [-limit N] <text>                   list entries whose query contains text
+  get       <id>                                print one entry as JSON
+  info                                          show FT.INFO for the cache index
+  probe     [-k N] <prompt>                     show the top-k matches for a prompt
+  delete    <id>... | -pattern <glob>           delete entries by ID or key pattern
+  clear                                         delete all entries
+  export    [-o file] [-embeddings=false]       write entries as an NDJSON snapshot
+  import    [-i file]                           restore entries from a snapshot
+  reindex   -dimensions N [-yes]                drop and recreate the vector index
+  calibrate [-i file] [-write .env]             recommend a threshold from labeled pairs
+  recall    [-k N] [-queries N] [-synthetic N]  measure vector index recall against brute force
+
+global flags:
+`
+
+// globals holds flags shared by all commands.
+type globals struct {
+	redisURL string
+	apiURL   string
+	token    string
+}
+
+func main() {
+	var g globals
+	fs := flag.NewFlagSet("cachectl", flag.ExitOnError)
+	fs.StringVar(&g.redisURL, "redis", "", "Redis URL (default: $REDIS_URL)")
+	fs.StringVar(&g.apiURL, "api", os.Getenv("CACHECTL_API"), "gateway base URL for export, import and clear")
+	fs.StringVar(&g.token, "token", os.Getenv("ADMIN_TOKEN"), "admin API bearer token")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	name, args := fs.Arg(0), fs.Args()[1:]
+	var err error
+	switch name {
+	case "list":
+		err = runList(ctx, g, args)
+	case "search":
+		err = runSearch(ctx, g, args)
+	case "get":
+		err = runGet(ctx, g, args)
+	case "info":
+		err = runInfo(ctx, g, args)
+	case "probe":
+		err = runProbe(ctx, g, args)
+	case "delete":
+		err = runDelete(ctx, g, args)
+	case "clear":
+		err = runClear(ctx, g, args)
+	case "export":
+		err = runExport(ctx, g, args)
+	case "import":
+		err = runImport(ctx, g, args)
+	case "reindex":
+		err = runReindex(ctx, g, args)
+	case "calibrate":
+		err = runCalibrate(ctx, args)
+	case "recall":
+		err = runRecall(ctx, g, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// openCache loads the gateway configuration and connects to Redis.
+// Logs go to stderr so stdout can carry command output.
+func openCache(g globals) (*config.Config, *cache.CacheServiceImpl, error) {
+	log := logger.NewWithOutput(os.Stderr, slog.LevelWarn)
+	cfg, store, err := openStore(g, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cacheConfig := cache.DefaultCacheServiceConfig()
+	cacheConfig.Dimensions = cfg.EmbeddingDimensions
+	cacheConfig.EmbeddingModel = cfg.EmbeddingModel
+	cacheConfig.EmbeddingVersion = cfg.EmbeddingVersion
+	cacheConfig.TTL = cfg.CacheTTL
+	cacheConfig.StaleWindow = cfg.CacheStaleWindow
+	cacheConfig.Compression = cfg.CacheCompression
+	cacheConfig.CompressionMinBytes = cfg.CacheCompressionMinBytes
+	svc, err := cache.NewCacheService(store, log, cacheConfig)
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	return cfg, svc, nil
+}
+
+// openStore loads the gateway configuration and connects to the RediSearch store.
+func openStore(g globals, log *logger.Logger) (*config.Config, *cache.RedisStore, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	if g.redisURL != "" {
+		cfg.RedisURL = g.redisURL
+	}
+	if cfg.CacheBackend != "redis" {
+		return nil, nil, fmt.Errorf("cachectl only opens the redis store directly; use -api with CACHE_BACKEND=%s", cfg.CacheBackend)
+	}
+
+	redisClient, err := cache.NewRedisClient(newRedisConfig(cfg), log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := redisClient.Ping(ctx); err != nil {
+		redisClient.Close()
+		return nil, nil, err
+	}
+	return cfg, cache.NewRedisStore(redisClient, newRedisStoreConfig(cfg)), nil
+}
+
+// newRedisStoreConfig builds the RediSearch store configuration from the gateway config.
+func newRedisStoreConfig(cfg *config.Config) cache.RedisStoreConfig {
+	return cache.RedisStoreConfig{
+		VectorType:        cfg.CacheVectorType,
+		Algorithm:         cfg.CacheIndexAlgorithm,
+		Metric:            cfg.CacheIndexMetric,
+		M:                 cfg.CacheHNSWM,
+		EFConstruction:    cfg.CacheHNSWEFConstruction,
+		EFRuntime:         cfg.CacheHNSWEFRuntime,
+		InitialCap:        cfg.CacheIndexInitialCap,
+		AutoRecreateIndex: cfg.CacheIndexAutoRecreate,
+	}
+}
+
+// newRedisConfig builds the Redis connection configuration from the gateway config.
+func newRedisConfig(cfg *config.Config) *cache.RedisConfig {
+	redisConfig := cache.DefaultRedisConfig(cfg.RedisURL)
+	redisConfig.Username = cfg.RedisUsername
+	redisConfig.Password = cfg.RedisPassword
+	redisConfig.SentinelMaster = cfg.RedisSentinelMaster
+	redisConfig.SentinelAddrs = cfg.RedisSentinelAddrs
+	redisConfig.SentinelPassword = cfg.RedisSentinelPassword
+	redisConfig.ClusterAddrs = cfg.RedisClusterAddrs
+	redisConfig.TLS = cfg.RedisTLS
+	redisConfig.TLSCAFile = cfg.RedisTLSCAFile
+	redisConfig.TLSCertFile = cfg.RedisTLSCertFile
+	redisConfig.TLSKeyFile = cfg.RedisTLSKeyFile
+	redisConfig.TLSServerName = cfg.RedisTLSServerName
+	return redisConfig
+}
+
+// newEmbeddingService builds the embedding client from the gateway configuration.
+func newEmbeddingService(cfg *config.Config) *embedding.Service {
+	embeddingConfig := embedding.DefaultConfig(cfg.EmbeddingAPIKey)
+	embeddingConfig.APIEndpoint = cfg.EmbeddingURL
+	embeddingConfig.ModelName = cfg.EmbeddingModel
+	embeddingConfig.Dimensions = cfg.EmbeddingDimensions
+	return embedding.NewService(embeddingConfig)
+}
+
+// newQueryEmbedder embeds text the way the gateway embeds queries: only the
+// meaningful part of templated prompts, normalized with the configured
+// QUERY_NORMALIZATION steps.
+func newQueryEmbedder(cfg *config.Config) (func(ctx context.Context, text string) ([]float32, error), error) {
+	normalizer, err := models.NewNormalizer(cfg.NormalizeConfig())
+	if err != nil {
+		return nil, err
+	}
+	var promptTemplates *templates.Set
+	if cfg.PromptTemplatesFile != "" {
+		if promptTemplates, err = templates.Load(cfg.PromptTemplatesFile); err != nil {
+			return nil, err
+		}
+	}
+	svc := newEmbeddingService(cfg)
+	return func(ctx context.Context, text string) ([]float32, error) {
+		lookup, _ := handler.LookupText(normalizer, promptTemplates, text)
+		return svc.Generate(ctx, lookup)
+	}, nil
+}
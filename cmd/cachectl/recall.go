@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/logger"
+)
+
+// errEnoughVectors stops the scan of cache entries once -max vectors are collected.
+var errEnoughVectors = errors.New("enough vectors")
+
+// runRecall builds a scratch index with the configured CACHE_INDEX_* and
+// CACHE_HNSW_* settings and reports how many of the exact nearest neighbors
+// its searches return. The vectors are the cached embeddings, or clustered
+// random vectors with -synthetic. Queries are perturbed copies of them.
+func runRecall(ctx context.Context, g globals, args []string) error {
+	fs := flag.NewFlagSet("recall", flag.ExitOnError)
+	k := fs.Int("k", 10, "neighbors compared per query")
+	queryCount := fs.Int("queries", 200, "number of queries")
+	synthetic := fs.Int("synthetic", 0, "index this many random vectors instead of the cached embeddings")
+	maxVectors := fs.Int("max", 10000, "most cached embeddings to index")
+	minRecall := fs.Float64("min-recall", 0, "fail if the mean recall is below this")
+	seed := fs.Int64("seed", 1, "random seed for synthetic vectors and queries")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+	if *k < 1 || *queryCount < 1 {
+		return errors.New("-k and -queries must be positive")
+	}
+
+	cfg, store, err := openStore(g, logger.NewWithOutput(os.Stderr, slog.LevelWarn))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	rng := rand.New(rand.NewSource(*seed))
+	var vectors [][]float32
+	if *synthetic > 0 {
+		vectors = clusteredVectors(rng, *synthetic, cfg.EmbeddingDimensions)
+	} else {
+		err := store.Scan(ctx, func(entry *cache.CacheEntry) error {
+			if len(entry.Embedding) != cfg.EmbeddingDimensions {
+				return nil
+			}
+			vectors = append(vectors, entry.Embedding)
+			if len(vectors) >= *maxVectors {
+				return errEnoughVectors
+			}
+			return nil
+		})
+		if err != nil && err != errEnoughVectors {
+			return err
+		}
+		if len(vectors) <= *k {
+			return fmt.Errorf("the cache holds %d embeddings of %d dimensions; use -synthetic", len(vectors), cfg.EmbeddingDimensions)
+		}
+	}
+
+	queries := make([][]float32, *queryCount)
+	for i := range queries {
+		queries[i] = perturb(rng, vectors[rng.Intn(len(vectors))], 0.1)
+	}
+
+	fmt.Fprintf(os.Stderr, "indexing %d vectors (%s %s, %s)...\n", len(vectors), cfg.CacheIndexAlgorithm, cfg.CacheIndexMetric, cfg.CacheVectorType)
+	report, err := store.MeasureRecall(ctx, vectors, queries, *k)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("algorithm:       %s (M=%s, EF_CONSTRUCTION=%s, EF_RUNTIME=%s)\n", cfg.CacheIndexAlgorithm,
+			orDefault(cfg.CacheHNSWM), orDefault(cfg.CacheHNSWEFConstruction), orDefault(cfg.CacheHNSWEFRuntime))
+		fmt.Printf("metric:          %s, %s vectors\n", cfg.CacheIndexMetric, cfg.CacheVectorType)
+		fmt.Printf("vectors:         %d indexed in %s\n", report.Vectors, report.IndexTime.Round(time.Millisecond))
+		fmt.Printf("recall@%d:        %.4f mean, %.4f worst of %d queries\n", report.K, report.Recall, report.MinRecall, report.Queries)
+		fmt.Printf("search latency:  p50 %s, p95 %s\n", report.LatencyP50, report.LatencyP95)
+	}
+
+	if report.Recall < *minRecall {
+		return fmt.Errorf("recall %.4f is below %.4f", report.Recall, *minRecall)
+	}
+	return nil
+}
+
+// orDefault formats an index parameter, where zero means the server default.
+func orDefault(n int) string {
+	if n == 0 {
+		return "default"
+	}
+	return fmt.Sprint(n)
+}
+
+// clusteredVectors returns n random vectors grouped around n/50 centers,
+// which resembles embeddings of related prompts better than uniform noise.
+func clusteredVectors(rng *rand.Rand, n, dims int) [][]float32 {
+	centers := make([][]float32, n/50+1)
+	for i := range centers {
+		centers[i] = make([]float32, dims)
+		for j := range centers[i] {
+			centers[i][j] = float32(rng.NormFloat64())
+		}
+	}
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = perturb(rng, centers[rng.Intn(len(centers))], 0.5)
+	}
+	return vectors
+}
+
+// perturb returns a copy of vec with Gaussian noise of the given scale
+// relative to the vector's average element magnitude.
+func perturb(rng *rand.Rand, vec []float32, scale float64) []float32 {
+	var sum float64
+	for _, f := range vec {
+		sum += float64(f) * float64(f)
+	}
+	rms := 1.0
+	if len(vec) > 0 && sum > 0 {
+		rms = math.Sqrt(sum / float64(len(vec)))
+	}
+	out := make([]float32, len(vec))
+	for i, f := range vec {
+		out[i] = f + float32(rng.NormFloat64()*scale*rms)
+	}
+	return out
+}
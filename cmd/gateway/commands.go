@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/config"
+	"semantic-cache-gateway/internal/embedding"
+	"semantic-cache-gateway/internal/logger"
+	"semantic-cache-gateway/internal/models"
+)
+
+// runCommand dispatches CLI subcommands and exits the process.
+func runCommand(name string, args []string) {
+	var err error
+	switch name {
+	case "export":
+		err = runExport(args)
+	case "import":
+		err = runImport(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\nusage:\n  gateway                 start the gateway\n  gateway export [flags]  write all cache entries as NDJSON\n  gateway import [flags]  restore cache entries from NDJSON\n", name)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// runExport writes a snapshot of the cache to a file or stdout.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "-", "output file (- for stdout)")
+	withEmbeddings := fs.Bool("embeddings", true, "include embeddings in the snapshot")
+	fs.Parse(args)
+
+	cfg, svc, err := openCacheService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	count, err := cache.Export(ctx, svc, w, cache.ExportOptions{
+		IncludeEmbeddings: *withEmbeddings,
+		EmbeddingModel:    cfg.EmbeddingModel,
+		Dimensions:        cfg.EmbeddingDimensions,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d entries\n", count)
+	return nil
+}
+
+// runImport restores a snapshot into the cache, re-embedding entries when
+// the snapshot was produced with a different embedding model or dimension.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	input := fs.String("i", "-", "input file (- for stdin)")
+	fs.Parse(args)
+
+	cfg, svc, err := openCacheService()
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	normalizer, err := models.NewNormalizer(cfg.NormalizeConfig())
+	if err != nil {
+		return err
+	}
+	promptTemplates, err := loadTemplates(cfg)
+	if err != nil {
+		return err
+	}
+	embeddingService := embedding.NewService(newEmbeddingConfig(cfg))
+	result, err := cache.Import(ctx, svc, r, cache.ImportOptions{
+		EmbeddingModel:   cfg.EmbeddingModel,
+		Dimensions:       cfg.EmbeddingDimensions,
+		EmbeddingVersion: cfg.EmbeddingVersion,
+		Embed:            queryEmbedder(embeddingService, normalizer, promptTemplates),
+	})
+	fmt.Fprintf(os.Stderr, "imported %d entries (%d re-embedded, %d skipped)\n", result.Imported, result.Reembedded, result.Skipped)
+	return err
+}
+
+// openCacheService loads configuration and connects to the cache for CLI commands.
+// Logs go to stderr so stdout can carry snapshot data.
+func openCacheService() (*config.Config, *cache.CacheServiceImpl, error) {
+	log := logger.NewWithOutput(os.Stderr, slog.LevelWarn)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	cacheConfig := newCacheServiceConfig(cfg)
+	cacheConfig.Eviction = cache.EvictionConfig{}
+
+	var store cache.VectorStore
+	switch cfg.CacheBackend {
+	case "memory":
+		return nil, nil, errors.New("CACHE_BACKEND=memory keeps entries inside the running gateway; use its /admin/cache endpoints instead")
+	case "bolt":
+		// The gateway holds the file while it runs
+		boltStore, err := cache.NewBoltStore(newBoltStoreConfig(cfg), log)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w; stop the gateway or use its /admin/cache endpoints", err)
+		}
+		store = boltStore
+	case "sqlite":
+		// Other processes may open the file while the gateway runs
+		sqliteStore, err := cache.NewSQLiteStore(newSQLiteStoreConfig(cfg), log)
+		if err != nil {
+			return nil, nil, err
+		}
+		store = sqliteStore
+	default:
+		redisClient, err := cache.NewRedisClient(newRedisConfig(cfg), log)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := redisClient.Ping(ctx); err != nil {
+			redisClient.Close()
+			return nil, nil, err
+		}
+		store = cache.NewRedisStore(redisClient, newRedisStoreConfig(cfg))
+	}
+
+	svc, err := cache.NewCacheService(store, log, cacheConfig)
+	if err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	return cfg, svc, nil
+}
@@ -14,12 +14,22 @@ import (
 	"semantic-cache-gateway/internal/config"
 	"semantic-cache-gateway/internal/embedding"
 	"semantic-cache-gateway/internal/handler"
+	"semantic-cache-gateway/internal/lexical"
 	"semantic-cache-gateway/internal/logger"
 	"semantic-cache-gateway/internal/middleware"
+	"semantic-cache-gateway/internal/models"
+	"semantic-cache-gateway/internal/policy"
 	"semantic-cache-gateway/internal/proxy"
+	"semantic-cache-gateway/internal/templates"
+	"semantic-cache-gateway/internal/verify"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	// Initialize logger
 	log := logger.New()
 	log.Info("starting semantic cache gateway")
@@ -35,41 +45,97 @@ func main() {
 		"port", cfg.Port,
 		"upstream_url", cfg.UpstreamURL,
 		"similarity_threshold", cfg.SimilarityThreshold,
+		"semantic_shadow_mode", cfg.SemanticShadowMode,
 	)
 
-	// Initialize Redis client
-	redisConfig := cache.DefaultRedisConfig(cfg.RedisURL)
-	redisClient, err := cache.NewRedisClient(redisConfig, log)
-	if err != nil {
-		log.Error("failed to create redis client", "error", err.Error())
-		os.Exit(1)
-	}
-	defer redisClient.Close()
+	// Initialize the cache store
+	var redisClient *cache.RedisClient
+	var store cache.VectorStore
+	switch cfg.CacheBackend {
+	case "memory":
+		memoryStore := cache.NewMemoryStore()
+		memoryStore.StartSweeping(cache.DefaultMemorySweepInterval)
+		store = memoryStore
+		log.Warn("using the in-memory cache store; entries are lost on restart and not shared between replicas")
+	case "bolt":
+		store, err = cache.NewBoltStore(newBoltStoreConfig(cfg), log)
+		if err != nil {
+			log.Error("failed to open cache file", "error", err.Error())
+			os.Exit(1)
+		}
+		log.Info("opened cache file", "path", cfg.CacheBoltPath)
+	case "sqlite":
+		store, err = cache.NewSQLiteStore(newSQLiteStoreConfig(cfg), log)
+		if err != nil {
+			log.Error("failed to open cache database", "error", err.Error())
+			os.Exit(1)
+		}
+		log.Info("opened cache database", "path", cfg.CacheSQLitePath)
+	default:
+		// Initialize Redis client
+		redisConfig := newRedisConfig(cfg)
+		if cfg.RedisBreakerFailures > 0 {
+			redisConfig.Breaker = &cache.BreakerConfig{
+				Failures: cfg.RedisBreakerFailures,
+				Cooldown: cfg.RedisBreakerCooldown,
+				OnStateChange: func(state string) {
+					log.Warn("redis circuit breaker changed state", "state", state)
+				},
+			}
+		}
+		redisClient, err = cache.NewRedisClient(redisConfig, log)
+		if err != nil {
+			log.Error("failed to create redis client", "error", err.Error())
+			os.Exit(1)
+		}
+		defer redisClient.Close()
 
-	// Check Redis connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	if err := redisClient.Ping(ctx); err != nil {
-		log.Error("failed to connect to redis", "error", err.Error())
+		// Check Redis connection; without retries the gateway cannot start without it
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := redisClient.Ping(ctx); err != nil {
+			if cfg.RedisRetryInterval <= 0 {
+				log.Error("failed to connect to redis", "error", err.Error())
+				cancel()
+				os.Exit(1)
+			}
+			log.Warn("redis unavailable at startup, passing requests through", "error", err.Error())
+		} else {
+			log.Info("connected to redis", "mode", redisMode(cfg))
+		}
 		cancel()
-		os.Exit(1)
+		store = cache.NewRedisStore(redisClient, newRedisStoreConfig(cfg))
 	}
-	cancel()
-	log.Info("connected to redis", "url", cfg.RedisURL)
-
 
 	// Initialize cache service
-	cacheService, err := cache.NewCacheService(redisClient, log, nil)
+	cacheConfig := newCacheServiceConfig(cfg)
+	cacheConfig.Eviction.OnEvict = handler.RecordEvictions
+	cacheConfig.RetryInterval = cfg.RedisRetryInterval
+	cacheConfig.L1 = cache.L1Config{MaxEntries: cfg.CacheL1MaxEntries, TTL: cfg.CacheL1TTL}
+	cacheService, err := cache.NewCacheService(store, log, cacheConfig)
 	if err != nil {
 		log.Error("failed to create cache service", "error", err.Error())
 		os.Exit(1)
 	}
 	defer cacheService.Close()
-	log.Info("cache service initialized")
+	log.Info("cache service initialized",
+		"backend", cfg.CacheBackend,
+		"ttl", cfg.CacheTTL.String(),
+		"sliding_ttl", cfg.CacheSlidingTTL,
+		"stale_window", cfg.CacheStaleWindow.String(),
+		"max_entries", cfg.CacheMaxEntries,
+		"max_bytes", cfg.CacheMaxBytes,
+		"eviction_policy", cfg.CacheEvictionPolicy,
+		"compression", cfg.CacheCompression,
+		"vector_type", cfg.CacheVectorType,
+		"l1_max_entries", cfg.CacheL1MaxEntries,
+		"l1_ttl", cfg.CacheL1TTL.String(),
+		"ready", cacheService.Ready(),
+	)
 
 	// Initialize embedding service
-	embeddingConfig := embedding.DefaultConfig(cfg.EmbeddingAPIKey)
+	embeddingConfig := newEmbeddingConfig(cfg)
 	embeddingService := embedding.NewService(embeddingConfig)
-	log.Info("embedding service initialized", "model", embeddingConfig.ModelName)
+	log.Info("embedding service initialized", "model", embeddingConfig.ModelName, "version", cfg.EmbeddingVersion)
 
 	// Initialize upstream proxy
 	proxyConfig := proxy.ProxyConfig{
@@ -88,9 +154,76 @@ func main() {
 		log.Info("upstream proxy initialized (using client auth headers)", "upstream_url", cfg.UpstreamURL)
 	}
 
+	// Initialize query normalization
+	normalizer, err := models.NewNormalizer(cfg.NormalizeConfig())
+	if err != nil {
+		log.Error("failed to create query normalizer", "error", err.Error())
+		os.Exit(1)
+	}
+	promptTemplates, err := loadTemplates(cfg)
+	if err != nil {
+		log.Error("failed to load prompt templates", "error", err.Error(), "path", cfg.PromptTemplatesFile)
+		os.Exit(1)
+	}
+	log.Info("query normalization configured", "steps", cfg.QueryNormalization, "prompt_templates", promptTemplates.Len())
+
+	lexicalGuard, err := lexical.New(cfg.LexicalGuardChecks)
+	if err != nil {
+		log.Error("failed to create lexical guard", "error", err.Error())
+		os.Exit(1)
+	}
+	log.Info("search scoping configured",
+		"partition_by", cfg.CachePartitionBy,
+		"search_max_age", cfg.CacheSearchMaxAge.String(),
+		"lexical_guard", cfg.LexicalGuardChecks,
+	)
+	previousSpace, err := startEmbeddingMigration(cfg, cacheService, queryEmbedder(embeddingService, normalizer, promptTemplates))
+	if err != nil {
+		// Entries of the previous space stay unsearchable until this is fixed
+		log.Error("failed to start embedding migration", "error", err.Error())
+	}
+
+	verifier := newVerifier(cfg, upstreamProxy)
+	if verifier != nil {
+		log.Info("hit verification enabled",
+			"verifier", cfg.HitVerifier,
+			"high_threshold", cfg.VerifierHighThreshold,
+			"cache_size", cfg.VerifierCacheSize,
+		)
+	}
+
 	// Initialize cache handler
+	cachePolicy := policy.New(policy.Config{
+		MaxTemperature: cfg.CacheMaxTemperature,
+		MaxN:           cfg.CacheMaxN,
+		AllowTools:     cfg.CacheAllowTools,
+		AllowUserField: cfg.CacheAllowUserField,
+		FinishReasons:  cfg.CacheFinishReasons,
+	})
 	handlerConfig := &handler.Config{
 		SimilarityThreshold: cfg.SimilarityThreshold,
+		Policy:              cachePolicy,
+		ExplainHeader:       cfg.DebugExplain,
+		AdminToken:          cfg.AdminToken,
+		Normalizer:          normalizer,
+		Templates:           promptTemplates,
+		PartitionBy:         cfg.CachePartitionBy,
+		TenantHeader:        cfg.TenantHeader,
+		SearchMaxAge:        cfg.CacheSearchMaxAge,
+		LexicalGuard:        lexicalGuard,
+		PreviousSpace:       previousSpace,
+
+		Verifier:            verifier,
+		VerifyHighThreshold: cfg.VerifierHighThreshold,
+		VerifyTimeout:       cfg.VerifierTimeout,
+
+		ShadowMode:               cfg.SemanticShadowMode,
+		ShadowAgreementThreshold: cfg.ShadowAgreementThreshold,
+
+		FeedbackWindow:           cfg.FeedbackWindow,
+		FeedbackMaxHits:          cfg.FeedbackMaxHits,
+		FeedbackMaxComplaints:    cfg.FeedbackMaxComplaints,
+		FeedbackThresholdPenalty: cfg.FeedbackThresholdPenalty,
 	}
 	cacheHandler := handler.New(cacheService, embeddingService, upstreamProxy, log, handlerConfig)
 
@@ -103,7 +236,12 @@ func main() {
 	mux.Handle("/v1/chat/completions", chatHandler)
 
 	// Health check endpoint
-	mux.HandleFunc("/health", handler.HealthHandler(redisClient))
+	var redisHealth interface{ IsHealthy(context.Context) bool }
+	if redisClient != nil {
+		redisHealth = redisClient
+	}
+	mux.HandleFunc("/health", handler.HealthHandler(redisHealth, cacheService))
+	mux.HandleFunc("/ready", handler.ReadyHandler(redisHealth, cacheService))
 
 	// Stats endpoints
 	mux.HandleFunc("/stats", handler.StatsDashboard)
@@ -112,6 +250,25 @@ func main() {
 	// Cache management endpoint
 	mux.HandleFunc("/cache/clear", handler.ClearCacheHandler(cacheService))
 
+	// Feedback on cache hits
+	mux.HandleFunc("/feedback", cacheHandler.FeedbackHandler(cacheService))
+
+	// Admin endpoints, disabled without ADMIN_TOKEN
+	exportOpts := cache.ExportOptions{
+		EmbeddingModel: embeddingConfig.ModelName,
+		Dimensions:     embeddingConfig.Dimensions,
+	}
+	importOpts := cache.ImportOptions{
+		EmbeddingModel:   embeddingConfig.ModelName,
+		Dimensions:       embeddingConfig.Dimensions,
+		EmbeddingVersion: cfg.EmbeddingVersion,
+		Embed:            queryEmbedder(embeddingService, normalizer, promptTemplates),
+	}
+	mux.Handle("/admin/cache/export", middleware.RequireToken(cfg.AdminToken, handler.ExportHandler(cacheService, exportOpts)))
+	mux.Handle("/admin/cache/import", middleware.RequireToken(cfg.AdminToken, handler.ImportHandler(cacheService, importOpts, cfg.AdminImportMaxBytes)))
+	mux.Handle("/admin/cache/entries/", middleware.RequireToken(cfg.AdminToken, handler.EntryHandler(cacheService, "/admin/cache/entries/")))
+	mux.Handle("/debug/explain", middleware.RequireToken(cfg.AdminToken, cacheHandler.ExplainHandler()))
+
 	// Create HTTP server
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
@@ -138,7 +295,7 @@ func main() {
 	log.Info("shutting down server...")
 
 	// Graceful shutdown with timeout
-	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
@@ -147,3 +304,167 @@ func main() {
 
 	log.Info("server stopped")
 }
+
+// newRedisConfig builds the Redis connection configuration from the gateway config.
+func newRedisConfig(cfg *config.Config) *cache.RedisConfig {
+	redisConfig := cache.DefaultRedisConfig(cfg.RedisURL)
+	redisConfig.Username = cfg.RedisUsername
+	redisConfig.Password = cfg.RedisPassword
+	redisConfig.SentinelMaster = cfg.RedisSentinelMaster
+	redisConfig.SentinelAddrs = cfg.RedisSentinelAddrs
+	redisConfig.SentinelPassword = cfg.RedisSentinelPassword
+	redisConfig.ClusterAddrs = cfg.RedisClusterAddrs
+	redisConfig.TLS = cfg.RedisTLS
+	redisConfig.TLSCAFile = cfg.RedisTLSCAFile
+	redisConfig.TLSCertFile = cfg.RedisTLSCertFile
+	redisConfig.TLSKeyFile = cfg.RedisTLSKeyFile
+	redisConfig.TLSServerName = cfg.RedisTLSServerName
+	return redisConfig
+}
+
+// newRedisStoreConfig builds the RediSearch store configuration from the gateway config.
+func newRedisStoreConfig(cfg *config.Config) cache.RedisStoreConfig {
+	return cache.RedisStoreConfig{
+		VectorType:        cfg.CacheVectorType,
+		Algorithm:         cfg.CacheIndexAlgorithm,
+		Metric:            cfg.CacheIndexMetric,
+		M:                 cfg.CacheHNSWM,
+		EFConstruction:    cfg.CacheHNSWEFConstruction,
+		EFRuntime:         cfg.CacheHNSWEFRuntime,
+		InitialCap:        cfg.CacheIndexInitialCap,
+		AutoRecreateIndex: cfg.CacheIndexAutoRecreate,
+	}
+}
+
+// newBoltStoreConfig builds the cache file store configuration from the gateway
+// config. Opening fails rather than waits while another process holds the file.
+func newBoltStoreConfig(cfg *config.Config) cache.BoltStoreConfig {
+	return cache.BoltStoreConfig{
+		Path:          cfg.CacheBoltPath,
+		SweepInterval: cfg.CacheBoltSweepInterval,
+		FlushInterval: cfg.CacheBoltFlushInterval,
+		OpenTimeout:   time.Second,
+	}
+}
+
+// newSQLiteStoreConfig builds the cache database store configuration from the
+// gateway config.
+func newSQLiteStoreConfig(cfg *config.Config) cache.SQLiteStoreConfig {
+	return cache.SQLiteStoreConfig{
+		Path:          cfg.CacheSQLitePath,
+		SweepInterval: cfg.CacheSQLiteSweepInterval,
+	}
+}
+
+// redisMode names the Redis deployment the gateway connects to.
+func redisMode(cfg *config.Config) string {
+	switch {
+	case cfg.RedisSentinelMaster != "":
+		return "sentinel"
+	case len(cfg.RedisClusterAddrs) > 0:
+		return "cluster"
+	default:
+		return "standalone"
+	}
+}
+
+// newCacheServiceConfig builds the cache service configuration from the gateway config.
+func newCacheServiceConfig(cfg *config.Config) *cache.CacheServiceConfig {
+	cacheConfig := cache.DefaultCacheServiceConfig()
+	cacheConfig.Dimensions = cfg.EmbeddingDimensions
+	cacheConfig.EmbeddingModel = cfg.EmbeddingModel
+	cacheConfig.EmbeddingVersion = cfg.EmbeddingVersion
+	cacheConfig.TTL = cfg.CacheTTL
+	cacheConfig.SlidingTTL = cfg.CacheSlidingTTL
+	cacheConfig.StaleWindow = cfg.CacheStaleWindow
+	cacheConfig.Compression = cfg.CacheCompression
+	cacheConfig.CompressionMinBytes = cfg.CacheCompressionMinBytes
+	cacheConfig.Eviction = cache.EvictionConfig{
+		MaxEntries: cfg.CacheMaxEntries,
+		MaxBytes:   cfg.CacheMaxBytes,
+		Policy:     cfg.CacheEvictionPolicy,
+		Interval:   cfg.CacheEvictionInterval,
+	}
+	return cacheConfig
+}
+
+// newEmbeddingConfig builds the embedding service configuration from the gateway config.
+func newEmbeddingConfig(cfg *config.Config) embedding.Config {
+	embeddingConfig := embedding.DefaultConfig(cfg.EmbeddingAPIKey)
+	embeddingConfig.APIEndpoint = cfg.EmbeddingURL
+	embeddingConfig.ModelName = cfg.EmbeddingModel
+	embeddingConfig.Dimensions = cfg.EmbeddingDimensions
+	return embeddingConfig
+}
+
+// loadTemplates reads the prompt templates file, if one is configured.
+func loadTemplates(cfg *config.Config) (*templates.Set, error) {
+	if cfg.PromptTemplatesFile == "" {
+		return nil, nil
+	}
+	return templates.Load(cfg.PromptTemplatesFile)
+}
+
+// startEmbeddingMigration moves entries from the previous embedding space
+// into the current one. With EMBEDDING_PREVIOUS_MODEL set, entries are
+// re-embedded and the previous model answers searches until they are done.
+// Otherwise entries written before embedding spaces were recorded are adopted
+// as they are. It returns nil when there is nothing to migrate.
+func startEmbeddingMigration(
+	cfg *config.Config,
+	svc *cache.CacheServiceImpl,
+	reembed func(ctx context.Context, text string) ([]float32, error),
+) (*handler.PreviousSpace, error) {
+	migrationConfig := cache.MigrationConfig{
+		From:      cache.EmbeddingSpace{Model: cfg.EmbeddingModel, Dimensions: cfg.EmbeddingDimensions},
+		BatchSize: cfg.EmbeddingMigrationBatch,
+		Interval:  cfg.EmbeddingMigrationInterval,
+	}
+	var previousEmbedding embedding.EmbeddingService
+	if cfg.EmbeddingPreviousModel != "" {
+		migrationConfig.From = cache.EmbeddingSpace{
+			Model:      cfg.EmbeddingPreviousModel,
+			Version:    cfg.EmbeddingPreviousVersion,
+			Dimensions: cfg.EmbeddingPreviousDimensions,
+		}
+		migrationConfig.Reembed = reembed
+
+		previousConfig := newEmbeddingConfig(cfg)
+		previousConfig.ModelName = cfg.EmbeddingPreviousModel
+		previousConfig.Dimensions = cfg.EmbeddingPreviousDimensions
+		previousEmbedding = embedding.NewService(previousConfig)
+	}
+
+	migration, err := svc.StartMigration(migrationConfig)
+	if err != nil || migration == nil {
+		return nil, err
+	}
+	return &handler.PreviousSpace{Index: migration, Embedding: previousEmbedding}, nil
+}
+
+// newVerifier builds the configured hit verifier, wrapped in a verdict cache.
+// It returns nil when verification is disabled.
+func newVerifier(cfg *config.Config, upstreamProxy proxy.UpstreamProxy) verify.HitVerifier {
+	var v verify.HitVerifier
+	switch cfg.HitVerifier {
+	case verify.KindLLM:
+		v = verify.NewLLM(upstreamProxy, cfg.VerifierModel)
+	case verify.KindReranker:
+		v = verify.NewReranker(cfg.VerifierURL, cfg.VerifierMinScore, cfg.VerifierTimeout)
+	default:
+		return nil
+	}
+	if cfg.VerifierCacheSize > 0 {
+		v = verify.NewCache(v, cfg.VerifierCacheSize)
+	}
+	return v
+}
+
+// queryEmbedder embeds text the way the chat handler embeds queries: only the
+// meaningful part of templated prompts, normalized.
+func queryEmbedder(svc *embedding.Service, normalizer *models.Normalizer, promptTemplates *templates.Set) func(ctx context.Context, text string) ([]float32, error) {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		lookup, _ := handler.LookupText(normalizer, promptTemplates, text)
+		return svc.Generate(ctx, lookup)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// defaultCorpus is used when no -corpus file is given.
+var defaultCorpus = []string{
+	"What is the capital of France?",
+	"How does photosynthesis work?",
+	"Explain the theory of relativity in simple terms.",
+	"What are the health benefits of green tea?",
+	"How do I reverse a linked list in Go?",
+	"What is the difference between TCP and UDP?",
+	"Who wrote Pride and Prejudice?",
+	"How many planets are in the solar system?",
+	"What causes the seasons on Earth?",
+	"How do vaccines train the immune system?",
+	"What is a good recipe for banana bread?",
+	"Explain how a hash map works.",
+	"What is the boiling point of water at sea level?",
+	"How do I center a div with CSS?",
+	"What is the tallest mountain in the world?",
+	"Why is the sky blue?",
+	"What is the population of Japan?",
+	"How does compound interest work?",
+	"What is machine learning?",
+	"How do I make a cup of pour-over coffee?",
+}
+
+// paraphrasePrefixes and paraphraseSuffixes wrap a prompt without changing its meaning.
+var paraphrasePrefixes = []string{"", "Please tell me: ", "Quick question - ", "Can you tell me ", "I'd like to know: "}
+var paraphraseSuffixes = []string{"", " Thanks!", " Please be brief.", "", " (asking for a friend)"}
+
+// loadCorpus reads one prompt per non-empty line, or returns the default corpus.
+func loadCorpus(path string) ([]string, error) {
+	if path == "" {
+		return defaultCorpus, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var prompts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			prompts = append(prompts, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("corpus %s is empty", path)
+	}
+	return prompts, nil
+}
+
+// promptKind labels how a generated prompt relates to earlier prompts.
+type promptKind string
+
+const (
+	kindUnique     promptKind = "unique"
+	kindDuplicate  promptKind = "duplicate"
+	kindParaphrase promptKind = "paraphrase"
+)
+
+// promptGenerator yields prompts with controlled duplicate and paraphrase ratios.
+type promptGenerator struct {
+	corpus          []string
+	dupRatio        float64
+	paraphraseRatio float64
+	rng             *rand.Rand
+	sent            []string
+	next            int
+}
+
+func newPromptGenerator(corpus []string, dupRatio, paraphraseRatio float64, seed int64) *promptGenerator {
+	return &promptGenerator{
+		corpus:          corpus,
+		dupRatio:        dupRatio,
+		paraphraseRatio: paraphraseRatio,
+		rng:             rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Next returns the next prompt. It is not safe for concurrent use.
+func (g *promptGenerator) Next() (string, promptKind) {
+	if len(g.sent) > 0 {
+		roll := g.rng.Float64()
+		switch {
+		case roll < g.dupRatio:
+			return g.sent[g.rng.Intn(len(g.sent))], kindDuplicate
+		case roll < g.dupRatio+g.paraphraseRatio:
+			return g.paraphrase(g.sent[g.rng.Intn(len(g.sent))]), kindParaphrase
+		}
+	}
+
+	// Cycle through the corpus; after the first pass, suffix prompts so they stay unique
+	prompt := g.corpus[g.next%len(g.corpus)]
+	if round := g.next / len(g.corpus); round > 0 {
+		prompt = fmt.Sprintf("%s (variant %d)", prompt, round)
+	}
+	g.next++
+	g.sent = append(g.sent, prompt)
+	return prompt, kindUnique
+}
+
+// paraphrase rewrites a prompt with filler and casing changes that keep its meaning.
+func (g *promptGenerator) paraphrase(prompt string) string {
+	for {
+		prefix := paraphrasePrefixes[g.rng.Intn(len(paraphrasePrefixes))]
+		suffix := paraphraseSuffixes[g.rng.Intn(len(paraphraseSuffixes))]
+		body := prompt
+		if prefix != "" {
+			body = strings.ToLower(body[:1]) + body[1:]
+		}
+		if g.rng.Intn(2) == 0 {
+			body = strings.TrimRight(body, "?.!")
+		}
+		if out := prefix + body + suffix; out != prompt {
+			return out
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Command loadgen replays a prompt corpus against the gateway and reports hit
+// rate and latency by cache status.
+//
+// With -mock it also serves a fake upstream and fake embedding API, so the
+// whole benchmark runs offline:
+//
+//	loadgen -mock :9090 &
+//	UPSTREAM_URL=http://localhost:9090/v1 EMBEDDING_URL=http://localhost:9090/v1/embeddings gateway
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"semantic-cache-gateway/internal/mockllm"
+	"semantic-cache-gateway/internal/models"
+)
+
+type options struct {
+	url             string
+	corpus          string
+	requests        int
+	duration        time.Duration
+	rps             float64
+	concurrency     int
+	dupRatio        float64
+	paraphraseRatio float64
+	model           string
+	apiKey          string
+	seed            int64
+	timeout         time.Duration
+	mockAddr        string
+	mockLatency     time.Duration
+	mockOnly        bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.url, "url", "http://localhost:8080/v1/chat/completions", "gateway chat completions URL")
+	flag.StringVar(&opts.corpus, "corpus", "", "file with one prompt per line (default: built-in corpus)")
+	flag.IntVar(&opts.requests, "requests", 200, "number of requests to send (ignored when -duration is set)")
+	flag.DurationVar(&opts.duration, "duration", 0, "run for this long instead of a fixed request count")
+	flag.Float64Var(&opts.rps, "rps", 20, "target requests per second (0 for unlimited)")
+	flag.IntVar(&opts.concurrency, "concurrency", 10, "maximum requests in flight")
+	flag.Float64Var(&opts.dupRatio, "dup", 0.5, "fraction of requests that repeat an earlier prompt exactly")
+	flag.Float64Var(&opts.paraphraseRatio, "paraphrase", 0.2, "fraction of requests that paraphrase an earlier prompt")
+	flag.StringVar(&opts.model, "model", "gpt-3.5-turbo", "model name sent in requests")
+	flag.StringVar(&opts.apiKey, "api-key", os.Getenv("OPENAI_API_KEY"), "bearer token sent to the gateway")
+	flag.Int64Var(&opts.seed, "seed", 1, "random seed for prompt selection")
+	flag.DurationVar(&opts.timeout, "timeout", 60*time.Second, "per-request timeout")
+	flag.StringVar(&opts.mockAddr, "mock", "", "serve a fake upstream and embedding API on this address (e.g. :9090)")
+	flag.DurationVar(&opts.mockLatency, "mock-latency", 300*time.Millisecond, "latency of the fake upstream")
+	flag.BoolVar(&opts.mockOnly, "mock-only", false, "only serve the fake APIs until interrupted")
+	flag.Parse()
+
+	if err := validate(opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.mockAddr != "" {
+		if err := startMock(ctx, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to start mock server: %v\n", err)
+			os.Exit(1)
+		}
+		if opts.mockOnly {
+			<-ctx.Done()
+			return
+		}
+	}
+
+	corpus, err := loadCorpus(opts.corpus)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load corpus: %v\n", err)
+		os.Exit(1)
+	}
+
+	rep := run(ctx, opts, corpus)
+	rep.print(os.Stdout)
+}
+
+func validate(opts options) error {
+	switch {
+	case opts.concurrency < 1:
+		return errors.New("-concurrency must be at least 1")
+	case opts.rps < 0:
+		return errors.New("-rps must not be negative")
+	case opts.dupRatio < 0 || opts.paraphraseRatio < 0 || opts.dupRatio+opts.paraphraseRatio > 1:
+		return errors.New("-dup and -paraphrase must be non-negative and sum to at most 1")
+	case opts.duration <= 0 && opts.requests < 1:
+		return errors.New("-requests must be at least 1")
+	}
+	return nil
+}
+
+// startMock serves the fake upstream and embedding API in the background.
+func startMock(ctx context.Context, opts options) error {
+	cfg := mockllm.DefaultConfig()
+	cfg.CompletionLatency = opts.mockLatency
+	server := &http.Server{Addr: opts.mockAddr, Handler: mockllm.New(cfg)}
+
+	ln, err := net.Listen("tcp", opts.mockAddr)
+	if err != nil {
+		return err
+	}
+	go server.Serve(ln)
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	base := "http://" + strings.Replace(ln.Addr().String(), "[::]", "localhost", 1)
+	fmt.Fprintf(os.Stderr, "mock upstream listening on %s\n", ln.Addr())
+	fmt.Fprintf(os.Stderr, "  UPSTREAM_URL=%s/v1 EMBEDDING_URL=%s/v1/embeddings\n", base, base)
+	return nil
+}
+
+// run sends requests according to opts and returns the aggregated report.
+func run(ctx context.Context, opts options, corpus []string) *report {
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	type job struct {
+		prompt string
+		kind   promptKind
+	}
+	jobs := make(chan job)
+	gen := newPromptGenerator(corpus, opts.dupRatio, opts.paraphraseRatio, opts.seed)
+
+	// Producer paces requests at the target rate
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if opts.rps > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.rps))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for sent := 0; opts.duration > 0 || sent < opts.requests; sent++ {
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prompt, kind := gen.Next()
+			select {
+			case jobs <- job{prompt: prompt, kind: kind}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	client := &http.Client{Timeout: opts.timeout}
+	rep := newReport()
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res := send(client, opts, j.prompt)
+				res.kind = j.kind
+				rep.add(res)
+			}
+		}()
+	}
+	wg.Wait()
+	rep.finish()
+	return rep
+}
+
+// send issues one chat completion request. It uses a background context so
+// requests already in flight complete when the run ends.
+func send(client *http.Client, opts options, prompt string) result {
+	temperature := 0.0
+	body, _ := json.Marshal(models.ChatCompletionRequest{
+		Model:       opts.model,
+		Messages:    []models.Message{{Role: "user", Content: prompt}},
+		Temperature: &temperature,
+	})
+	req, err := http.NewRequest(http.MethodPost, opts.url, bytes.NewReader(body))
+	if err != nil {
+		return result{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.apiKey)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{err: err}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	return result{
+		cacheStatus: resp.Header.Get("X-Cache-Status"),
+		statusCode:  resp.StatusCode,
+		latency:     time.Since(start),
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// result is the outcome of a single request.
+type result struct {
+	kind        promptKind
+	cacheStatus string
+	statusCode  int
+	latency     time.Duration
+	err         error
+}
+
+// report aggregates results. It is safe for concurrent use.
+type report struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	byKind    map[promptKind]map[string]int
+	errors    map[string]int
+	total     int
+	start     time.Time
+	elapsed   time.Duration
+}
+
+func newReport() *report {
+	return &report{
+		latencies: make(map[string][]time.Duration),
+		byKind:    make(map[promptKind]map[string]int),
+		errors:    make(map[string]int),
+		start:     time.Now(),
+	}
+}
+
+func (r *report) add(res result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total++
+	if res.err != nil {
+		r.errors[res.err.Error()]++
+		return
+	}
+	if res.statusCode >= 400 {
+		r.errors[fmt.Sprintf("HTTP %d", res.statusCode)]++
+		return
+	}
+
+	status := res.cacheStatus
+	if status == "" {
+		status = "NONE"
+	}
+	r.latencies[status] = append(r.latencies[status], res.latency)
+	if r.byKind[res.kind] == nil {
+		r.byKind[res.kind] = make(map[string]int)
+	}
+	r.byKind[res.kind][status]++
+}
+
+func (r *report) finish() {
+	r.mu.Lock()
+	r.elapsed = time.Since(r.start)
+	r.mu.Unlock()
+}
+
+// percentile returns the p-th percentile (0-100) of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p / 100)
+	return sorted[idx]
+}
+
+func (r *report) print(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var hits, succeeded int
+	for status, l := range r.latencies {
+		succeeded += len(l)
+		if status == "HIT" || status == "STALE" {
+			hits += len(l)
+		}
+	}
+	var errorCount int
+	for _, n := range r.errors {
+		errorCount += n
+	}
+
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintln(w, "  LOAD TEST RESULTS")
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintf(w, "Requests:      %d in %s (%.1f req/s)\n", r.total, r.elapsed.Round(time.Millisecond), float64(r.total)/r.elapsed.Seconds())
+	fmt.Fprintf(w, "Succeeded:     %d\n", succeeded)
+	fmt.Fprintf(w, "Errors:        %d\n", errorCount)
+	if succeeded > 0 {
+		fmt.Fprintf(w, "Hit rate:      %.1f%%\n", float64(hits)/float64(succeeded)*100)
+	}
+
+	fmt.Fprintln(w, "\nLATENCY BY X-Cache-Status:")
+	fmt.Fprintf(w, "  %-8s %7s %9s %9s %9s %9s\n", "status", "count", "p50", "p90", "p99", "max")
+	statuses := make([]string, 0, len(r.latencies))
+	for status := range r.latencies {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		l := append([]time.Duration(nil), r.latencies[status]...)
+		sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+		fmt.Fprintf(w, "  %-8s %7d %9s %9s %9s %9s\n", status, len(l),
+			percentile(l, 50).Round(time.Microsecond*100),
+			percentile(l, 90).Round(time.Microsecond*100),
+			percentile(l, 99).Round(time.Microsecond*100),
+			l[len(l)-1].Round(time.Microsecond*100))
+	}
+
+	fmt.Fprintln(w, "\nCACHE STATUS BY PROMPT KIND:")
+	for _, kind := range []promptKind{kindUnique, kindDuplicate, kindParaphrase} {
+		counts := r.byKind[kind]
+		if len(counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %-10s", kind)
+		for _, status := range statuses {
+			fmt.Fprintf(w, " %s=%d", status, counts[status])
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(r.errors) > 0 {
+		fmt.Fprintln(w, "\nERRORS:")
+		for msg, n := range r.errors {
+			fmt.Fprintf(w, "  %5d  %s\n", n, msg)
+		}
+	}
+	fmt.Fprintln(w, "========================================")
+}
@@ -0,0 +1,66 @@
+// Command mockllm serves an offline stand-in for the OpenAI chat completion and
+// embedding APIs, so the gateway can run and be tested without network access:
+//
+//	mockllm -addr :9090 &
+//	UPSTREAM_URL=http://localhost:9090/v1 EMBEDDING_URL=http://localhost:9090/v1/embeddings gateway
+//
+// Completions are deterministic per prompt and support "stream": true. Latency
+// and failures can be injected with flags, or per request with the
+// X-Mock-Error header.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"semantic-cache-gateway/internal/mockllm"
+)
+
+func main() {
+	cfg := mockllm.DefaultConfig()
+	addr := flag.String("addr", ":9090", "listen address")
+	flag.IntVar(&cfg.Dimensions, "dimensions", cfg.Dimensions, "embedding dimensions")
+	flag.DurationVar(&cfg.CompletionLatency, "latency", cfg.CompletionLatency, "latency added to chat completions")
+	flag.DurationVar(&cfg.EmbeddingLatency, "embedding-latency", cfg.EmbeddingLatency, "latency added to embeddings")
+	flag.DurationVar(&cfg.LatencyJitter, "jitter", cfg.LatencyJitter, "random extra latency in [0, jitter)")
+	flag.DurationVar(&cfg.StreamChunkDelay, "chunk-delay", cfg.StreamChunkDelay, "delay between streamed chunks")
+	flag.Float64Var(&cfg.ErrorRate, "error-rate", cfg.ErrorRate, "fraction of requests that fail (0-1)")
+	flag.IntVar(&cfg.ErrorStatus, "error-status", cfg.ErrorStatus, "HTTP status returned by injected failures")
+	flag.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed for jitter and error injection")
+	flag.Parse()
+
+	if cfg.ErrorRate < 0 || cfg.ErrorRate > 1 {
+		fmt.Fprintln(os.Stderr, "-error-rate must be between 0 and 1")
+		os.Exit(2)
+	}
+	if cfg.ErrorStatus < 400 || cfg.ErrorStatus > 599 {
+		fmt.Fprintln(os.Stderr, "-error-status must be an HTTP error status")
+		os.Exit(2)
+	}
+
+	server := &http.Server{Addr: *addr, Handler: mockllm.New(cfg)}
+
+	go func() {
+		fmt.Fprintf(os.Stderr, "mock LLM listening on %s (dimensions=%d latency=%s error-rate=%.2f)\n",
+			*addr, cfg.Dimensions, cfg.CompletionLatency, cfg.ErrorRate)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "server error: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(ctx)
+}
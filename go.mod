@@ -3,11 +3,24 @@ module semantic-cache-gateway
 go 1.21
 
 require (
+	github.com/klauspost/compress v1.17.11
 	github.com/redis/go-redis/v9 v9.7.0
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/text v0.21.0
+	modernc.org/sqlite v1.34.5
 	pgregory.net/rapid v1.2.0
 )
 
 require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
 )
@@ -0,0 +1,547 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"semantic-cache-gateway/internal/logger"
+)
+
+const (
+	// DefaultBoltSweepInterval is how often a BoltStore removes expired entries.
+	DefaultBoltSweepInterval = time.Minute
+	// DefaultBoltFlushInterval is how often a BoltStore writes hit metadata.
+	DefaultBoltFlushInterval = 5 * time.Second
+
+	// compactMinBytes is the smallest database file worth compacting.
+	compactMinBytes = 1 << 20
+)
+
+// boltBucket holds one record per entry, keyed by the entry ID.
+var boltBucket = []byte("entries")
+
+// boltAccessBucket holds the access metadata and feedback of entries changed
+// since their record was written, keyed by the entry ID. It is kept apart so
+// hits do not rewrite the entry and its embedding.
+var boltAccessBucket = []byte("access")
+
+// boltAccess is the part of an entry that hits and feedback change. It is
+// decoded over the entry's record on load.
+type boltAccess struct {
+	HitCount       int64 `json:"hit_count"`
+	LastAccessedAt int64 `json:"last_accessed_at"`
+	ExpiresAt      int64 `json:"expires_at"`
+	FeedbackUp     int64 `json:"feedback_up"`
+	FeedbackDown   int64 `json:"feedback_down"`
+}
+
+// BoltStoreConfig configures a BoltStore.
+type BoltStoreConfig struct {
+	// Path of the database file; it is created if it does not exist.
+	Path string
+	// SweepInterval is how often expired entries are removed from the file.
+	SweepInterval time.Duration
+	// FlushInterval is how often hits recorded by Touch are written to the
+	// file. Hits since the last flush are lost if the process crashes.
+	FlushInterval time.Duration
+	// OpenTimeout bounds the wait for another process to release the file.
+	// Zero waits forever.
+	OpenTimeout time.Duration
+}
+
+// BoltStore persists entries in an embedded bbolt database file and serves
+// reads and searches from an in-memory index rebuilt from the file on
+// startup. It needs no external service, but the file can only be opened by
+// one process at a time, so it suits single-replica deployments.
+//
+// Hits only update the index and are written to the file together every
+// FlushInterval. Expired entries are removed from the file every
+// SweepInterval. Freed pages are reused by later writes; once more than half
+// of the file is free it is compacted into a new file.
+type BoltStore struct {
+	path   string
+	index  *MemoryStore
+	logger *logger.Logger
+
+	// mu guards db, which compaction replaces.
+	mu sync.RWMutex
+	db *bolt.DB
+
+	// touched holds the IDs of entries hit since the last flush.
+	touchedMu sync.Mutex
+	touched   map[string]struct{}
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewBoltStore opens the database file, drops the entries that expired while
+// it was closed, loads the rest into memory and starts sweeping.
+func NewBoltStore(cfg BoltStoreConfig, log *logger.Logger) (*BoltStore, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("bolt store path is empty")
+	}
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = DefaultBoltSweepInterval
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultBoltFlushInterval
+	}
+
+	db, err := openBolt(cfg.Path, cfg.OpenTimeout)
+	if err != nil {
+		return nil, err
+	}
+	s := &BoltStore{
+		path:    cfg.Path,
+		index:   NewMemoryStore(),
+		logger:  log,
+		db:      db,
+		touched: make(map[string]struct{}),
+		done:    make(chan struct{}),
+	}
+	if err := s.load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.compactIfSparse(); err != nil {
+		s.logger.Warn("failed to compact cache file", "path", s.path, "error", err.Error())
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		sweepTicker := time.NewTicker(cfg.SweepInterval)
+		defer sweepTicker.Stop()
+		flushTicker := time.NewTicker(cfg.FlushInterval)
+		defer flushTicker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-flushTicker.C:
+				if err := s.flush(); err != nil {
+					s.logger.Error("failed to write cache hits", "path", s.path, "error", err.Error())
+				}
+			case <-sweepTicker.C:
+				if _, err := s.sweep(); err != nil {
+					s.logger.Error("failed to sweep cache file", "path", s.path, "error", err.Error())
+				}
+			}
+		}
+	}()
+	return s, nil
+}
+
+func openBolt(path string, timeout time.Duration) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("cache file %s is in use by another process", path)
+		}
+		return nil, fmt.Errorf("failed to open cache file %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltAccessBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// encodeBoltRecord prefixes the JSON of an entry or its boltAccess with its
+// deadline in Unix nanoseconds, zero for none, so sweeps need not decode it.
+func encodeBoltRecord(v interface{}, deadline time.Time) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, 8, 8+len(data))
+	if !deadline.IsZero() {
+		binary.BigEndian.PutUint64(record, uint64(deadline.UnixNano()))
+	}
+	return append(record, data...), nil
+}
+
+// boltDeadline returns the deadline of a record.
+func boltDeadline(record []byte) (time.Time, error) {
+	if len(record) < 8 {
+		return time.Time{}, errors.New("cache record is truncated")
+	}
+	nanos := binary.BigEndian.Uint64(record)
+	if nanos == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, int64(nanos)), nil
+}
+
+// expiredIDs returns the IDs of the entries whose deadline has passed. An
+// access record, written when a hit slid the deadline, takes precedence.
+func expiredIDs(tx *bolt.Tx, now time.Time) ([][]byte, error) {
+	access := tx.Bucket(boltAccessBucket)
+	var ids [][]byte
+	err := tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+		if a := access.Get(k); a != nil {
+			v = a
+		}
+		deadline, err := boltDeadline(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		if !deadline.IsZero() && !now.Before(deadline) {
+			ids = append(ids, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// deleteRecords removes the entries' records and access records.
+func deleteRecords(tx *bolt.Tx, ids ...[]byte) error {
+	for _, id := range ids {
+		if err := tx.Bucket(boltBucket).Delete(id); err != nil {
+			return err
+		}
+		if err := tx.Bucket(boltAccessBucket).Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load drops expired records and loads the others into the index.
+func (s *BoltStore) load() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, access := tx.Bucket(boltBucket), tx.Bucket(boltAccessBucket)
+		expired, err := expiredIDs(tx, s.index.now())
+		if err != nil {
+			return err
+		}
+		if err := deleteRecords(tx, expired...); err != nil {
+			return err
+		}
+		err = bucket.ForEach(func(k, v []byte) error {
+			deadline, _ := boltDeadline(v)
+			var entry CacheEntry
+			if err := json.Unmarshal(v[8:], &entry); err != nil {
+				return fmt.Errorf("failed to decode cache record %s: %w", k, err)
+			}
+			if a := access.Get(k); a != nil {
+				deadline, _ = boltDeadline(a)
+				if err := json.Unmarshal(a[8:], &entry); err != nil {
+					return fmt.Errorf("failed to decode access record %s: %w", k, err)
+				}
+			}
+			s.index.put(&entry, deadline)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Access records of entries that are gone
+		var orphans [][]byte
+		access.ForEach(func(k, v []byte) error {
+			if bucket.Get(k) == nil {
+				orphans = append(orphans, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, id := range orphans {
+			if err := access.Delete(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// update runs fn in a write transaction. Index changes are made inside fn,
+// so they are ordered like the writes to the file.
+func (s *BoltStore) update(fn func(tx *bolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db.Update(fn)
+}
+
+// persist writes the indexed entry stored under id to the file, if it still
+// exists. The record then holds the entry's access metadata, so its access
+// record is dropped.
+func (s *BoltStore) persist(tx *bolt.Tx, id string) error {
+	entry, deadline, ok := s.index.snapshot(id)
+	if !ok {
+		return nil
+	}
+	record, err := encodeBoltRecord(&entry, deadline)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(boltBucket).Put([]byte(id), record); err != nil {
+		return err
+	}
+	return tx.Bucket(boltAccessBucket).Delete([]byte(id))
+}
+
+// persistAccess writes the access metadata of the indexed entry stored under
+// id to its access record, leaving the entry's record and embedding as they
+// are. Entries that no longer exist are skipped.
+func (s *BoltStore) persistAccess(tx *bolt.Tx, id string) error {
+	entry, deadline, ok := s.index.snapshot(id)
+	if !ok || tx.Bucket(boltBucket).Get([]byte(id)) == nil {
+		return nil
+	}
+	record, err := encodeBoltRecord(boltAccess{
+		HitCount:       entry.HitCount,
+		LastAccessedAt: entry.LastAccessedAt,
+		ExpiresAt:      entry.ExpiresAt,
+		FeedbackUp:     entry.FeedbackUp,
+		FeedbackDown:   entry.FeedbackDown,
+	}, deadline)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(boltAccessBucket).Put([]byte(id), record)
+}
+
+// takeTouched returns the IDs hit since the last call.
+func (s *BoltStore) takeTouched() []string {
+	s.touchedMu.Lock()
+	defer s.touchedMu.Unlock()
+	ids := make([]string, 0, len(s.touched))
+	for id := range s.touched {
+		ids = append(ids, id)
+	}
+	s.touched = make(map[string]struct{})
+	return ids
+}
+
+// flushTouched writes the access records of the entries hit since the last flush.
+func (s *BoltStore) flushTouched(tx *bolt.Tx) error {
+	for _, id := range s.takeTouched() {
+		if err := s.persistAccess(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush writes the hits recorded since the last flush in one transaction.
+func (s *BoltStore) flush() error {
+	return s.update(s.flushTouched)
+}
+
+// sweep removes expired entries from the file and the index, compacts the
+// file if it has become sparse and returns the number of removed entries.
+// Pending hits are flushed first, since they may have slid deadlines.
+func (s *BoltStore) sweep() (int, error) {
+	var removed int
+	err := s.update(func(tx *bolt.Tx) error {
+		if err := s.flushTouched(tx); err != nil {
+			return err
+		}
+		expired, err := expiredIDs(tx, s.index.now())
+		if err != nil {
+			return err
+		}
+		removed = len(expired)
+		return deleteRecords(tx, expired...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.index.purge()
+	if removed > 0 {
+		s.logger.Debug("expired cache entries swept", "path", s.path, "removed", removed)
+	}
+	return removed, s.compactIfSparse()
+}
+
+// compactIfSparse compacts the file once more than half of it is free.
+func (s *BoltStore) compactIfSparse() error {
+	s.mu.RLock()
+	free := int64(s.db.Stats().FreeAlloc)
+	var size int64
+	s.db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	s.mu.RUnlock()
+
+	if size < compactMinBytes || free*2 < size {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact copies the live records into a new file and replaces the old one
+// with it. Writes wait until it is done; reads are served from the index.
+func (s *BoltStore) compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmpPath := s.path + ".compact"
+	os.Remove(tmpPath)
+	dst, err := bolt.Open(tmpPath, 0o600, nil)
+	if err != nil {
+		return err
+	}
+	if err := bolt.Compact(dst, s.db, 0); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	before, _ := os.Stat(s.path)
+	// The old file stays mapped until it is closed, so a failed rename leaves it in use
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	s.db.Close()
+	s.db, err = bolt.Open(s.path, 0o600, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reopen compacted cache file %s: %w", s.path, err)
+	}
+	if after, err := os.Stat(s.path); err == nil && before != nil {
+		s.logger.Info("cache file compacted", "path", s.path, "bytes_before", before.Size(), "bytes_after", after.Size())
+	}
+	return nil
+}
+
+// Setup does nothing; entries of every space are searched by brute force.
+func (s *BoltStore) Setup(ctx context.Context, space EmbeddingSpace) error {
+	return nil
+}
+
+// Get returns a copy of the entry stored under id, or nil.
+func (s *BoltStore) Get(ctx context.Context, id string) (*CacheEntry, error) {
+	return s.index.Get(ctx, id)
+}
+
+// Upsert writes the entry to the file and the index.
+func (s *BoltStore) Upsert(ctx context.Context, entry *CacheEntry, ttl time.Duration) error {
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = s.index.now().Add(ttl)
+	}
+	record, err := encodeBoltRecord(entry, deadline)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltBucket).Put([]byte(entry.ID), record); err != nil {
+			return err
+		}
+		if err := tx.Bucket(boltAccessBucket).Delete([]byte(entry.ID)); err != nil {
+			return err
+		}
+		s.index.put(entry, deadline)
+		return nil
+	})
+}
+
+// Search compares query with every indexed entry matching the filter.
+func (s *BoltStore) Search(ctx context.Context, space EmbeddingSpace, query []float32, k int, filter SearchFilter) ([]Neighbor, error) {
+	return s.index.Search(ctx, space, query, k, filter)
+}
+
+// Delete removes the entries from the file and the index and returns how
+// many existed.
+func (s *BoltStore) Delete(ctx context.Context, ids ...string) (int64, error) {
+	var deleted int64
+	err := s.update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			if err := deleteRecords(tx, []byte(id)); err != nil {
+				return err
+			}
+		}
+		var err error
+		deleted, err = s.index.Delete(ctx, ids...)
+		return err
+	})
+	return deleted, err
+}
+
+// ScanIDs calls fn with the indexed IDs matching pattern.
+func (s *BoltStore) ScanIDs(ctx context.Context, pattern string, fn func(ids []string) error) error {
+	return s.index.ScanIDs(ctx, pattern, fn)
+}
+
+// Scan calls fn with a copy of every indexed entry.
+func (s *BoltStore) Scan(ctx context.Context, fn func(*CacheEntry) error) error {
+	return s.index.Scan(ctx, fn)
+}
+
+// Stats returns the entries' access metadata. Sizes are those of the
+// entries' JSON encoding.
+func (s *BoltStore) Stats(ctx context.Context, ids []string, withBytes bool) ([]EntryStats, error) {
+	return s.index.Stats(ctx, ids, withBytes)
+}
+
+// Touch updates the entry's access metadata in the index. It is written to
+// the file by the next flush.
+func (s *BoltStore) Touch(ctx context.Context, id string, accessedAt, expiresAt int64, ttl time.Duration) error {
+	if err := s.index.Touch(ctx, id, accessedAt, expiresAt, ttl); err != nil {
+		return err
+	}
+	s.touchedMu.Lock()
+	s.touched[id] = struct{}{}
+	s.touchedMu.Unlock()
+	return nil
+}
+
+// AddFeedback increments a feedback counter of the entry and writes its
+// access record at once.
+func (s *BoltStore) AddFeedback(ctx context.Context, id string, positive bool) (FeedbackCounts, error) {
+	counts, err := s.index.AddFeedback(ctx, id, positive)
+	if err != nil {
+		return counts, err
+	}
+	return counts, s.update(func(tx *bolt.Tx) error {
+		return s.persistAccess(tx, id)
+	})
+}
+
+// Retag moves the entry into the space. The whole record is rewritten, since
+// its embedding changes.
+func (s *BoltStore) Retag(ctx context.Context, id string, space EmbeddingSpace, vec []float32) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if err := s.index.Retag(ctx, id, space, vec); err != nil {
+			return err
+		}
+		return s.persist(tx, id)
+	})
+}
+
+// Close stops sweeping, writes pending hits and closes the file. Entries
+// stay on disk.
+func (s *BoltStore) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	if err := s.flush(); err != nil {
+		s.logger.Error("failed to write cache hits", "path", s.path, "error", err.Error())
+	}
+	s.index.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.Close()
+}
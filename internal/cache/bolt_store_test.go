@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"semantic-cache-gateway/internal/logger"
+)
+
+// openBoltStore opens a BoltStore on path and closes it when the test ends,
+// unless the test closed it already.
+func openBoltStore(t *testing.T, path string) *BoltStore {
+	t.Helper()
+	store, err := NewBoltStore(BoltStoreConfig{Path: path, SweepInterval: time.Hour, FlushInterval: time.Hour}, logger.New())
+	if err != nil {
+		t.Fatalf("NewBoltStore() error: %v", err)
+	}
+	t.Cleanup(func() {
+		select {
+		case <-store.done:
+		default:
+			store.Close()
+		}
+	})
+	return store
+}
+
+// boltRecords counts the records in the store's file.
+func boltRecords(t *testing.T, store *BoltStore) int {
+	t.Helper()
+	var n int
+	store.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(boltBucket).Stats().KeyN
+		return nil
+	})
+	return n
+}
+
+// TestBoltStore_Reopen verifies entries and their updates survive a restart
+// and are searchable again.
+func TestBoltStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	store := openBoltStore(t, path)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:x", Embedding: []float32{1, 0}, LLMResponse: "x"}, time.Hour)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:y", Embedding: []float32{0, 1}, LLMResponse: "y"}, 0)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:deleted", Embedding: []float32{1, 1}}, 0)
+	store.Touch(ctx, "cache:x", 1234, 0, 0)
+	if _, err := store.AddFeedback(ctx, "cache:y", false); err != nil {
+		t.Fatal(err)
+	}
+	store.Retag(ctx, "cache:y", EmbeddingSpace{Model: "m", Version: "2"}, []float32{0, 2})
+	store.Delete(ctx, "cache:deleted")
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store = openBoltStore(t, path)
+	x, _ := store.Get(ctx, "cache:x")
+	if x == nil || x.HitCount != 1 || x.LastAccessedAt != 1234 || x.LLMResponse != "x" {
+		t.Fatalf("touched entry not restored: %+v", x)
+	}
+	y, _ := store.Get(ctx, "cache:y")
+	if y == nil || y.FeedbackDown != 1 || y.EmbeddingVersion != "2" || y.Embedding[1] != 2 {
+		t.Fatalf("rated and retagged entry not restored: %+v", y)
+	}
+	if entry, _ := store.Get(ctx, "cache:deleted"); entry != nil {
+		t.Error("deleted entry came back")
+	}
+
+	neighbors, err := store.Search(ctx, EmbeddingSpace{}, []float32{1, 0.1}, 1, SearchFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighbors) != 1 || neighbors[0].Entry.ID != "cache:x" {
+		t.Errorf("unexpected neighbors after reopen: %+v", neighbors)
+	}
+}
+
+// TestBoltStore_Sweep verifies expired entries are removed from the file,
+// both by sweeps and when the file is reopened.
+func TestBoltStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	now := time.Now()
+	store := openBoltStore(t, path)
+	store.index.now = func() time.Time { return now }
+	store.Upsert(ctx, &CacheEntry{ID: "cache:swept"}, time.Minute)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:forever"}, 0)
+
+	now = now.Add(2 * time.Minute)
+	removed, err := store.sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 || boltRecords(t, store) != 1 {
+		t.Errorf("sweep removed %d entries, %d records left", removed, boltRecords(t, store))
+	}
+
+	// Expires while the store is closed
+	now = time.Now().Add(-time.Hour)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:stale"}, time.Minute)
+	store.Close()
+
+	store = openBoltStore(t, path)
+	if entry, _ := store.Get(ctx, "cache:stale"); entry != nil {
+		t.Error("expected the entry to expire while closed")
+	}
+	if entry, _ := store.Get(ctx, "cache:forever"); entry == nil {
+		t.Error("expected the entry without TTL to be kept")
+	}
+	if n := boltRecords(t, store); n != 1 {
+		t.Errorf("%d records on disk after reopening, want 1", n)
+	}
+}
+
+// TestBoltStore_TouchFlush verifies hits leave the entry's record alone,
+// are written together by a flush, and keep a slid deadline from being swept.
+func TestBoltStore_TouchFlush(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	now := time.Now()
+	store := openBoltStore(t, path)
+	store.index.now = func() time.Time { return now }
+	store.Upsert(ctx, &CacheEntry{ID: "cache:hot", Embedding: []float32{1, 0}, LLMResponse: "hot"}, time.Minute)
+	record := func() (entry, access []byte) {
+		store.db.View(func(tx *bolt.Tx) error {
+			entry = append([]byte(nil), tx.Bucket(boltBucket).Get([]byte("cache:hot"))...)
+			if a := tx.Bucket(boltAccessBucket).Get([]byte("cache:hot")); a != nil {
+				access = append([]byte(nil), a...)
+			}
+			return nil
+		})
+		return entry, access
+	}
+	written, _ := record()
+
+	for i := 0; i < 3; i++ {
+		store.Touch(ctx, "cache:hot", 1000+int64(i), 0, time.Hour)
+	}
+	if entry, access := record(); string(entry) != string(written) || access != nil {
+		t.Fatal("expected hits not to be written before a flush")
+	}
+	if err := store.flush(); err != nil {
+		t.Fatal(err)
+	}
+	entry, access := record()
+	if string(entry) != string(written) || access == nil {
+		t.Fatal("expected a flush to write an access record and leave the entry's record")
+	}
+	if strings.Contains(string(access), "embedding") {
+		t.Errorf("access record holds the embedding: %s", access)
+	}
+
+	// The hits slid the deadline past the original minute
+	now = now.Add(2 * time.Minute)
+	store.Touch(ctx, "cache:hot", 2000, 0, time.Hour)
+	if removed, err := store.sweep(); err != nil || removed != 0 {
+		t.Fatalf("sweep removed %d entries (err %v), want 0", removed, err)
+	}
+
+	store.Close()
+	store = openBoltStore(t, path)
+	hot, _ := store.Get(ctx, "cache:hot")
+	if hot == nil || hot.HitCount != 4 || hot.LastAccessedAt != 2000 || hot.LLMResponse != "hot" {
+		t.Fatalf("hits not restored: %+v", hot)
+	}
+}
+
+// TestBoltStore_Compact verifies a file that is mostly free space is
+// compacted and keeps its live entries.
+func TestBoltStore_Compact(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	store := openBoltStore(t, path)
+	response := strings.Repeat("x", 8<<10)
+	var ids []string
+	for i := 0; i < 400; i++ {
+		id := fmt.Sprintf("cache:%03d", i)
+		ids = append(ids, id)
+		store.Upsert(ctx, &CacheEntry{ID: id, LLMResponse: response}, 0)
+	}
+	store.Delete(ctx, ids[1:]...)
+	before, _ := os.Stat(path)
+
+	if _, err := store.sweep(); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size()/2 {
+		t.Errorf("file not compacted: %d bytes before, %d after", before.Size(), after.Size())
+	}
+
+	store.Upsert(ctx, &CacheEntry{ID: "cache:new"}, 0)
+	store.Close()
+	store = openBoltStore(t, path)
+	for _, id := range []string{ids[0], "cache:new"} {
+		if entry, _ := store.Get(ctx, id); entry == nil {
+			t.Errorf("entry %s lost by compaction", id)
+		}
+	}
+	if _, err := os.Stat(path + ".compact"); !os.IsNotExist(err) {
+		t.Error("temporary compaction file left behind")
+	}
+}
+
+// TestBoltStore_Locked verifies a second process cannot open the file.
+func TestBoltStore_Locked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	openBoltStore(t, path)
+	_, err := NewBoltStore(BoltStoreConfig{Path: path, OpenTimeout: 50 * time.Millisecond}, logger.New())
+	if err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected the file to be in use, got %v", err)
+	}
+}
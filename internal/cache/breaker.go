@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrUnavailable is returned by cache operations while the store has not
+// been set up, and for Redis commands while the circuit breaker is open,
+// without contacting Redis.
+var ErrUnavailable = errors.New("cache unavailable")
+
+// errBreakerOpen is returned for Redis commands while the breaker is open.
+var errBreakerOpen = fmt.Errorf("%w: redis circuit breaker open", ErrUnavailable)
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// Circuit breaker defaults.
+const (
+	DefaultBreakerFailures = 5
+	DefaultBreakerCooldown = 5 * time.Second
+)
+
+// BreakerConfig configures the Redis circuit breaker.
+type BreakerConfig struct {
+	// Failures is the number of consecutive connection failures that open
+	// the breaker.
+	Failures int
+	// Cooldown is how long the breaker stays open before a single probe
+	// command is let through.
+	Cooldown time.Duration
+	// OnStateChange is called with the new state on every transition.
+	OnStateChange func(state string)
+}
+
+// Breaker stops Redis commands after repeated connection failures, so
+// requests do not each wait for a timeout while Redis is down. Only network
+// errors and timeouts count as failures; error replies mean Redis answered.
+type Breaker struct {
+	config BreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker creates a closed circuit breaker.
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	if cfg.Failures <= 0 {
+		cfg.Failures = DefaultBreakerFailures
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultBreakerCooldown
+	}
+	return &Breaker{config: cfg, now: time.Now, state: BreakerClosed}
+}
+
+// State returns the breaker's current state.
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.config.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a command may be sent and whether it is the probe.
+// Once the cooldown has passed, one probe is allowed at a time until a probe
+// succeeds.
+func (b *Breaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return false, nil
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.config.Cooldown {
+			return false, errBreakerOpen
+		}
+		b.setState(BreakerHalfOpen)
+	}
+	if b.probing {
+		return false, errBreakerOpen
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record updates the breaker with the outcome of an allowed command.
+func (b *Breaker) record(err error, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The caller gave up; the outcome says nothing about Redis
+	case !isConnectionError(err):
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.setState(BreakerClosed)
+		}
+	case probe:
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	default:
+		b.failures++
+		if b.state == BreakerClosed && b.failures >= b.config.Failures {
+			b.openedAt = b.now()
+			b.setState(BreakerOpen)
+		}
+	}
+}
+
+// setState changes state and notifies the callback. b.mu must be held.
+func (b *Breaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if state == BreakerClosed {
+		b.failures = 0
+	}
+	if b.config.OnStateChange != nil {
+		b.config.OnStateChange(state)
+	}
+}
+
+// isConnectionError reports whether err means Redis could not be reached.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		// Error replies, including redis.Nil, come from a live server
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// breakerHook applies a Breaker to every command and pipeline.
+type breakerHook struct {
+	breaker *Breaker
+}
+
+func (h breakerHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		probe, err := h.breaker.allow()
+		if err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		err = next(ctx, cmd)
+		h.breaker.record(err, probe)
+		return err
+	}
+}
+
+func (h breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		probe, err := h.breaker.allow()
+		if err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err = next(ctx, cmds)
+		h.breaker.record(pipelineError(err, cmds), probe)
+		return err
+	}
+}
+
+// pipelineError returns the first connection error of a pipeline, or err.
+func pipelineError(err error, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		if isConnectionError(cmd.Err()) {
+			return cmd.Err()
+		}
+	}
+	return err
+}
@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"semantic-cache-gateway/internal/logger"
+)
+
+var errConnRefused = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+// TestBreaker_Transitions verifies the breaker opens after consecutive
+// connection failures, lets one probe through after the cooldown and closes
+// when the probe succeeds.
+func TestBreaker_Transitions(t *testing.T) {
+	now := time.Now()
+	var states []string
+	b := NewBreaker(BreakerConfig{Failures: 2, Cooldown: time.Second, OnStateChange: func(s string) { states = append(states, s) }})
+	b.now = func() time.Time { return now }
+
+	call := func(err error) error {
+		probe, allowErr := b.allow()
+		if allowErr != nil {
+			return allowErr
+		}
+		b.record(err, probe)
+		return err
+	}
+
+	call(errConnRefused)
+	call(redis.Nil) // error replies reset the count
+	call(errConnRefused)
+	if b.State() != BreakerClosed {
+		t.Fatalf("state = %s after non-consecutive failures, want closed", b.State())
+	}
+	call(errConnRefused)
+	if b.State() != BreakerOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	if err := call(nil); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("open breaker allowed a command: %v", err)
+	}
+
+	now = now.Add(time.Second)
+	probe, err := b.allow()
+	if err != nil || !probe {
+		t.Fatalf("expected a probe after the cooldown, got %v, %v", probe, err)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrUnavailable) {
+		t.Fatal("expected a single probe at a time")
+	}
+	b.record(errConnRefused, true)
+	if b.State() != BreakerOpen {
+		t.Fatalf("failed probe left state %s, want open", b.State())
+	}
+
+	now = now.Add(time.Second)
+	if err := call(nil); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("state = %s after a successful probe, want closed", b.State())
+	}
+	want := []string{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if fmt.Sprint(states) != fmt.Sprint(want) {
+		t.Errorf("transitions = %v, want %v", states, want)
+	}
+}
+
+// TestIsConnectionError verifies only errors reaching Redis count as failures.
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{redis.Nil, false},
+		{fmt.Errorf("FT.INFO failed: %w", redis.Nil), false},
+		{errConnRefused, true},
+		{io.EOF, true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{errors.New("unknown index name"), false},
+	}
+	for _, tt := range tests {
+		if got := isConnectionError(tt.err); got != tt.want {
+			t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// unreachableRedis returns a config for a port nothing listens on.
+func unreachableRedis(t *testing.T) *RedisConfig {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	cfg := DefaultRedisConfig("redis://" + addr)
+	cfg.MaxRetries = -1
+	cfg.DialTimeout = 100 * time.Millisecond
+	return cfg
+}
+
+// TestRedisClient_BreakerFailsFast verifies commands to an unreachable Redis
+// stop reaching the network once the breaker opens.
+func TestRedisClient_BreakerFailsFast(t *testing.T) {
+	cfg := unreachableRedis(t)
+	cfg.Breaker = &BreakerConfig{Failures: 2, Cooldown: time.Minute}
+	client, err := NewRedisClient(cfg, logger.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := client.Ping(ctx); err == nil || errors.Is(err, ErrUnavailable) {
+			t.Fatalf("ping %d: expected a connection error, got %v", i, err)
+		}
+	}
+	if err := client.Ping(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable once the breaker opened, got %v", err)
+	}
+	pipe := client.Client().Pipeline()
+	pipe.Get(ctx, "cache:a")
+	if _, err := pipe.Exec(ctx); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected pipelines to fail fast, got %v", err)
+	}
+	if client.BreakerState() != BreakerOpen {
+		t.Errorf("BreakerState() = %q, want open", client.BreakerState())
+	}
+}
+
+// TestNewCacheService_RetriesSetup verifies the service starts without Redis
+// when retries are enabled and fails fast otherwise.
+func TestNewCacheService_RetriesSetup(t *testing.T) {
+	client, err := NewRedisClient(unreachableRedis(t), logger.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	store := NewRedisStore(client, RedisStoreConfig{})
+
+	cfg := DefaultCacheServiceConfig()
+	if _, err := NewCacheService(store, logger.New(), cfg); err == nil {
+		t.Fatal("expected an error without retries")
+	}
+
+	cfg.RetryInterval = time.Hour
+	svc, err := NewCacheService(store, logger.New(), cfg)
+	if err != nil {
+		t.Fatalf("NewCacheService() with retries: %v", err)
+	}
+	if svc.Ready() {
+		t.Error("expected the service not to be ready")
+	}
+	if err := svc.CheckIndex(context.Background()); err == nil {
+		t.Error("expected CheckIndex to report the missing index")
+	}
+	if _, err := svc.CheckExactMatch(context.Background(), "sha256:a"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("CheckExactMatch() before setup = %v, want ErrUnavailable", err)
+	}
+	if _, _, err := svc.SearchSimilar(context.Background(), []float32{1}, 0.9, SearchFilter{}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("SearchSimilar() before setup = %v, want ErrUnavailable", err)
+	}
+	entry := &CacheEntry{QueryHash: "sha256:a", QueryText: "q", Embedding: []float32{1}, LLMResponse: "r"}
+	if err := svc.Store(context.Background(), entry); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Store() before setup = %v, want ErrUnavailable", err)
+	}
+
+	ran := false
+	svc.OnReady(func() { ran = true })
+	svc.markReady()
+	if !ran || !svc.Ready() {
+		t.Error("expected OnReady callbacks to run once ready")
+	}
+	svc.stopSetup()
+}
+
+// setupErrorStore is a store whose setup fails with err.
+type setupErrorStore struct {
+	*MemoryStore
+	err error
+}
+
+func (s setupErrorStore) Setup(ctx context.Context, space EmbeddingSpace) error {
+	return s.err
+}
+
+// TestNewCacheService_SetupErrorNotRetried verifies errors other than an
+// unreachable store fail at once even when retries are enabled.
+func TestNewCacheService_SetupErrorNotRetried(t *testing.T) {
+	cfg := DefaultCacheServiceConfig()
+	cfg.RetryInterval = time.Hour
+	store := setupErrorStore{NewMemoryStore(), errors.New("redis server is missing the search module")}
+	if _, err := NewCacheService(store, logger.New(), cfg); err == nil {
+		t.Fatal("expected a module error to be returned despite retries")
+	}
+}
@@ -2,7 +2,6 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -18,46 +17,144 @@ type CacheEntry struct {
 	Embedding   []float32 `json:"embedding"`
 	LLMResponse string    `json:"llm_response"`
 	CreatedAt   int64     `json:"created_at"`
+	// ExpiresAt is the Unix time after which the entry is stale. Zero means it never goes stale.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+	// HitCount and LastAccessedAt drive LFU/LRU eviction.
+	HitCount       int64 `json:"hit_count"`
+	LastAccessedAt int64 `json:"last_accessed_at,omitempty"`
+	// Format and Encoding describe how LLMResponse is stored. Entries written
+	// before compression support have neither and hold the raw response.
+	Format   int    `json:"format,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	// FeedbackUp and FeedbackDown count user ratings of hits served from the entry.
+	FeedbackUp   int64 `json:"feedback_up,omitempty"`
+	FeedbackDown int64 `json:"feedback_down,omitempty"`
+	// TemplateKey identifies the prompt template and variable values the
+	// entry was stored for. Empty for prompts that matched no template.
+	TemplateKey string `json:"template_key,omitempty"`
+	// Model, Tenant, SystemHash and Language describe the request the entry
+	// was stored for. They are indexed so searches can be partitioned by them.
+	Model      string `json:"model,omitempty"`
+	Tenant     string `json:"tenant,omitempty"`
+	SystemHash string `json:"system_hash,omitempty"`
+	Language   string `json:"language,omitempty"`
+	// EmbeddingModel and EmbeddingVersion identify the embedding space of
+	// Embedding. Both are empty for entries written before spaces were recorded.
+	EmbeddingModel   string `json:"embedding_model,omitempty"`
+	EmbeddingVersion string `json:"embedding_version,omitempty"`
+	// Tier is the cache tier that served the entry. It is not stored.
+	Tier string `json:"-"`
+}
+
+// IsStale reports whether the entry's fresh period has ended at the given time.
+func (e *CacheEntry) IsStale(now time.Time) bool {
+	return e.ExpiresAt > 0 && now.Unix() >= e.ExpiresAt
 }
 
 type CacheService interface {
 	CheckExactMatch(ctx context.Context, queryHash string) (*CacheEntry, error)
-	SearchSimilar(ctx context.Context, embedding []float32, threshold float64) (*CacheEntry, float64, error)
+	SearchSimilar(ctx context.Context, embedding []float32, threshold float64, filter SearchFilter) (*CacheEntry, float64, error)
+	SearchTopK(ctx context.Context, embedding []float32, k int, filter SearchFilter) ([]Neighbor, error)
 	StoreAsync(entry *CacheEntry)
+	Touch(ctx context.Context, entry *CacheEntry) error
 	Clear(ctx context.Context) error
 	Close() error
 }
 
 type CacheServiceImpl struct {
-	redis     *RedisClient
-	logger    *logger.Logger
-	indexName string
-	ttl       time.Duration
+	backend     VectorStore
+	logger      *logger.Logger
+	space       EmbeddingSpace
+	migration   *Migration
+	ttl         time.Duration
+	slidingTTL  bool
+	staleWindow time.Duration
+	evictor     *evictor
+	eviction    EvictionConfig
+	readiness   readiness
+	l1          *l1Cache
+	invalidator invalidator
+
+	compression         string
+	compressionMinBytes int
 }
 
 type CacheServiceConfig struct {
-	IndexName  string
 	Dimensions int
-	TTL        time.Duration
+	// EmbeddingModel and EmbeddingVersion identify the embedding space of new
+	// entries. Without a version the base index is used and entries are not tagged.
+	EmbeddingModel   string
+	EmbeddingVersion string
+	TTL              time.Duration
+	// SlidingTTL pushes an entry's expiry forward by TTL on every hit.
+	SlidingTTL bool
+	// StaleWindow keeps entries in the store for this long after TTL so they can
+	// be served as stale while a fresh response is fetched.
+	StaleWindow time.Duration
+	// Eviction bounds the number or total size of entries. Disabled when both limits are zero.
+	Eviction EvictionConfig
+	// Compression is the response encoding for new entries (none, gzip, zstd).
+	// Responses shorter than CompressionMinBytes are stored uncompressed.
+	Compression         string
+	CompressionMinBytes int
+	// L1 serves exact matches from memory. With a RedisStore, writes, deletes
+	// and clears are published so that the L1 tiers of other replicas stay coherent.
+	L1 L1Config
+	// RetryInterval, when positive, lets NewCacheService succeed while the
+	// store is unreachable: setup is retried at this interval and cache
+	// operations return ErrUnavailable until it succeeds. Other setup
+	// errors, such as a missing module or a mismatched index, are returned.
+	RetryInterval time.Duration
 }
 
 // DefaultCacheServiceConfig returns default configuration.
 func DefaultCacheServiceConfig() *CacheServiceConfig {
-	return &CacheServiceConfig{IndexName: "cache_idx", Dimensions: 1536, TTL: 24 * time.Hour}
+	return &CacheServiceConfig{
+		Dimensions:          1536,
+		TTL:                 24 * time.Hour,
+		Compression:         EncodingNone,
+		CompressionMinBytes: 1024,
+	}
 }
 
-// NewCacheService creates a new CacheService with the given Redis client.
-func NewCacheService(redis *RedisClient, log *logger.Logger, cfg *CacheServiceConfig) (*CacheServiceImpl, error) {
+// NewCacheService creates a new CacheService on the given store.
+func NewCacheService(store VectorStore, log *logger.Logger, cfg *CacheServiceConfig) (*CacheServiceImpl, error) {
 	if cfg == nil {
 		cfg = DefaultCacheServiceConfig()
 	}
-	svc := &CacheServiceImpl{redis: redis, logger: log, indexName: cfg.IndexName, ttl: cfg.TTL}
+	space := EmbeddingSpace{Model: cfg.EmbeddingModel, Version: cfg.EmbeddingVersion, Dimensions: cfg.Dimensions}
+	svc := &CacheServiceImpl{
+		backend:     store,
+		logger:      log,
+		space:       space,
+		ttl:         cfg.TTL,
+		slidingTTL:  cfg.SlidingTTL,
+		staleWindow: cfg.StaleWindow,
+
+		compression:         cfg.Compression,
+		compressionMinBytes: cfg.CompressionMinBytes,
+		eviction:            cfg.Eviction,
+	}
+	svc.invalidator.origin = newOrigin()
+	if redisStore, ok := store.(*RedisStore); ok {
+		svc.invalidator.redis = redisStore.Redis()
+	}
+	if cfg.L1.Enabled() {
+		svc.l1 = newL1Cache(cfg.L1)
+		svc.subscribeInvalidations()
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	if err := redis.CreateVectorIndex(ctx, cfg.IndexName, cfg.Dimensions); err != nil {
-		return nil, fmt.Errorf("failed to create vector index: %w", err)
+	if err := svc.setup(ctx); err != nil {
+		if cfg.RetryInterval <= 0 || !retrySetup(err) {
+			svc.stopInvalidations()
+			return nil, err
+		}
+		svc.setupInBackground(err, cfg.RetryInterval)
+		return svc, nil
 	}
+	svc.markReady()
 	return svc, nil
 }
 
@@ -69,80 +166,65 @@ func CacheKeyFromHash(queryHash string) string {
 
 // CheckExactMatch looks up a cache entry by its query hash.
 func (c *CacheServiceImpl) CheckExactMatch(ctx context.Context, queryHash string) (*CacheEntry, error) {
-	key := CacheKeyFromHash(queryHash)
-
-	exists, err := c.redis.Exists(ctx, key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check key existence: %w", err)
-	}
-	if !exists {
-		return nil, nil
+	if err := c.checkReady(); err != nil {
+		return nil, err
 	}
-
-	data, err := c.redis.JSONGet(ctx, key, "$")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cache entry: %w", err)
-	}
-	if data == nil {
-		return nil, nil
+	key := CacheKeyFromHash(queryHash)
+	if c.l1 != nil {
+		if entry, ok := c.l1.get(key); ok {
+			return entry, nil
+		}
 	}
 
-	var entries []CacheEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal cache entry: %w", err)
+	entry, err := c.Get(ctx, key)
+	if err != nil || entry == nil {
+		return nil, err
 	}
-	if len(entries) == 0 {
-		return nil, nil
+	entry.Tier = TierL2
+	if c.l1 != nil && !entry.IsStale(time.Now()) {
+		c.l1.add(entry)
 	}
-	return &entries[0], nil
+	return entry, nil
 }
 
 // Close releases resources held by the cache service.
 func (c *CacheServiceImpl) Close() error {
-	return c.redis.Close()
-}
-
-// SearchSimilar performs a KNN vector search to find semantically similar cached entries.
-func (c *CacheServiceImpl) SearchSimilar(ctx context.Context, embedding []float32, threshold float64) (*CacheEntry, float64, error) {
-	if len(embedding) == 0 {
-		return nil, 0, fmt.Errorf("embedding cannot be empty")
+	c.stopSetup()
+	c.stopInvalidations()
+	if c.migration != nil {
+		c.migration.Stop()
+	}
+	if c.evictor != nil {
+		c.evictor.stop()
 	}
+	return c.backend.Close()
+}
 
-	embeddingBytes := float32SliceToBytes(embedding)
-	query := "*=>[KNN 1 @embedding $vec AS __vector_score]"
+// SearchSimilar performs a KNN vector search to find semantically similar
+// cached entries among those matching the filter.
+func (c *CacheServiceImpl) SearchSimilar(ctx context.Context, embedding []float32, threshold float64, filter SearchFilter) (*CacheEntry, float64, error) {
+	return c.searchSpace(ctx, c.space, embedding, threshold, filter)
+}
 
-	results, err := c.redis.FTSearch(ctx, c.indexName, query,
-		"PARAMS", "2", "vec", embeddingBytes,
-		"RETURN", "1", "$",
-		"SORTBY", "__vector_score",
-		"DIALECT", "2",
-	)
+// searchSpace returns the best match above threshold among the entries of
+// the given embedding space.
+func (c *CacheServiceImpl) searchSpace(ctx context.Context, space EmbeddingSpace, embedding []float32, threshold float64, filter SearchFilter) (*CacheEntry, float64, error) {
+	neighbors, err := c.searchNeighbors(ctx, space, embedding, 1, filter)
 	if err != nil {
-		return nil, 0, fmt.Errorf("vector search failed: %w", err)
+		return nil, 0, err
 	}
-	if len(results) == 0 {
+	if len(neighbors) == 0 {
 		return nil, 0, nil
 	}
 
-	bestMatch := results[0]
-	similarity := bestMatch.Score
-
-	if similarity <= threshold {
-		c.logger.Info("vector search below threshold", "similarity", similarity, "threshold", threshold)
-		return nil, similarity, nil
-	}
-
-	if bestMatch.Document == nil {
-		return nil, similarity, nil
+	best := neighbors[0]
+	if best.Similarity <= threshold {
+		c.logger.Info("vector search below threshold", "similarity", best.Similarity, "threshold", threshold)
+		return nil, best.Similarity, nil
 	}
 
-	var entry CacheEntry
-	if err := json.Unmarshal(bestMatch.Document, &entry); err != nil {
-		return nil, similarity, fmt.Errorf("failed to unmarshal cache entry: %w", err)
-	}
-
-	c.logger.Info("vector search hit", "similarity", similarity, "threshold", threshold, "cache_key", bestMatch.Key)
-	return &entry, similarity, nil
+	c.logger.Info("vector search hit", "similarity", best.Similarity, "threshold", threshold, "cache_key", best.Entry.ID)
+	return best.Entry, best.Similarity, nil
 }
 
 func float32SliceToBytes(floats []float32) []byte {
@@ -171,6 +253,9 @@ func (c *CacheServiceImpl) StoreAsync(entry *CacheEntry) {
 }
 
 func (c *CacheServiceImpl) store(ctx context.Context, entry *CacheEntry) error {
+	if err := c.checkReady(); err != nil {
+		return err
+	}
 	if err := validateCacheEntry(entry); err != nil {
 		return fmt.Errorf("invalid cache entry: %w", err)
 	}
@@ -180,20 +265,59 @@ func (c *CacheServiceImpl) store(ctx context.Context, entry *CacheEntry) error {
 	if entry.CreatedAt == 0 {
 		entry.CreatedAt = time.Now().Unix()
 	}
+	if entry.LastAccessedAt == 0 {
+		entry.LastAccessedAt = entry.CreatedAt
+	}
+	entry.EmbeddingModel, entry.EmbeddingVersion = "", ""
+	if c.space.Versioned() {
+		entry.EmbeddingModel, entry.EmbeddingVersion = c.space.Model, c.space.Version
+	}
+	if c.ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(c.ttl).Unix()
+	}
 
-	data, err := json.Marshal(entry)
+	stored, err := encodeResponse(entry, c.compression, c.compressionMinBytes)
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
-	}
-	if err := c.redis.JSONSetRaw(ctx, entry.ID, "$", string(data)); err != nil {
-		return fmt.Errorf("failed to store cache entry: %w", err)
+		return fmt.Errorf("failed to encode cache entry: %w", err)
 	}
-	
-	// Set TTL for automatic expiration
+	var ttl time.Duration
 	if c.ttl > 0 {
-		if err := c.redis.Client().Expire(ctx, entry.ID, c.ttl).Err(); err != nil {
-			c.logger.Error("failed to set TTL", "error", err.Error(), "key", entry.ID)
-		}
+		ttl = c.ttl + c.staleWindow
+	}
+	if err := c.backend.Upsert(ctx, stored, ttl); err != nil {
+		return err
+	}
+	c.invalidate(ctx, entry.ID)
+	return nil
+}
+
+// Touch records a hit on the entry for LRU/LFU eviction and, when sliding
+// TTL is enabled, pushes its expiry forward. Stale entries keep their expiry
+// so that revalidation replaces them.
+func (c *CacheServiceImpl) Touch(ctx context.Context, entry *CacheEntry) error {
+	if entry == nil || entry.ID == "" {
+		return nil
+	}
+	if err := c.checkReady(); err != nil {
+		return err
+	}
+	now := time.Now()
+	var expiresAt int64
+	var ttl time.Duration
+	if c.slidingTTL && c.ttl > 0 && !entry.IsStale(now) {
+		expiresAt, ttl = now.Add(c.ttl).Unix(), c.ttl+c.staleWindow
+	}
+	if err := c.backend.Touch(ctx, entry.ID, now.Unix(), expiresAt, ttl); err != nil {
+		return err
+	}
+
+	entry.HitCount++
+	entry.LastAccessedAt = now.Unix()
+	if expiresAt > 0 {
+		entry.ExpiresAt = expiresAt
+	}
+	if c.l1 != nil {
+		c.l1.refresh(entry)
 	}
 	return nil
 }
@@ -222,33 +346,34 @@ func (c *CacheServiceImpl) Store(ctx context.Context, entry *CacheEntry) error {
 	return c.store(ctx, entry)
 }
 
-// Clear removes all cache entries from Redis.
+// Clear removes all cache entries from the store.
 func (c *CacheServiceImpl) Clear(ctx context.Context) error {
-	client := c.redis.Client()
-	
-	// Delete all keys with cache: prefix
-	var cursor uint64
 	var deleted int64
-	for {
-		keys, nextCursor, err := client.Scan(ctx, cursor, "cache:*", 100).Result()
+	err := c.backend.ScanIDs(ctx, keyPrefix+"*", func(keys []string) error {
+		count, err := c.backend.Delete(ctx, keys...)
 		if err != nil {
-			return fmt.Errorf("failed to scan keys: %w", err)
-		}
-		
-		if len(keys) > 0 {
-			count, err := client.Del(ctx, keys...).Result()
-			if err != nil {
-				return fmt.Errorf("failed to delete keys: %w", err)
-			}
-			deleted += count
-		}
-		
-		cursor = nextCursor
-		if cursor == 0 {
-			break
+			return fmt.Errorf("failed to delete keys: %w", err)
 		}
+		deleted += count
+		return nil
+	})
+	c.invalidateAll(ctx)
+	if err != nil {
+		return err
 	}
-	
+
 	c.logger.Info("cache cleared", "deleted_keys", deleted)
 	return nil
 }
+
+// Scan calls fn for every cache entry with its response decoded.
+// Entries that expire during the scan are skipped.
+func (c *CacheServiceImpl) Scan(ctx context.Context, fn func(*CacheEntry) error) error {
+	return c.backend.Scan(ctx, func(entry *CacheEntry) error {
+		if err := decodeResponse(entry); err != nil {
+			c.logger.Error("skipping undecodable cache entry", "cache_key", entry.ID, "error", err.Error())
+			return nil
+		}
+		return fn(entry)
+	})
+}
@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Response payload encodings.
+const (
+	EncodingNone = "none"
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// Entry storage formats. FormatLegacy entries hold the raw response text;
+// FormatEncoded entries hold a base64 payload described by CacheEntry.Encoding.
+const (
+	FormatLegacy  = 0
+	FormatEncoded = 1
+)
+
+// Vector element types supported by the index.
+const (
+	VectorFloat32 = "FLOAT32"
+	VectorFloat16 = "FLOAT16"
+	VectorInt8    = "INT8"
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// encodeResponse compresses the response payload of a copy of entry when it
+// is at least minBytes long. The original entry is not modified.
+func encodeResponse(entry *CacheEntry, encoding string, minBytes int) (*CacheEntry, error) {
+	if encoding == "" || encoding == EncodingNone || len(entry.LLMResponse) < minBytes {
+		return entry, nil
+	}
+
+	var compressed []byte
+	switch encoding {
+	case EncodingGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write([]byte(entry.LLMResponse)); err != nil {
+			return nil, fmt.Errorf("gzip compression failed: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("gzip compression failed: %w", err)
+		}
+		compressed = buf.Bytes()
+	case EncodingZstd:
+		compressed = zstdEncoder.EncodeAll([]byte(entry.LLMResponse), nil)
+	default:
+		return nil, fmt.Errorf("unsupported response encoding %q", encoding)
+	}
+
+	encoded := *entry
+	encoded.Format = FormatEncoded
+	encoded.Encoding = encoding
+	encoded.LLMResponse = base64.StdEncoding.EncodeToString(compressed)
+	return &encoded, nil
+}
+
+// decodeResponse restores the raw response text of an entry in place.
+// Legacy entries are returned unchanged.
+func decodeResponse(entry *CacheEntry) error {
+	if entry.Format == FormatLegacy {
+		return nil
+	}
+	if entry.Format != FormatEncoded {
+		return fmt.Errorf("unsupported entry format %d", entry.Format)
+	}
+	if entry.Encoding == "" || entry.Encoding == EncodingNone {
+		entry.Format = FormatLegacy
+		return nil
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(entry.LLMResponse)
+	if err != nil {
+		return fmt.Errorf("invalid response payload: %w", err)
+	}
+
+	var raw []byte
+	switch entry.Encoding {
+	case EncodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("gzip decompression failed: %w", err)
+		}
+		defer zr.Close()
+		if raw, err = io.ReadAll(zr); err != nil {
+			return fmt.Errorf("gzip decompression failed: %w", err)
+		}
+	case EncodingZstd:
+		if raw, err = zstdDecoder.DecodeAll(payload, nil); err != nil {
+			return fmt.Errorf("zstd decompression failed: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported response encoding %q", entry.Encoding)
+	}
+
+	entry.LLMResponse = string(raw)
+	entry.Format = FormatLegacy
+	entry.Encoding = ""
+	return nil
+}
+
+// quantizeEmbedding converts an embedding to the values stored for the given
+// vector type. FLOAT16 rounds to half precision; INT8 scales each vector into
+// [-127, 127], which preserves cosine similarity.
+func quantizeEmbedding(vec []float32, vectorType string) []float32 {
+	switch vectorType {
+	case VectorFloat16:
+		out := make([]float32, len(vec))
+		for i, f := range vec {
+			out[i] = float16ToFloat32(float32ToFloat16(f))
+		}
+		return out
+	case VectorInt8:
+		out := make([]float32, len(vec))
+		for i, q := range quantizeInt8(vec) {
+			out[i] = float32(q)
+		}
+		return out
+	default:
+		return vec
+	}
+}
+
+// vectorBlob serializes a query vector in the little-endian layout expected
+// by an index of the given vector type.
+func vectorBlob(vec []float32, vectorType string) []byte {
+	switch vectorType {
+	case VectorFloat16:
+		out := make([]byte, len(vec)*2)
+		for i, f := range vec {
+			h := float32ToFloat16(f)
+			out[i*2] = byte(h)
+			out[i*2+1] = byte(h >> 8)
+		}
+		return out
+	case VectorInt8:
+		out := make([]byte, len(vec))
+		for i, q := range quantizeInt8(vec) {
+			out[i] = byte(q)
+		}
+		return out
+	default:
+		return float32SliceToBytes(vec)
+	}
+}
+
+func quantizeInt8(vec []float32) []int8 {
+	var maxAbs float64
+	for _, f := range vec {
+		if a := math.Abs(float64(f)); a > maxAbs {
+			maxAbs = a
+		}
+	}
+	out := make([]int8, len(vec))
+	if maxAbs == 0 {
+		return out
+	}
+	for i, f := range vec {
+		out[i] = int8(math.Round(float64(f) / maxAbs * 127))
+	}
+	return out
+}
+
+// float32ToFloat16 converts to IEEE 754 half precision with round-to-nearest-even.
+func float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+
+	switch {
+	case exp == 0xff: // Inf or NaN
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp-127 > 15: // overflow
+		return sign | 0x7c00
+	case exp-127 >= -14: // normal
+		half := uint32(exp-127+15)<<10 | mant>>13
+		round := mant & 0x1fff
+		if round > 0x1000 || (round == 0x1000 && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	case exp-127 >= -24: // subnormal
+		mant |= 0x800000
+		shift := uint32(-(exp - 127) - 14 + 13)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	default: // underflow
+		return sign
+	}
+}
+
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		f := float32(mant) / 1024 / 16384
+		if sign != 0 {
+			return -f
+		}
+		return f
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+	}
+}
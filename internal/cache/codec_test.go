@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestResponseEncoding_RoundTrip verifies compressed entries decode to the original response.
+func TestResponseEncoding_RoundTrip(t *testing.T) {
+	response := `{"choices":[{"message":{"content":"` + strings.Repeat("cached answer ", 200) + `"}}]}`
+
+	for _, encoding := range []string{EncodingNone, EncodingGzip, EncodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			entry := &CacheEntry{ID: "cache:abc", LLMResponse: response}
+			stored, err := encodeResponse(entry, encoding, 16)
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+			if entry.LLMResponse != response {
+				t.Fatal("encoding must not modify the original entry")
+			}
+			if encoding != EncodingNone && len(stored.LLMResponse) >= len(response) {
+				t.Errorf("expected %s payload to be smaller, got %d >= %d", encoding, len(stored.LLMResponse), len(response))
+			}
+
+			decoded := *stored
+			if err := decodeResponse(&decoded); err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			if decoded.LLMResponse != response {
+				t.Error("decoded response does not match the original")
+			}
+		})
+	}
+}
+
+// TestResponseEncoding_LegacyAndSmall verifies legacy entries and short responses are stored as-is.
+func TestResponseEncoding_LegacyAndSmall(t *testing.T) {
+	legacy := &CacheEntry{LLMResponse: `{"id":"legacy"}`}
+	if err := decodeResponse(legacy); err != nil || legacy.LLMResponse != `{"id":"legacy"}` {
+		t.Errorf("legacy entry should decode unchanged, got %q (err %v)", legacy.LLMResponse, err)
+	}
+
+	small := &CacheEntry{LLMResponse: `{"id":"small"}`}
+	stored, err := encodeResponse(small, EncodingZstd, 1024)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if stored.Format != FormatLegacy || stored.LLMResponse != small.LLMResponse {
+		t.Error("responses below the size threshold should not be compressed")
+	}
+
+	unknown := &CacheEntry{Format: 7, LLMResponse: "x"}
+	if err := decodeResponse(unknown); err == nil {
+		t.Error("expected error for unknown entry format")
+	}
+}
+
+// TestFloat16_Conversion verifies half precision conversion against known values.
+func TestFloat16_Conversion(t *testing.T) {
+	tests := []struct {
+		in   float32
+		want uint16
+	}{
+		{0, 0x0000},
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{0.5, 0x3800},
+		{65504, 0x7bff},
+		{1e6, 0x7c00},
+		{5.9604645e-8, 0x0001},
+	}
+	for _, tt := range tests {
+		if got := float32ToFloat16(tt.in); got != tt.want {
+			t.Errorf("float32ToFloat16(%v) = %#04x, want %#04x", tt.in, got, tt.want)
+		}
+	}
+
+	for _, f := range []float32{0.1, -0.033, 0.7071, 3.14159} {
+		back := float16ToFloat32(float32ToFloat16(f))
+		if math.Abs(float64(back-f)) > math.Abs(float64(f))*1e-3 {
+			t.Errorf("round trip of %v gave %v", f, back)
+		}
+	}
+}
+
+// TestQuantizeInt8 verifies INT8 quantization scales the largest component to 127.
+func TestQuantizeInt8(t *testing.T) {
+	got := quantizeInt8([]float32{0.5, -0.25, 0, 0.125})
+	want := []int8{127, -64, 0, 32}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("component %d: got %d, want %d", i, got[i], want[i])
+		}
+	}
+	if blob := vectorBlob([]float32{0.5, -0.25}, VectorInt8); len(blob) != 2 || int8(blob[1]) != -64 {
+		t.Errorf("unexpected INT8 blob %v", blob)
+	}
+	if blob := vectorBlob([]float32{1, 2, 3}, VectorFloat16); len(blob) != 6 {
+		t.Errorf("expected 6-byte FLOAT16 blob, got %d", len(blob))
+	}
+}
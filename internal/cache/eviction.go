@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"semantic-cache-gateway/internal/logger"
+)
+
+// Eviction policies.
+const (
+	EvictionLRU = "lru"
+	EvictionLFU = "lfu"
+)
+
+// EvictionConfig bounds the cache size. Entries are evicted least-recently
+// or least-frequently used first until both limits are satisfied.
+type EvictionConfig struct {
+	MaxEntries int
+	MaxBytes   int64
+	Policy     string
+	Interval   time.Duration
+	// OnEvict is called with the number of entries removed by each pass.
+	OnEvict func(count int)
+}
+
+// Enabled reports whether any size limit is configured.
+func (c EvictionConfig) Enabled() bool {
+	return c.MaxEntries > 0 || c.MaxBytes > 0
+}
+
+type evictionCandidate struct {
+	key        string
+	hitCount   int64
+	lastAccess int64
+	bytes      int64
+}
+
+type evictor struct {
+	store  VectorStore
+	logger *logger.Logger
+	config EvictionConfig
+	done   chan struct{}
+	wg     sync.WaitGroup
+	// invalidate drops evicted entries from the L1 tiers.
+	invalidate func(ctx context.Context, keys ...string)
+}
+
+func newEvictor(store VectorStore, log *logger.Logger, cfg EvictionConfig) *evictor {
+	if cfg.Policy == "" {
+		cfg.Policy = EvictionLRU
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	return &evictor{store: store, logger: log, config: cfg, done: make(chan struct{})}
+}
+
+func (e *evictor) start() {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), e.config.Interval)
+				if _, err := e.run(ctx); err != nil {
+					e.logger.Error("cache eviction failed", "error", err.Error())
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+func (e *evictor) stop() {
+	close(e.done)
+	e.wg.Wait()
+}
+
+// run performs a single eviction pass and returns the number of evicted entries.
+func (e *evictor) run(ctx context.Context) (int, error) {
+	candidates, err := e.collect(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	victims := selectVictims(candidates, e.config)
+	if len(victims) == 0 {
+		return 0, nil
+	}
+
+	var evicted int64
+	for start := 0; start < len(victims); start += 100 {
+		end := start + 100
+		if end > len(victims) {
+			end = len(victims)
+		}
+		count, err := e.store.Delete(ctx, victims[start:end]...)
+		if err != nil {
+			return int(evicted), fmt.Errorf("failed to delete keys: %w", err)
+		}
+		if e.invalidate != nil {
+			e.invalidate(ctx, victims[start:end]...)
+		}
+		evicted += count
+	}
+
+	e.logger.Info("cache entries evicted", "policy", e.config.Policy, "evicted", evicted, "entries", len(candidates))
+	if e.config.OnEvict != nil && evicted > 0 {
+		e.config.OnEvict(int(evicted))
+	}
+	return int(evicted), nil
+}
+
+// collect scans all cache entries and reads their access metadata.
+func (e *evictor) collect(ctx context.Context) ([]evictionCandidate, error) {
+	var candidates []evictionCandidate
+	err := e.store.ScanIDs(ctx, keyPrefix+"*", func(ids []string) error {
+		stats, err := e.store.Stats(ctx, ids, e.config.MaxBytes > 0)
+		if err != nil {
+			return err
+		}
+		for _, stat := range stats {
+			candidates = append(candidates, evictionCandidate{
+				key:        stat.ID,
+				hitCount:   stat.HitCount,
+				lastAccess: stat.LastAccessedAt,
+				bytes:      stat.Bytes,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// selectVictims orders candidates by the eviction policy and returns the keys
+// that must be removed to satisfy the configured limits.
+func selectVictims(candidates []evictionCandidate, cfg EvictionConfig) []string {
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if cfg.Policy == EvictionLFU && a.hitCount != b.hitCount {
+			return a.hitCount < b.hitCount
+		}
+		return a.lastAccess < b.lastAccess
+	})
+
+	var totalBytes int64
+	for _, c := range candidates {
+		totalBytes += c.bytes
+	}
+
+	remaining := len(candidates)
+	var victims []string
+	for _, c := range candidates {
+		overCount := cfg.MaxEntries > 0 && remaining > cfg.MaxEntries
+		overBytes := cfg.MaxBytes > 0 && totalBytes > cfg.MaxBytes
+		if !overCount && !overBytes {
+			break
+		}
+		victims = append(victims, c.key)
+		remaining--
+		totalBytes -= c.bytes
+	}
+	return victims
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSelectVictims verifies eviction ordering and limits for both policies.
+func TestSelectVictims(t *testing.T) {
+	candidates := func() []evictionCandidate {
+		return []evictionCandidate{
+			{key: "cache:a", hitCount: 10, lastAccess: 100, bytes: 400},
+			{key: "cache:b", hitCount: 1, lastAccess: 300, bytes: 400},
+			{key: "cache:c", hitCount: 5, lastAccess: 200, bytes: 400},
+		}
+	}
+
+	tests := []struct {
+		name string
+		cfg  EvictionConfig
+		want []string
+	}{
+		{
+			name: "lru by entry count",
+			cfg:  EvictionConfig{MaxEntries: 2, Policy: EvictionLRU},
+			want: []string{"cache:a"},
+		},
+		{
+			name: "lfu by entry count",
+			cfg:  EvictionConfig{MaxEntries: 1, Policy: EvictionLFU},
+			want: []string{"cache:b", "cache:c"},
+		},
+		{
+			name: "lru by bytes",
+			cfg:  EvictionConfig{MaxBytes: 500, Policy: EvictionLRU},
+			want: []string{"cache:a", "cache:c"},
+		},
+		{
+			name: "within limits",
+			cfg:  EvictionConfig{MaxEntries: 3, MaxBytes: 1200, Policy: EvictionLRU},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectVictims(candidates(), tt.cfg)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected victims %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
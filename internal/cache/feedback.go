@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrEntryNotFound is returned when an entry does not exist or has expired.
+var ErrEntryNotFound = errors.New("cache entry not found")
+
+// FeedbackCounts are the ratings recorded for an entry.
+type FeedbackCounts struct {
+	Up   int64 `json:"feedback_up"`
+	Down int64 `json:"feedback_down"`
+}
+
+// RecordFeedback adds a positive or negative rating to an entry and returns
+// the updated counts.
+func (c *CacheServiceImpl) RecordFeedback(ctx context.Context, id string, positive bool) (FeedbackCounts, error) {
+	return c.backend.AddFeedback(ctx, entryKey(id), positive)
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Partition attributes that can be used as vector search pre-filters.
+const (
+	PartitionModel        = "model"
+	PartitionTenant       = "tenant"
+	PartitionSystemPrompt = "system_prompt"
+	PartitionLanguage     = "language"
+)
+
+// Partitions lists the valid partition attributes.
+var Partitions = []string{PartitionModel, PartitionTenant, PartitionSystemPrompt, PartitionLanguage}
+
+// SearchFilter restricts vector search to entries with matching attributes.
+// Empty fields are not filtered on, so the zero value searches all entries.
+type SearchFilter struct {
+	// TemplateKey limits the search to entries stored for the same prompt
+	// template and variable values.
+	TemplateKey string
+	Model       string
+	Tenant      string
+	SystemHash  string
+	Language    string
+	// MinCreatedAt excludes entries created before this Unix time.
+	MinCreatedAt int64
+	// EmbeddingModel and EmbeddingVersion are set by the cache service to
+	// keep searches within its embedding space.
+	EmbeddingModel   string
+	EmbeddingVersion string
+}
+
+// filterTag is a tag field of the index and the value a filter requires.
+type filterTag struct{ field, value string }
+
+// tags returns the tag fields the filter restricts, by index field name.
+func (f SearchFilter) tags() []filterTag {
+	return []filterTag{
+		{"template_key", f.TemplateKey},
+		{"model", f.Model},
+		{"tenant", f.Tenant},
+		{"system_hash", f.SystemHash},
+		{"language", f.Language},
+		{"embedding_model", f.EmbeddingModel},
+		{"embedding_version", f.EmbeddingVersion},
+	}
+}
+
+// knnQuery builds the FT.SEARCH query for the k nearest neighbors of $vec
+// among the entries matching the filter. A positive efRuntime overrides the
+// HNSW candidate list size.
+func (f SearchFilter) knnQuery(k, efRuntime int) string {
+	var clauses []string
+	for _, tag := range f.tags() {
+		if tag.value != "" {
+			clauses = append(clauses, fmt.Sprintf("@%s:{%s}", tag.field, escapeTag(tag.value)))
+		}
+	}
+	if f.MinCreatedAt > 0 {
+		clauses = append(clauses, fmt.Sprintf("@created_at:[%d +inf]", f.MinCreatedAt))
+	}
+
+	prefilter := "*"
+	if len(clauses) > 0 {
+		prefilter = "(" + strings.Join(clauses, " ") + ")"
+	}
+	if efRuntime > 0 {
+		return fmt.Sprintf("%s=>[KNN %d @embedding $vec EF_RUNTIME %d AS __vector_score]", prefilter, k, efRuntime)
+	}
+	return fmt.Sprintf("%s=>[KNN %d @embedding $vec AS __vector_score]", prefilter, k)
+}
+
+// sqlWhere builds the SQL conditions selecting the entries matching the
+// filter, joined with AND, and their arguments. Tag fields are columns of
+// the same name.
+func (f SearchFilter) sqlWhere() (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	for _, tag := range f.tags() {
+		if tag.value != "" {
+			clauses = append(clauses, tag.field+" = ?")
+			args = append(args, tag.value)
+		}
+	}
+	if f.MinCreatedAt > 0 {
+		clauses = append(clauses, "created_at >= ?")
+		args = append(args, f.MinCreatedAt)
+	}
+	if len(clauses) == 0 {
+		return "1", nil
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+// matches reports whether entry has the attributes the filter requires.
+func (f SearchFilter) matches(entry *CacheEntry) bool {
+	for _, tag := range []struct{ want, have string }{
+		{f.TemplateKey, entry.TemplateKey},
+		{f.Model, entry.Model},
+		{f.Tenant, entry.Tenant},
+		{f.SystemHash, entry.SystemHash},
+		{f.Language, entry.Language},
+		{f.EmbeddingModel, entry.EmbeddingModel},
+		{f.EmbeddingVersion, entry.EmbeddingVersion},
+	} {
+		if tag.want != "" && tag.want != tag.have {
+			return false
+		}
+	}
+	return entry.CreatedAt >= f.MinCreatedAt
+}
+
+// escapeTag escapes the characters RediSearch treats as syntax in TAG values.
+func escapeTag(value string) string {
+	out := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch c {
+		case ',', '.', '<', '>', '{', '}', '[', ']', '"', '\'', ':', ';', '!', '@', '#', '$', '%', '^', '&', '*', '(', ')', '-', '+', '=', '~', '|', '/', '\\', ' ':
+			out = append(out, '\\')
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
@@ -0,0 +1,79 @@
+package cache
+
+import "testing"
+
+// TestSearchFilterKNNQuery verifies filters become KNN pre-filters.
+func TestSearchFilterKNNQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter SearchFilter
+		k      int
+		want   string
+	}{
+		{"no filter", SearchFilter{}, 1, "*=>[KNN 1 @embedding $vec AS __vector_score]"},
+		{"template key", SearchFilter{TemplateKey: "ab12"}, 5, "(@template_key:{ab12})=>[KNN 5 @embedding $vec AS __vector_score]"},
+		{"partition and age", SearchFilter{Model: "gpt-4o", Tenant: "acme", MinCreatedAt: 1700000000}, 1, `(@model:{gpt\-4o} @tenant:{acme} @created_at:[1700000000 +inf])=>[KNN 1 @embedding $vec AS __vector_score]`},
+		{"embedding space", SearchFilter{EmbeddingModel: "small", EmbeddingVersion: "2"}, 1, "(@embedding_model:{small} @embedding_version:{2})=>[KNN 1 @embedding $vec AS __vector_score]"},
+		{"escaped tag", SearchFilter{TemplateKey: "a-b c"}, 1, `(@template_key:{a\-b\ c})=>[KNN 1 @embedding $vec AS __vector_score]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.knnQuery(tt.k, 0); got != tt.want {
+				t.Errorf("knnQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	want := "(@tenant:{acme})=>[KNN 3 @embedding $vec EF_RUNTIME 200 AS __vector_score]"
+	if got := (SearchFilter{Tenant: "acme"}).knnQuery(3, 200); got != want {
+		t.Errorf("knnQuery() with EF_RUNTIME = %q, want %q", got, want)
+	}
+}
+
+// TestSearchFilterSQLWhere verifies filters become SQL conditions on the
+// columns of the same name.
+func TestSearchFilterSQLWhere(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   SearchFilter
+		want     string
+		wantArgs int
+	}{
+		{"no filter", SearchFilter{}, "1", 0},
+		{"partition and age", SearchFilter{Model: "gpt-4o", Tenant: "acme", MinCreatedAt: 1700000000}, "model = ? AND tenant = ? AND created_at >= ?", 3},
+		{"embedding space", SearchFilter{EmbeddingModel: "small", EmbeddingVersion: "2"}, "embedding_model = ? AND embedding_version = ?", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := tt.filter.sqlWhere()
+			if got != tt.want || len(args) != tt.wantArgs {
+				t.Errorf("sqlWhere() = %q with %d args, want %q with %d", got, len(args), tt.want, tt.wantArgs)
+			}
+		})
+	}
+}
+
+// TestSearchFilterMatches verifies filters select entries the same way the
+// KNN pre-filters do.
+func TestSearchFilterMatches(t *testing.T) {
+	entry := &CacheEntry{Model: "gpt-4o", Tenant: "acme", Language: "en", CreatedAt: 1700000000}
+	tests := []struct {
+		name   string
+		filter SearchFilter
+		want   bool
+	}{
+		{"no filter", SearchFilter{}, true},
+		{"matching partition", SearchFilter{Model: "gpt-4o", Tenant: "acme"}, true},
+		{"other tenant", SearchFilter{Model: "gpt-4o", Tenant: "other"}, false},
+		{"missing attribute", SearchFilter{TemplateKey: "ab12"}, false},
+		{"recent enough", SearchFilter{MinCreatedAt: 1700000000}, true},
+		{"too old", SearchFilter{MinCreatedAt: 1700000001}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(entry); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
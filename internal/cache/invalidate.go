@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidationChannel is the Redis pub/sub channel on which writes, deletes
+// and clears are announced so that every replica drops the affected entries
+// from its L1 tier.
+const InvalidationChannel = "semantic-cache:invalidate"
+
+// invalidation is the message published on InvalidationChannel.
+type invalidation struct {
+	// Origin identifies the publishing service, which ignores its own messages.
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+	All    bool     `json:"all,omitempty"`
+}
+
+// invalidator keeps the L1 tier coherent with the store and, through Redis,
+// with other replicas.
+type invalidator struct {
+	origin string
+	// redis carries invalidations between replicas; nil for stores local to
+	// the process.
+	redis  *RedisClient
+	cancel context.CancelFunc
+	pubsub *redis.PubSub
+	wg     sync.WaitGroup
+}
+
+// newOrigin returns a random identifier for the service's messages.
+func newOrigin() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// invalidate drops keys from the local L1 tier and tells other replicas to
+// drop them too. Failing to publish is logged; other replicas then serve the
+// old entries until their L1 TTL ends.
+func (c *CacheServiceImpl) invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if c.l1 != nil {
+		c.l1.remove(keys...)
+	}
+	c.publishInvalidation(ctx, invalidation{Keys: keys})
+}
+
+// invalidateAll empties the local L1 tier and those of other replicas.
+func (c *CacheServiceImpl) invalidateAll(ctx context.Context) {
+	if c.l1 != nil {
+		c.l1.purge()
+	}
+	c.publishInvalidation(ctx, invalidation{All: true})
+}
+
+func (c *CacheServiceImpl) publishInvalidation(ctx context.Context, msg invalidation) {
+	if c.invalidator.redis == nil {
+		return
+	}
+	msg.Origin = c.invalidator.origin
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if err := c.invalidator.redis.Client().Publish(ctx, InvalidationChannel, data).Err(); err != nil {
+		c.logger.Warn("failed to publish cache invalidation", "error", err.Error(), "keys", len(msg.Keys), "all", msg.All)
+	}
+}
+
+// subscribeInvalidations applies other replicas' invalidations to the L1
+// tier until the service is closed.
+func (c *CacheServiceImpl) subscribeInvalidations() {
+	if c.invalidator.redis == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.invalidator.cancel = cancel
+	c.invalidator.pubsub = c.invalidator.redis.Client().Subscribe(ctx, InvalidationChannel)
+	c.invalidator.wg.Add(1)
+	go func() {
+		defer c.invalidator.wg.Done()
+		c.receiveInvalidations(ctx, c.invalidator.pubsub)
+	}()
+}
+
+func (c *CacheServiceImpl) receiveInvalidations(ctx context.Context, pubsub *redis.PubSub) {
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Invalidations published while disconnected are lost
+			c.l1.purge()
+			if !sleepCtx(ctx, time.Second) {
+				return
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			// Subscribed again after a reconnect; drop what may have changed meanwhile
+			c.l1.purge()
+		case *redis.Message:
+			c.applyInvalidation(msg.Payload)
+		}
+	}
+}
+
+// applyInvalidation drops the entries named by a published message.
+func (c *CacheServiceImpl) applyInvalidation(payload string) {
+	var msg invalidation
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		c.logger.Warn("ignoring malformed cache invalidation", "error", err.Error())
+		return
+	}
+	if msg.Origin == c.invalidator.origin {
+		return
+	}
+	if msg.All {
+		c.l1.purge()
+		return
+	}
+	c.l1.remove(msg.Keys...)
+}
+
+// stopInvalidations stops receiving invalidations.
+func (c *CacheServiceImpl) stopInvalidations() {
+	if c.invalidator.cancel == nil {
+		return
+	}
+	c.invalidator.cancel()
+	c.invalidator.pubsub.Close()
+	c.invalidator.wg.Wait()
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache tiers that serve entries.
+const (
+	TierL1 = "L1"
+	TierL2 = "L2"
+)
+
+// L1 defaults.
+const (
+	DefaultL1MaxEntries = 1000
+	DefaultL1TTL        = time.Minute
+)
+
+// L1Config configures the in-process tier that serves exact matches from
+// memory in front of Redis.
+type L1Config struct {
+	// MaxEntries bounds the tier; the least recently used entry is evicted
+	// beyond it. Zero disables the tier.
+	MaxEntries int
+	// TTL bounds how long an entry is served from memory without rereading
+	// it from Redis. Invalidations normally remove changed entries sooner.
+	TTL time.Duration
+}
+
+// Enabled reports whether the tier is configured.
+func (c L1Config) Enabled() bool {
+	return c.MaxEntries > 0
+}
+
+// l1Cache is an LRU of cache entries keyed by entry ID. Entries are held
+// without embeddings and returned as copies.
+type l1Cache struct {
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List // most recently used first
+}
+
+type l1Item struct {
+	entry    CacheEntry
+	storedAt time.Time
+}
+
+func newL1Cache(cfg L1Config) *l1Cache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultL1TTL
+	}
+	return &l1Cache{
+		maxEntries: cfg.MaxEntries,
+		ttl:        cfg.TTL,
+		now:        time.Now,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// get returns a copy of the entry stored under key. Expired and stale
+// entries are dropped so they are reread from Redis.
+func (l *l1Cache) get(key string) (*CacheEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*l1Item)
+	now := l.now()
+	if now.Sub(item.storedAt) >= l.ttl || item.entry.IsStale(now) {
+		l.removeElement(elem)
+		return nil, false
+	}
+	l.order.MoveToFront(elem)
+	entry := item.entry
+	entry.Tier = TierL1
+	return &entry, true
+}
+
+// add stores a copy of entry, evicting the least recently used entry if the
+// tier is full.
+func (l *l1Cache) add(entry *CacheEntry) {
+	item := &l1Item{entry: *entry, storedAt: l.now()}
+	item.entry.Embedding = nil
+	item.entry.Tier = ""
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.items[entry.ID]; ok {
+		elem.Value = item
+		l.order.MoveToFront(elem)
+		return
+	}
+	l.items[entry.ID] = l.order.PushFront(item)
+	for l.order.Len() > l.maxEntries {
+		l.removeElement(l.order.Back())
+	}
+}
+
+// refresh copies the access metadata and expiry of entry to its stored copy,
+// if there is one.
+func (l *l1Cache) refresh(entry *CacheEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.items[entry.ID]; ok {
+		item := elem.Value.(*l1Item)
+		item.entry.HitCount = entry.HitCount
+		item.entry.LastAccessedAt = entry.LastAccessedAt
+		item.entry.ExpiresAt = entry.ExpiresAt
+	}
+}
+
+// remove drops the entries stored under keys.
+func (l *l1Cache) remove(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := l.items[key]; ok {
+			l.removeElement(elem)
+		}
+	}
+}
+
+// purge drops every entry.
+func (l *l1Cache) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = make(map[string]*list.Element)
+	l.order.Init()
+}
+
+// len returns the number of stored entries.
+func (l *l1Cache) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// removeElement drops elem. l.mu must be held.
+func (l *l1Cache) removeElement(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.items, elem.Value.(*l1Item).entry.ID)
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"semantic-cache-gateway/internal/logger"
+)
+
+// TestL1Cache_LRU verifies the least recently used entry is evicted and
+// entries are returned as copies without embeddings.
+func TestL1Cache_LRU(t *testing.T) {
+	l := newL1Cache(L1Config{MaxEntries: 2, TTL: time.Minute})
+	l.add(&CacheEntry{ID: "cache:a", LLMResponse: "A", Embedding: []float32{1}})
+	l.add(&CacheEntry{ID: "cache:b", LLMResponse: "B"})
+	l.get("cache:a")
+	l.add(&CacheEntry{ID: "cache:c", LLMResponse: "C"})
+
+	if _, ok := l.get("cache:b"); ok {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+	entry, ok := l.get("cache:a")
+	if !ok || entry.LLMResponse != "A" || entry.Tier != TierL1 || entry.Embedding != nil {
+		t.Fatalf("get(a) = %+v, %v", entry, ok)
+	}
+	entry.LLMResponse = "changed"
+	if again, _ := l.get("cache:a"); again.LLMResponse != "A" {
+		t.Error("expected get to return a copy")
+	}
+	if l.len() != 2 {
+		t.Errorf("len() = %d, want 2", l.len())
+	}
+}
+
+// TestL1Cache_Expiry verifies entries are dropped after the L1 TTL and once
+// they go stale.
+func TestL1Cache_Expiry(t *testing.T) {
+	now := time.Now()
+	l := newL1Cache(L1Config{MaxEntries: 10, TTL: time.Minute})
+	l.now = func() time.Time { return now }
+	l.add(&CacheEntry{ID: "cache:short", ExpiresAt: now.Add(10 * time.Second).Unix()})
+	l.add(&CacheEntry{ID: "cache:long"})
+
+	now = now.Add(30 * time.Second)
+	if _, ok := l.get("cache:short"); ok {
+		t.Error("expected a stale entry to be dropped")
+	}
+	if _, ok := l.get("cache:long"); !ok {
+		t.Error("expected a fresh entry within the TTL")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := l.get("cache:long"); ok {
+		t.Error("expected the entry to expire after the L1 TTL")
+	}
+	if l.len() != 0 {
+		t.Errorf("len() = %d, want 0", l.len())
+	}
+}
+
+// TestL1Cache_Refresh verifies hits update the stored copy's metadata.
+func TestL1Cache_Refresh(t *testing.T) {
+	l := newL1Cache(L1Config{MaxEntries: 10})
+	l.add(&CacheEntry{ID: "cache:a", HitCount: 1})
+	l.refresh(&CacheEntry{ID: "cache:a", HitCount: 2, LastAccessedAt: 100})
+	l.refresh(&CacheEntry{ID: "cache:missing", HitCount: 5})
+
+	entry, _ := l.get("cache:a")
+	if entry.HitCount != 2 || entry.LastAccessedAt != 100 {
+		t.Errorf("refreshed entry = %+v", entry)
+	}
+	if _, ok := l.get("cache:missing"); ok {
+		t.Error("refresh must not add entries")
+	}
+}
+
+// TestApplyInvalidation verifies published invalidations drop entries, and
+// that a service ignores its own messages.
+func TestApplyInvalidation(t *testing.T) {
+	svc := &CacheServiceImpl{logger: logger.New(), l1: newL1Cache(L1Config{MaxEntries: 10})}
+	svc.invalidator.origin = "self"
+	for _, id := range []string{"cache:a", "cache:b", "cache:c"} {
+		svc.l1.add(&CacheEntry{ID: id})
+	}
+	publish := func(msg invalidation) {
+		data, _ := json.Marshal(msg)
+		svc.applyInvalidation(string(data))
+	}
+
+	publish(invalidation{Origin: "self", Keys: []string{"cache:a"}})
+	if svc.l1.len() != 3 {
+		t.Fatal("expected the service to ignore its own invalidation")
+	}
+	publish(invalidation{Origin: "other", Keys: []string{"cache:a", "cache:b"}})
+	if _, ok := svc.l1.get("cache:c"); !ok || svc.l1.len() != 1 {
+		t.Fatalf("expected only cache:c to remain, have %d entries", svc.l1.len())
+	}
+	svc.applyInvalidation("not json")
+	publish(invalidation{Origin: "other", All: true})
+	if svc.l1.len() != 0 {
+		t.Error("expected a clear to empty the L1 tier")
+	}
+}
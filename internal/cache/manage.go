@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Neighbor is a cache entry returned by a top-k similarity search.
+type Neighbor struct {
+	Entry      *CacheEntry
+	Similarity float64
+}
+
+// SearchTopK returns the k entries matching the filter that are most similar
+// to embedding, best first, regardless of any similarity threshold.
+func (c *CacheServiceImpl) SearchTopK(ctx context.Context, embedding []float32, k int, filter SearchFilter) ([]Neighbor, error) {
+	if k < 1 {
+		k = 1
+	}
+	return c.searchNeighbors(ctx, c.space, embedding, k, filter)
+}
+
+// searchNeighbors returns the k nearest entries of the given embedding space
+// with their responses decoded.
+func (c *CacheServiceImpl) searchNeighbors(ctx context.Context, space EmbeddingSpace, embedding []float32, k int, filter SearchFilter) ([]Neighbor, error) {
+	if err := c.checkReady(); err != nil {
+		return nil, err
+	}
+	if len(embedding) == 0 {
+		return nil, fmt.Errorf("embedding cannot be empty")
+	}
+	neighbors, err := c.backend.Search(ctx, space, embedding, k, space.scope(filter))
+	if err != nil {
+		return nil, err
+	}
+	for _, neighbor := range neighbors {
+		if err := decodeResponse(neighbor.Entry); err != nil {
+			return nil, fmt.Errorf("failed to decode cache entry %s: %w", neighbor.Entry.ID, err)
+		}
+		neighbor.Entry.Tier = TierL2
+	}
+	return neighbors, nil
+}
+
+// Get returns the entry stored under id, or nil if it does not exist.
+func (c *CacheServiceImpl) Get(ctx context.Context, id string) (*CacheEntry, error) {
+	entry, err := c.backend.Get(ctx, id)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	if err := decodeResponse(entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	return entry, nil
+}
+
+// Delete removes the given entries and returns how many existed.
+// IDs without the "cache:" prefix are treated as bare hashes.
+func (c *CacheServiceImpl) Delete(ctx context.Context, ids ...string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = entryKey(id)
+	}
+	deleted, err := c.backend.Delete(ctx, keys...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete entries: %w", err)
+	}
+	c.invalidate(ctx, keys...)
+	c.logger.Info("cache entries deleted", "deleted_keys", deleted)
+	return deleted, nil
+}
+
+// DeleteMatching removes every entry whose key matches the glob pattern,
+// e.g. "cache:ab*". The pattern is confined to the cache key space.
+func (c *CacheServiceImpl) DeleteMatching(ctx context.Context, pattern string) (int64, error) {
+	pattern = entryKey(pattern)
+
+	var deleted int64
+	err := c.backend.ScanIDs(ctx, pattern, func(keys []string) error {
+		count, err := c.backend.Delete(ctx, keys...)
+		if err != nil {
+			return fmt.Errorf("failed to delete keys: %w", err)
+		}
+		c.invalidate(ctx, keys...)
+		deleted += count
+		return nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+
+	c.logger.Info("cache entries deleted", "pattern", pattern, "deleted_keys", deleted)
+	return deleted, nil
+}
+
+// IndexInfo describes the cache index, e.g. FT.INFO for a RedisStore.
+func (c *CacheServiceImpl) IndexInfo(ctx context.Context) (map[string]interface{}, error) {
+	indexed, ok := c.backend.(IndexedStore)
+	if !ok {
+		return nil, errNotIndexed
+	}
+	return indexed.IndexInfo(ctx, c.space)
+}
+
+// RecreateIndex drops the cache index and creates it again with the given
+// dimensions. Entries are kept; those whose embeddings no longer match the
+// new dimensions are skipped by the indexer until they are re-embedded.
+func (c *CacheServiceImpl) RecreateIndex(ctx context.Context, dimensions int) error {
+	if dimensions < 1 {
+		return fmt.Errorf("dimensions must be positive")
+	}
+	indexed, ok := c.backend.(IndexedStore)
+	if !ok {
+		return errNotIndexed
+	}
+	space := c.space
+	space.Dimensions = dimensions
+	return indexed.RecreateIndex(ctx, space)
+}
+
+// entryKey adds the "cache:" prefix to bare hashes and patterns.
+func entryKey(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if strings.HasPrefix(id, "cache:") {
+		return id
+	}
+	return "cache:" + id
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+)
+
+// TestEntryKey verifies IDs, hashes and patterns map into the cache key space.
+func TestEntryKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"cache:abc", "cache:abc"},
+		{"abc", "cache:abc"},
+		{"sha256:abc", "cache:abc"},
+		{"ab*", "cache:ab*"},
+		{"*", "cache:*"},
+	}
+	for _, tt := range tests {
+		if got := entryKey(tt.in); got != tt.want {
+			t.Errorf("entryKey(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestReplyToMap verifies FT.INFO replies decode under RESP2 and RESP3.
+func TestReplyToMap(t *testing.T) {
+	resp2 := []interface{}{"index_name", "cache_idx", "num_docs", int64(3), "dangling"}
+	info, ok := replyToMap(resp2)
+	if !ok {
+		t.Fatal("expected RESP2 array to convert")
+	}
+	if info["index_name"] != "cache_idx" || info["num_docs"] != int64(3) {
+		t.Errorf("unexpected RESP2 map: %v", info)
+	}
+	if _, ok := info["dangling"]; ok {
+		t.Error("expected unpaired trailing element to be ignored")
+	}
+
+	resp3 := map[interface{}]interface{}{"index_name": "cache_idx", "num_docs": int64(3)}
+	info, ok = replyToMap(resp3)
+	if !ok || info["num_docs"] != int64(3) {
+		t.Errorf("unexpected RESP3 map: %v", info)
+	}
+
+	if _, ok := replyToMap("OK"); ok {
+		t.Error("expected scalar reply to be rejected")
+	}
+}
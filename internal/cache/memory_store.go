@@ -0,0 +1,318 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"semantic-cache-gateway/internal/embedding"
+)
+
+// scanBatchSize is the number of IDs passed to each ScanIDs callback.
+const scanBatchSize = 100
+
+// DefaultMemorySweepInterval is how often StartSweeping removes expired entries.
+const DefaultMemorySweepInterval = time.Minute
+
+// MemoryStore keeps entries in process memory and searches them by brute
+// force. It needs no external service, but entries do not survive a restart
+// and are not shared between replicas.
+//
+// Expired entries are skipped by every read and removed by StartSweeping.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]*memoryEntry
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type memoryEntry struct {
+	entry CacheEntry
+	// deadline is when the entry is removed; zero means never.
+	deadline time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{now: time.Now, entries: make(map[string]*memoryEntry), done: make(chan struct{})}
+}
+
+// StartSweeping removes expired entries every interval until Close.
+func (s *MemoryStore) StartSweeping(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultMemorySweepInterval
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.purge()
+			}
+		}
+	}()
+}
+
+// Setup does nothing; entries of every space are searched by brute force.
+func (s *MemoryStore) Setup(ctx context.Context, space EmbeddingSpace) error {
+	return nil
+}
+
+// lookup returns the live entry stored under id. s.mu must be held.
+func (s *MemoryStore) lookup(id string, now time.Time) (*memoryEntry, bool) {
+	e, ok := s.entries[id]
+	if !ok || e.expired(now) {
+		return nil, false
+	}
+	return e, true
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.deadline.IsZero() && !now.Before(e.deadline)
+}
+
+// Get returns a copy of the entry stored under id, or nil.
+func (s *MemoryStore) Get(ctx context.Context, id string) (*CacheEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.lookup(id, s.now())
+	if !ok {
+		return nil, nil
+	}
+	entry := e.entry
+	return &entry, nil
+}
+
+// Upsert stores a copy of entry.
+func (s *MemoryStore) Upsert(ctx context.Context, entry *CacheEntry, ttl time.Duration) error {
+	now := s.now()
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = now.Add(ttl)
+	}
+	s.put(entry, deadline)
+	return nil
+}
+
+// put stores a copy of entry until deadline. Expired entries are left to
+// lookups, which skip them, and to purge.
+func (s *MemoryStore) put(entry *CacheEntry, deadline time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.ID] = &memoryEntry{entry: *entry, deadline: deadline}
+}
+
+// purge removes expired entries.
+func (s *MemoryStore) purge() {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked(now)
+}
+
+func (s *MemoryStore) purgeLocked(now time.Time) {
+	for id, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// snapshot returns a copy of the live entry stored under id and its deadline.
+func (s *MemoryStore) snapshot(id string) (CacheEntry, time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.lookup(id, s.now())
+	if !ok {
+		return CacheEntry{}, time.Time{}, false
+	}
+	return e.entry, e.deadline, true
+}
+
+// Search compares query with every entry matching the filter. Entries
+// with embeddings of other dimensions are skipped, as an index would.
+func (s *MemoryStore) Search(ctx context.Context, space EmbeddingSpace, query []float32, k int, filter SearchFilter) ([]Neighbor, error) {
+	now := s.now()
+	s.mu.RLock()
+	neighbors := make([]Neighbor, 0, k)
+	for _, e := range s.entries {
+		if e.expired(now) || len(e.entry.Embedding) != len(query) || !filter.matches(&e.entry) {
+			continue
+		}
+		entry := e.entry
+		neighbors = append(neighbors, Neighbor{Entry: &entry, Similarity: embedding.CosineSimilarity(query, entry.Embedding)})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(neighbors, func(i, j int) bool {
+		return neighbors[i].Similarity > neighbors[j].Similarity
+	})
+	if len(neighbors) > k {
+		neighbors = neighbors[:k]
+	}
+	return neighbors, nil
+}
+
+// Delete removes the entries and returns how many existed.
+func (s *MemoryStore) Delete(ctx context.Context, ids ...string) (int64, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for _, id := range ids {
+		if _, ok := s.lookup(id, now); ok {
+			deleted++
+		}
+		delete(s.entries, id)
+	}
+	return deleted, nil
+}
+
+// ScanIDs calls fn with the IDs matching pattern. The IDs are collected
+// first, so fn may modify the store.
+func (s *MemoryStore) ScanIDs(ctx context.Context, pattern string, fn func(ids []string) error) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	now := s.now()
+	s.mu.RLock()
+	var ids []string
+	for id, e := range s.entries {
+		if matched, _ := path.Match(pattern, id); matched && !e.expired(now) {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.RUnlock()
+
+	for start := 0; start < len(ids); start += scanBatchSize {
+		end := start + scanBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := fn(ids[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scan calls fn with a copy of every entry.
+func (s *MemoryStore) Scan(ctx context.Context, fn func(*CacheEntry) error) error {
+	return s.ScanIDs(ctx, "*", func(ids []string) error {
+		for _, id := range ids {
+			entry, _ := s.Get(ctx, id)
+			if entry == nil {
+				continue
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stats returns the entries' access metadata. Sizes are those of the
+// entries' JSON encoding.
+func (s *MemoryStore) Stats(ctx context.Context, ids []string, withBytes bool) ([]EntryStats, error) {
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := make([]EntryStats, 0, len(ids))
+	for _, id := range ids {
+		e, ok := s.lookup(id, now)
+		if !ok {
+			continue
+		}
+		stat := EntryStats{ID: id, HitCount: e.entry.HitCount, LastAccessedAt: e.entry.LastAccessedAt}
+		if stat.LastAccessedAt == 0 {
+			stat.LastAccessedAt = e.entry.CreatedAt
+		}
+		if withBytes {
+			data, _ := json.Marshal(&e.entry)
+			stat.Bytes = int64(len(data))
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// Touch updates the entry's access metadata.
+func (s *MemoryStore) Touch(ctx context.Context, id string, accessedAt, expiresAt int64, ttl time.Duration) error {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.lookup(id, now)
+	if !ok {
+		return nil
+	}
+	e.entry.HitCount++
+	e.entry.LastAccessedAt = accessedAt
+	if expiresAt > 0 {
+		e.entry.ExpiresAt = expiresAt
+	}
+	if ttl > 0 {
+		e.deadline = now.Add(ttl)
+	}
+	return nil
+}
+
+// AddFeedback increments a feedback counter of the entry.
+func (s *MemoryStore) AddFeedback(ctx context.Context, id string, positive bool) (FeedbackCounts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.lookup(id, s.now())
+	if !ok {
+		return FeedbackCounts{}, ErrEntryNotFound
+	}
+	if positive {
+		e.entry.FeedbackUp++
+	} else {
+		e.entry.FeedbackDown++
+	}
+	return FeedbackCounts{Up: e.entry.FeedbackUp, Down: e.entry.FeedbackDown}, nil
+}
+
+// Retag moves the entry into the space.
+func (s *MemoryStore) Retag(ctx context.Context, id string, space EmbeddingSpace, vec []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.lookup(id, s.now())
+	if !ok {
+		return nil
+	}
+	e.entry.EmbeddingModel, e.entry.EmbeddingVersion = "", ""
+	if space.Versioned() {
+		e.entry.EmbeddingModel, e.entry.EmbeddingVersion = space.Model, space.Version
+	}
+	if vec != nil {
+		e.entry.Embedding = vec
+	}
+	return nil
+}
+
+// Close stops sweeping and drops every entry.
+func (s *MemoryStore) Close() error {
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]*memoryEntry)
+	return nil
+}
@@ -0,0 +1,314 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"semantic-cache-gateway/internal/logger"
+)
+
+// newMemoryService returns a cache service on a MemoryStore.
+func newMemoryService(t *testing.T, cfg *CacheServiceConfig) (*CacheServiceImpl, *MemoryStore) {
+	t.Helper()
+	store := NewMemoryStore()
+	svc, err := NewCacheService(store, logger.New(), cfg)
+	if err != nil {
+		t.Fatalf("NewCacheService() error: %v", err)
+	}
+	t.Cleanup(func() { svc.Close() })
+	return svc, store
+}
+
+func testEntry(hash string, embedding ...float32) *CacheEntry {
+	return &CacheEntry{
+		QueryHash:   "sha256:" + hash,
+		QueryText:   "query " + hash,
+		Embedding:   embedding,
+		LLMResponse: `{"answer":"` + hash + `"}`,
+	}
+}
+
+// TestMemoryStore_Search verifies brute-force search ranks entries, applies
+// filters and skips embeddings of other dimensions.
+func TestMemoryStore_Search(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for _, entry := range []*CacheEntry{
+		{ID: "cache:x", Embedding: []float32{1, 0}, Tenant: "acme"},
+		{ID: "cache:xy", Embedding: []float32{1, 1}, Tenant: "acme"},
+		{ID: "cache:y", Embedding: []float32{0, 1}, Tenant: "other"},
+		{ID: "cache:3d", Embedding: []float32{1, 0, 0}, Tenant: "acme"},
+	} {
+		store.Upsert(ctx, entry, 0)
+	}
+
+	neighbors, err := store.Search(ctx, EmbeddingSpace{}, []float32{1, 0}, 2, SearchFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighbors) != 2 || neighbors[0].Entry.ID != "cache:x" || neighbors[1].Entry.ID != "cache:xy" {
+		t.Fatalf("unexpected neighbors: %+v", neighbors)
+	}
+	if neighbors[0].Similarity < 0.999 {
+		t.Errorf("similarity of identical vectors = %f", neighbors[0].Similarity)
+	}
+
+	neighbors, _ = store.Search(ctx, EmbeddingSpace{}, []float32{0, 1}, 5, SearchFilter{Tenant: "acme"})
+	if len(neighbors) != 2 || neighbors[0].Entry.ID != "cache:xy" {
+		t.Errorf("expected only acme entries of matching dimensions, got %+v", neighbors)
+	}
+}
+
+// TestMemoryStore_Expiry verifies entries disappear after their TTL and that
+// a touch can extend it.
+func TestMemoryStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	store.Upsert(ctx, &CacheEntry{ID: "cache:short"}, time.Minute)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:touched"}, time.Minute)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:forever"}, 0)
+
+	now = now.Add(30 * time.Second)
+	store.Touch(ctx, "cache:touched", now.Unix(), 0, time.Minute)
+	now = now.Add(45 * time.Second)
+
+	if entry, _ := store.Get(ctx, "cache:short"); entry != nil {
+		t.Error("expected the entry to expire")
+	}
+	var ids []string
+	store.ScanIDs(ctx, "cache:*", func(batch []string) error {
+		ids = append(ids, batch...)
+		return nil
+	})
+	if len(ids) != 2 {
+		t.Errorf("ScanIDs() = %v, want the touched and permanent entries", ids)
+	}
+	if n, _ := store.Delete(ctx, "cache:short", "cache:forever"); n != 1 {
+		t.Errorf("Delete() = %d, want 1", n)
+	}
+
+	store.purge()
+	if len(store.entries) != 1 {
+		t.Errorf("purge left %d entries, want the touched one", len(store.entries))
+	}
+}
+
+// TestCacheService_MemoryStore exercises the cache service end to end
+// without Redis.
+func TestCacheService_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	cfg := DefaultCacheServiceConfig()
+	cfg.Compression = EncodingGzip
+	cfg.CompressionMinBytes = 1
+	svc, store := newMemoryService(t, cfg)
+	if !svc.Ready() {
+		t.Fatal("expected a memory store to be ready at once")
+	}
+
+	if err := svc.Store(ctx, testEntry("aa", 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Store(ctx, testEntry("ab", 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := store.Get(ctx, "cache:aa"); raw.Encoding != EncodingGzip {
+		t.Errorf("expected the stored response to be compressed, got encoding %q", raw.Encoding)
+	}
+
+	entry, err := svc.CheckExactMatch(ctx, "sha256:aa")
+	if err != nil || entry == nil {
+		t.Fatalf("CheckExactMatch() = %v, %v", entry, err)
+	}
+	if entry.LLMResponse != `{"answer":"aa"}` || entry.Tier != TierL2 {
+		t.Errorf("exact match = %q from %s", entry.LLMResponse, entry.Tier)
+	}
+
+	hit, similarity, err := svc.SearchSimilar(ctx, []float32{0.1, 1}, 0.9, SearchFilter{})
+	if err != nil || hit == nil || hit.ID != "cache:ab" {
+		t.Fatalf("SearchSimilar() = %v, %f, %v", hit, similarity, err)
+	}
+	if miss, _, _ := svc.SearchSimilar(ctx, []float32{1, 1}, 0.9, SearchFilter{}); miss != nil {
+		t.Errorf("expected no match above the threshold, got %s", miss.ID)
+	}
+
+	if err := svc.Touch(ctx, hit); err != nil {
+		t.Fatal(err)
+	}
+	if counts, err := svc.RecordFeedback(ctx, "ab", false); err != nil || counts.Down != 1 {
+		t.Errorf("RecordFeedback() = %+v, %v", counts, err)
+	}
+	if _, err := svc.RecordFeedback(ctx, "missing", true); err != ErrEntryNotFound {
+		t.Errorf("RecordFeedback(missing) error = %v, want ErrEntryNotFound", err)
+	}
+	if stored, _ := svc.Get(ctx, "cache:ab"); stored.HitCount != 1 || stored.FeedbackDown != 1 {
+		t.Errorf("stored entry = %+v", stored)
+	}
+
+	var scanned []string
+	svc.Scan(ctx, func(e *CacheEntry) error {
+		if !strings.HasPrefix(e.LLMResponse, "{") {
+			t.Errorf("expected scanned responses to be decoded, got %q", e.LLMResponse)
+		}
+		scanned = append(scanned, e.ID)
+		return nil
+	})
+	if len(scanned) != 2 {
+		t.Errorf("Scan() visited %v", scanned)
+	}
+
+	if n, err := svc.DeleteMatching(ctx, "a*"); err != nil || n != 2 {
+		t.Errorf("DeleteMatching() = %d, %v", n, err)
+	}
+	if entry, _ := svc.CheckExactMatch(ctx, "sha256:aa"); entry != nil {
+		t.Error("expected deleted entries to be gone")
+	}
+	if _, err := svc.IndexInfo(ctx); err != errNotIndexed {
+		t.Errorf("IndexInfo() error = %v, want errNotIndexed", err)
+	}
+}
+
+// TestEvictor_MemoryStore verifies eviction passes work on any store.
+func TestEvictor_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for i, id := range []string{"cache:old", "cache:mid", "cache:new"} {
+		store.Upsert(ctx, &CacheEntry{ID: id, LastAccessedAt: int64(100 + i)}, 0)
+	}
+
+	var invalidated []string
+	e := newEvictor(store, logger.New(), EvictionConfig{MaxEntries: 1})
+	e.invalidate = func(ctx context.Context, keys ...string) { invalidated = append(invalidated, keys...) }
+	evicted, err := e.run(ctx)
+	if err != nil || evicted != 2 {
+		t.Fatalf("run() = %d, %v", evicted, err)
+	}
+	if entry, _ := store.Get(ctx, "cache:new"); entry == nil {
+		t.Error("expected the most recently used entry to remain")
+	}
+	if len(invalidated) != 2 {
+		t.Errorf("invalidated %v, want both evicted entries", invalidated)
+	}
+}
+
+// TestMigration_MemoryStore verifies untagged entries are adopted into a
+// versioned space, and that there is nothing to migrate afterwards.
+func TestMigration_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	legacy, store := newMemoryService(t, &CacheServiceConfig{Dimensions: 2})
+	legacy.Store(ctx, testEntry("aa", 1, 0))
+
+	cfg := &CacheServiceConfig{EmbeddingModel: "small", EmbeddingVersion: "2", Dimensions: 2}
+	svc, err := NewCacheService(store, logger.New(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Close()
+
+	migration, err := svc.StartMigration(MigrationConfig{From: EmbeddingSpace{Model: "small", Dimensions: 2}, Interval: time.Millisecond})
+	if err != nil || migration == nil {
+		t.Fatalf("StartMigration() = %v, %v", migration, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for migration.Active() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	migration.Stop()
+	if migration.Migrated() != 1 {
+		t.Fatalf("Migrated() = %d, want 1", migration.Migrated())
+	}
+	if entry, _ := store.Get(ctx, "cache:aa"); entry.EmbeddingModel != "small" || entry.EmbeddingVersion != "2" {
+		t.Errorf("entry not retagged: %+v", entry)
+	}
+	if hit, _, _ := svc.SearchSimilar(ctx, []float32{1, 0}, 0.5, SearchFilter{}); hit == nil {
+		t.Error("expected the migrated entry to be found in the new space")
+	}
+
+	svc.migration = nil
+	if again, err := svc.StartMigration(MigrationConfig{From: EmbeddingSpace{Model: "small", Dimensions: 2}}); again != nil || err != nil {
+		t.Errorf("expected nothing left to migrate, got %v, %v", again, err)
+	}
+}
+
+// TestMigration_SearchSkipsMigrated verifies a search of the previous space
+// falls through migrated entries to the nearest one not migrated yet.
+func TestMigration_SearchSkipsMigrated(t *testing.T) {
+	ctx := context.Background()
+	legacy, store := newMemoryService(t, &CacheServiceConfig{Dimensions: 2})
+	legacy.Store(ctx, testEntry("aa", 1, 0))
+	legacy.Store(ctx, testEntry("bb", 1, 0.1))
+	legacy.Store(ctx, testEntry("cc", 0, 1))
+
+	cfg := &CacheServiceConfig{EmbeddingModel: "small", EmbeddingVersion: "2", Dimensions: 2}
+	svc, err := NewCacheService(store, logger.New(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Close()
+	store.Retag(ctx, "cache:aa", svc.space, nil)
+
+	m := &Migration{svc: svc, from: EmbeddingSpace{Model: "small", Dimensions: 2}}
+	hit, similarity, err := m.SearchSimilar(ctx, []float32{1, 0}, 0.9, SearchFilter{})
+	if err != nil || hit == nil || hit.ID != "cache:bb" {
+		t.Fatalf("SearchSimilar() = %+v, %v, want the unmigrated neighbor", hit, err)
+	}
+	if similarity >= 1 {
+		t.Errorf("similarity = %v, want that of the unmigrated neighbor", similarity)
+	}
+	store.Retag(ctx, "cache:bb", svc.space, nil)
+	if hit, _, _ := m.SearchSimilar(ctx, []float32{1, 0}, 0.9, SearchFilter{}); hit != nil {
+		t.Errorf("SearchSimilar() = %s, want no match above the threshold", hit.ID)
+	}
+}
+
+// TestMigration_GivesUpOnFailingEntries verifies entries that keep failing
+// are left behind after MaxAttempts passes so the migration finishes.
+func TestMigration_GivesUpOnFailingEntries(t *testing.T) {
+	ctx := context.Background()
+	legacy, store := newMemoryService(t, &CacheServiceConfig{Dimensions: 2})
+	legacy.Store(ctx, testEntry("aa", 1, 0))
+	legacy.Store(ctx, testEntry("bb", 0, 1))
+
+	cfg := &CacheServiceConfig{EmbeddingModel: "large", EmbeddingVersion: "1", Dimensions: 2}
+	svc, err := NewCacheService(store, logger.New(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Close()
+
+	var attempts atomic.Int64
+	migration, err := svc.StartMigration(MigrationConfig{
+		From: EmbeddingSpace{Dimensions: 2},
+		Reembed: func(ctx context.Context, text string) ([]float32, error) {
+			if text == "query aa" {
+				attempts.Add(1)
+				return nil, errors.New("rejected")
+			}
+			return []float32{0, 1}, nil
+		},
+		Interval:    time.Millisecond,
+		MaxAttempts: 2,
+	})
+	if err != nil || migration == nil {
+		t.Fatalf("StartMigration() = %v, %v", migration, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for migration.Active() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	migration.Stop()
+	if migration.Active() {
+		t.Fatal("expected the migration to finish")
+	}
+	if migration.Migrated() != 1 || attempts.Load() != 2 {
+		t.Errorf("Migrated() = %d after %d attempts, want 1 after 2", migration.Migrated(), attempts.Load())
+	}
+	if entry, _ := store.Get(ctx, "cache:aa"); entry.EmbeddingModel != "" {
+		t.Errorf("failing entry was retagged: %+v", entry)
+	}
+}
@@ -0,0 +1,305 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Migration defaults.
+const (
+	DefaultMigrationBatchSize   = 100
+	DefaultMigrationInterval    = time.Second
+	DefaultMigrationMaxAttempts = 3
+)
+
+// migrationSearchK is how many neighbors a search of the previous space
+// considers, so migrated entries still in its index do not hide others.
+const migrationSearchK = 10
+
+// MigrationConfig describes a move from a previous embedding space to the
+// cache service's current one.
+type MigrationConfig struct {
+	From EmbeddingSpace
+	// Reembed embeds an entry's query text in the current space. When nil the
+	// previous space is assumed to hold the same embeddings, so entries are
+	// only tagged; this adopts entries written before spaces were recorded.
+	Reembed func(ctx context.Context, text string) ([]float32, error)
+	// BatchSize entries are migrated between pauses of Interval, which also
+	// separates passes over the cache.
+	BatchSize int
+	Interval  time.Duration
+	// MaxAttempts is how many passes try an entry before it is given up on
+	// and left in the previous space.
+	MaxAttempts int
+}
+
+// Migration moves entries from a previous embedding space into the current
+// one in the background. Until every entry has moved, the previous space
+// keeps serving semantic hits for entries that have not. Stores that index
+// each space separately drop its index once the migration completes.
+type Migration struct {
+	svc    *CacheServiceImpl
+	from   EmbeddingSpace
+	config MigrationConfig
+
+	migrated atomic.Int64
+	// failures counts failed attempts per entry. Only the run loop uses it.
+	failures map[string]int
+	done     atomic.Bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// errNothingToMigrate reports that the previous space has no index or no entries.
+var errNothingToMigrate = errors.New("previous embedding space is empty")
+
+// StartMigration starts migrating entries from cfg.From. It returns nil when
+// the previous space has no index, or no entries in stores without indexes,
+// as there is nothing to migrate. While the cache is not ready the migration
+// waits for it and counts as active.
+func (c *CacheServiceImpl) StartMigration(cfg MigrationConfig) (*Migration, error) {
+	if cfg.From.sameAs(c.space) {
+		return nil, fmt.Errorf("cannot migrate %s into itself", cfg.From)
+	}
+	if c.migration != nil {
+		return nil, fmt.Errorf("a migration is already running")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultMigrationBatchSize
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultMigrationInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMigrationMaxAttempts
+	}
+
+	m := &Migration{svc: c, from: cfg.From, config: cfg, failures: make(map[string]int)}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	if !c.Ready() {
+		c.OnReady(func() {
+			if err := m.start(); err != nil {
+				m.done.Store(true)
+				if err != errNothingToMigrate {
+					c.logger.Error("failed to start embedding migration", "error", err.Error())
+				}
+			}
+		})
+		c.migration = m
+		c.logger.Info("embedding migration waiting for the cache", "from", cfg.From.String(), "to", c.space.String())
+		return m, nil
+	}
+
+	if err := m.start(); err != nil {
+		if err == errNothingToMigrate {
+			return nil, nil
+		}
+		return nil, err
+	}
+	c.migration = m
+	return m, nil
+}
+
+// start checks the previous space and runs the migration in the background.
+func (m *Migration) start() error {
+	c := m.svc
+	ctx, cancel := context.WithTimeout(m.ctx, 10*time.Second)
+	defer cancel()
+	if err := m.check(ctx); err != nil {
+		return err
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run(m.ctx)
+	}()
+	c.logger.Info("embedding migration started", "from", m.from.String(), "to", c.space.String())
+	return nil
+}
+
+// check reports errNothingToMigrate if the previous space has no index, or
+// no entries in a store without indexes.
+func (m *Migration) check(ctx context.Context) error {
+	c := m.svc
+	indexed, ok := c.backend.(IndexedStore)
+	if !ok {
+		err := c.backend.Scan(ctx, func(entry *CacheEntry) error {
+			if m.from.owns(entry) {
+				return errFound
+			}
+			return nil
+		})
+		if err == errFound {
+			return nil
+		}
+		if err == nil {
+			return errNothingToMigrate
+		}
+		return err
+	}
+
+	dim, exists, err := indexed.IndexDimensions(ctx, m.from)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errNothingToMigrate
+	}
+	if m.config.Reembed == nil && dim > 0 && dim != c.space.Dimensions {
+		// Entries are adopted with their vectors, which must fit the current index
+		return fmt.Errorf("index of %s has %d dimensions, not %d: its entries must be re-embedded", m.from, dim, c.space.Dimensions)
+	}
+	return nil
+}
+
+// errFound stops a scan once an entry is found.
+var errFound = errors.New("found")
+
+// Active reports whether entries are still being migrated.
+func (m *Migration) Active() bool {
+	return m != nil && !m.done.Load()
+}
+
+// Migrated returns the number of entries moved so far.
+func (m *Migration) Migrated() int64 {
+	return m.migrated.Load()
+}
+
+// Stop halts the migration. The previous index is kept, so a later
+// migration resumes where this one stopped.
+func (m *Migration) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// SearchSimilar searches the previous space for entries that have not been
+// migrated yet. embedding must come from the previous space's model. Entries
+// already migrated, but still indexed because their dimensions did not
+// change, are passed over for the next nearest neighbor.
+func (m *Migration) SearchSimilar(ctx context.Context, embedding []float32, threshold float64, filter SearchFilter) (*CacheEntry, float64, error) {
+	neighbors, err := m.svc.searchNeighbors(ctx, m.from, embedding, migrationSearchK, filter)
+	if err != nil || len(neighbors) == 0 {
+		return nil, 0, err
+	}
+	for _, neighbor := range neighbors {
+		if neighbor.Similarity <= threshold {
+			break
+		}
+		if m.from.owns(neighbor.Entry) {
+			return neighbor.Entry, neighbor.Similarity, nil
+		}
+	}
+	return nil, neighbors[0].Similarity, nil
+}
+
+// run migrates entries pass by pass until a pass finds none left, then
+// retires the previous index, if the store has one.
+func (m *Migration) run(ctx context.Context) {
+	log := m.svc.logger
+	for {
+		remaining, err := m.pass(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Error("embedding migration pass failed", "error", err.Error())
+		} else if remaining == 0 {
+			break
+		} else {
+			log.Info("embedding migration pass finished", "migrated", m.Migrated(), "remaining", remaining)
+		}
+		if !sleepCtx(ctx, m.config.Interval) {
+			return
+		}
+	}
+
+	m.done.Store(true)
+	if indexed, ok := m.svc.backend.(IndexedStore); ok {
+		dropCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := indexed.DropIndex(dropCtx, m.from); err != nil {
+			log.Error("failed to drop previous index", "space", m.from.String(), "error", err.Error())
+		}
+	}
+	log.Info("embedding migration finished", "migrated", m.Migrated(), "abandoned", m.abandoned(), "retired_space", m.from.String())
+}
+
+// pass migrates every entry still in the previous space and returns how
+// many could not be migrated but will be tried again. Entries that failed
+// MaxAttempts times are skipped.
+func (m *Migration) pass(ctx context.Context) (int, error) {
+	remaining, batch := 0, 0
+	err := m.svc.Scan(ctx, func(entry *CacheEntry) error {
+		if !m.from.owns(entry) || m.failures[entry.ID] >= m.config.MaxAttempts {
+			return nil
+		}
+		if err := m.migrate(ctx, entry); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			m.failures[entry.ID]++
+			if attempts := m.failures[entry.ID]; attempts >= m.config.MaxAttempts {
+				m.svc.logger.Error("giving up on migrating cache entry", "cache_key", entry.ID, "attempts", attempts, "error", err.Error())
+			} else {
+				m.svc.logger.Error("failed to migrate cache entry", "cache_key", entry.ID, "attempts", attempts, "error", err.Error())
+				remaining++
+			}
+			return nil
+		}
+		delete(m.failures, entry.ID)
+		m.migrated.Add(1)
+		if batch++; batch >= m.config.BatchSize {
+			batch = 0
+			if !sleepCtx(ctx, m.config.Interval) {
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	return remaining, err
+}
+
+// abandoned returns how many entries failed MaxAttempts times.
+func (m *Migration) abandoned() int {
+	n := 0
+	for _, attempts := range m.failures {
+		if attempts >= m.config.MaxAttempts {
+			n++
+		}
+	}
+	return n
+}
+
+// migrate re-embeds entry if needed and moves it into the current space.
+func (m *Migration) migrate(ctx context.Context, entry *CacheEntry) error {
+	space := m.svc.space
+	var vec []float32
+	if m.config.Reembed != nil {
+		var err error
+		if vec, err = m.config.Reembed(ctx, entry.QueryText); err != nil {
+			return err
+		}
+		if space.Dimensions > 0 && len(vec) != space.Dimensions {
+			return fmt.Errorf("embedding has %d dimensions, want %d", len(vec), space.Dimensions)
+		}
+	}
+	return m.svc.backend.Retag(ctx, entry.ID, space, vec)
+}
+
+// sleepCtx waits for d and reports false if ctx ends first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
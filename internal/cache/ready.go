@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readiness tracks whether the cache store has been set up. Until it has,
+// setup is retried in the background and cache operations return
+// ErrUnavailable.
+type readiness struct {
+	ready atomic.Bool
+
+	mu      sync.Mutex
+	err     error
+	onReady []func()
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// setup prepares the store, e.g. creating its index, and starts eviction.
+func (c *CacheServiceImpl) setup(ctx context.Context) error {
+	if err := c.backend.Setup(ctx, c.space); err != nil {
+		return err
+	}
+	if c.eviction.Enabled() {
+		c.evictor = newEvictor(c.backend, c.logger, c.eviction)
+		c.evictor.invalidate = c.invalidate
+		c.evictor.start()
+	}
+	return nil
+}
+
+// retrySetup reports whether a failed setup may succeed later. Only an
+// unreachable store is retried; a missing module or a mismatched index needs
+// an operator and is reported at once.
+func retrySetup(err error) bool {
+	return isConnectionError(err) || errors.Is(err, ErrUnavailable)
+}
+
+// setupInBackground retries setup every interval until it succeeds, fails
+// with an error retrying cannot fix, or the service is closed.
+func (c *CacheServiceImpl) setupInBackground(err error, interval time.Duration) {
+	c.readiness.mu.Lock()
+	c.readiness.err = err
+	c.readiness.mu.Unlock()
+	c.logger.Error("cache unavailable, serving requests without it", "error", err.Error(), "retry_interval", interval.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.readiness.cancel = cancel
+	c.readiness.wg.Add(1)
+	go func() {
+		defer c.readiness.wg.Done()
+		for sleepCtx(ctx, interval) {
+			setupCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			err := c.setup(setupCtx)
+			cancel()
+			if err == nil {
+				c.logger.Info("cache available", "space", c.space.String())
+				c.markReady()
+				return
+			}
+			c.readiness.mu.Lock()
+			c.readiness.err = err
+			c.readiness.mu.Unlock()
+			if !retrySetup(err) {
+				c.logger.Error("cache setup failed, no longer retrying", "error", err.Error())
+				return
+			}
+			c.logger.Warn("cache still unavailable", "error", err.Error())
+		}
+	}()
+}
+
+// markReady records a successful setup and runs the OnReady callbacks.
+func (c *CacheServiceImpl) markReady() {
+	c.readiness.mu.Lock()
+	c.readiness.err = nil
+	c.readiness.ready.Store(true)
+	callbacks := c.readiness.onReady
+	c.readiness.onReady = nil
+	c.readiness.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn()
+	}
+}
+
+// stopSetup stops retrying setup and waits for an attempt in progress.
+func (c *CacheServiceImpl) stopSetup() {
+	if c.readiness.cancel != nil {
+		c.readiness.cancel()
+		c.readiness.wg.Wait()
+	}
+}
+
+// Ready reports whether the cache store has been set up.
+func (c *CacheServiceImpl) Ready() bool {
+	return c.readiness.ready.Load()
+}
+
+// OnReady runs fn once the cache store has been set up, immediately if it
+// already has been.
+func (c *CacheServiceImpl) OnReady(fn func()) {
+	c.readiness.mu.Lock()
+	if !c.readiness.ready.Load() {
+		c.readiness.onReady = append(c.readiness.onReady, fn)
+		c.readiness.mu.Unlock()
+		return
+	}
+	c.readiness.mu.Unlock()
+	fn()
+}
+
+// checkReady returns ErrUnavailable until the cache store has been set up.
+func (c *CacheServiceImpl) checkReady() error {
+	if c.Ready() {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrUnavailable, c.setupError())
+}
+
+// setupError returns why the cache is not ready yet.
+func (c *CacheServiceImpl) setupError() error {
+	c.readiness.mu.Lock()
+	defer c.readiness.mu.Unlock()
+	return c.readiness.err
+}
+
+// CheckIndex verifies the store is set up and, if it keeps a search index,
+// that the index matches the expected schema.
+func (c *CacheServiceImpl) CheckIndex(ctx context.Context) error {
+	if !c.Ready() {
+		return fmt.Errorf("index of %s not set up yet: %w", c.space, c.setupError())
+	}
+	if indexed, ok := c.backend.(IndexedStore); ok {
+		return indexed.CheckIndex(ctx, c.space)
+	}
+	return nil
+}
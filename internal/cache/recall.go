@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// RecallReport compares the nearest neighbors a vector index returns with
+// the exact nearest neighbors found by brute force.
+type RecallReport struct {
+	Vectors int `json:"vectors"`
+	Queries int `json:"queries"`
+	K       int `json:"k"`
+	// Recall is the mean fraction of each query's exact top K that the
+	// index returned; MinRecall is that of the worst query.
+	Recall    float64 `json:"recall"`
+	MinRecall float64 `json:"min_recall"`
+	// IndexTime is how long indexing the vectors took.
+	IndexTime time.Duration `json:"index_time"`
+	// Search latencies of the index.
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP95 time.Duration `json:"latency_p95"`
+}
+
+// MeasureRecall indexes vectors in a scratch index built with the store's
+// index settings, searches it for the k nearest neighbors of each query and
+// compares them with brute force over the same vectors. The scratch index
+// and its documents are deleted afterwards; cache entries are not touched.
+func (s *RedisStore) MeasureRecall(ctx context.Context, vectors, queries [][]float32, k int) (*RecallReport, error) {
+	if len(vectors) == 0 || len(queries) == 0 || k < 1 {
+		return nil, errors.New("recall needs vectors, queries and a positive k")
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	index := s.baseIndex + "_recall_" + hex.EncodeToString(suffix)
+	prefix := "recall:" + hex.EncodeToString(suffix) + ":"
+
+	cfg := s.index
+	cfg.Dimensions = len(vectors[0])
+	cfg.Prefix = prefix
+	cfg.InitialCap = len(vectors)
+	if err := s.redis.CreateVectorIndex(ctx, index, cfg); err != nil {
+		return nil, err
+	}
+	defer func() {
+		dropCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := s.redis.Client().Do(dropCtx, "FT.DROPINDEX", index, "DD").Err(); err != nil {
+			s.redis.logger.Error("failed to drop recall index", "index", index, "error", err.Error())
+		}
+	}()
+
+	// Brute force compares the vectors as the index stores them
+	indexed := make([][]float32, len(vectors))
+	for i, vec := range vectors {
+		indexed[i] = s.indexedVector(vec)
+	}
+	start := time.Now()
+	if err := s.loadRecallVectors(ctx, prefix, indexed); err != nil {
+		return nil, err
+	}
+	if err := s.waitIndexed(ctx, index, len(indexed)); err != nil {
+		return nil, err
+	}
+	report := &RecallReport{Vectors: len(vectors), Queries: len(queries), K: k, MinRecall: 1, IndexTime: time.Since(start)}
+
+	efRuntime := 0
+	if cfg.Algorithm == IndexHNSW {
+		efRuntime = s.efRuntime
+	}
+	latencies := make([]time.Duration, 0, len(queries))
+	var total float64
+	for _, query := range queries {
+		query = s.indexVector(query)
+		began := time.Now()
+		results, err := s.redis.FTSearch(ctx, index, cfg.Metric, SearchFilter{}.knnQuery(k, efRuntime),
+			"PARAMS", "2", "vec", vectorBlob(query, cfg.VectorType),
+			"NOCONTENT",
+			"SORTBY", vectorScoreField,
+			"LIMIT", "0", k,
+			"DIALECT", "2",
+		)
+		if err != nil {
+			return nil, fmt.Errorf("vector search failed: %w", err)
+		}
+		latencies = append(latencies, time.Since(began))
+
+		returned := make([]string, len(results))
+		for i, result := range results {
+			returned[i] = result.Key
+		}
+		exact := make([]string, 0, k)
+		for _, i := range exactNeighbors(indexed, query, k, cfg.Metric) {
+			exact = append(exact, prefix+strconv.Itoa(i))
+		}
+		r := recall(exact, returned)
+		total += r
+		if r < report.MinRecall {
+			report.MinRecall = r
+		}
+	}
+	report.Recall = total / float64(len(queries))
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.LatencyP50 = latencies[len(latencies)/2]
+	report.LatencyP95 = latencies[len(latencies)*95/100]
+	return report, nil
+}
+
+// loadRecallVectors stores each vector as a JSON document under prefix + its position.
+func (s *RedisStore) loadRecallVectors(ctx context.Context, prefix string, vectors [][]float32) error {
+	const batch = 500
+	for start := 0; start < len(vectors); start += batch {
+		pipe := s.redis.Client().Pipeline()
+		for i := start; i < len(vectors) && i < start+batch; i++ {
+			data, err := json.Marshal(map[string][]float32{s.index.vectorField(): vectors[i]})
+			if err != nil {
+				return err
+			}
+			pipe.Do(ctx, "JSON.SET", prefix+strconv.Itoa(i), "$", string(data))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to store recall vectors: %w", err)
+		}
+	}
+	return nil
+}
+
+// waitIndexed waits until the index holds n documents and has finished indexing.
+func (s *RedisStore) waitIndexed(ctx context.Context, index string, n int) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		info, err := s.redis.FTInfo(ctx, index)
+		if err != nil {
+			return err
+		}
+		docs, _ := attributeInt(info, "num_docs")
+		indexing, _ := attributeInt(info, "indexing")
+		if docs >= n && indexing == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// exactNeighbors returns the positions of the k vectors nearest to query
+// under the metric, nearest first.
+func exactNeighbors(vectors [][]float32, query []float32, k int, metric string) []int {
+	type scored struct {
+		pos   int
+		score float64
+	}
+	all := make([]scored, len(vectors))
+	for i, vec := range vectors {
+		all[i] = scored{i, vectorScore(metric, query, vec)}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].score > all[j].score })
+	if len(all) > k {
+		all = all[:k]
+	}
+	out := make([]int, len(all))
+	for i, s := range all {
+		out[i] = s.pos
+	}
+	return out
+}
+
+// vectorScore rates how near b is to a under the metric; higher is nearer.
+func vectorScore(metric string, a, b []float32) float64 {
+	var dot, normA, normB, dist float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		dist += (x - y) * (x - y)
+	}
+	switch metric {
+	case MetricIP:
+		return dot
+	case MetricL2:
+		return -dist
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// recall returns the fraction of exact found in returned.
+func recall(exact, returned []string) float64 {
+	if len(exact) == 0 {
+		return 1
+	}
+	found := make(map[string]bool, len(returned))
+	for _, id := range returned {
+		found[id] = true
+	}
+	var hits int
+	for _, id := range exact {
+		if found[id] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(exact))
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestExactNeighbors verifies brute force ranks vectors by the index's metric.
+func TestExactNeighbors(t *testing.T) {
+	vectors := [][]float32{
+		{1, 0},     // same direction as the query, but far away
+		{0.3, 0.1}, // close to the query, at an angle
+		{0, 1},
+		{-1, 0},
+	}
+	query := []float32{0.3, 0}
+
+	tests := []struct {
+		metric string
+		want   []int
+	}{
+		{MetricCosine, []int{0, 1}},
+		{MetricIP, []int{0, 1}},
+		{MetricL2, []int{1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			if got := exactNeighbors(vectors, query, 2, tt.metric); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exactNeighbors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := exactNeighbors(vectors, query, 10, MetricCosine); len(got) != len(vectors) {
+		t.Errorf("k above the vector count returned %d neighbors", len(got))
+	}
+}
+
+// TestRecall verifies recall is the fraction of exact neighbors returned,
+// regardless of their order.
+func TestRecall(t *testing.T) {
+	exact := []string{"a", "b", "c", "d"}
+	if r := recall(exact, []string{"d", "c", "b", "a"}); r != 1 {
+		t.Errorf("all neighbors returned: recall %f", r)
+	}
+	if r := recall(exact, []string{"a", "x", "c"}); r != 0.5 {
+		t.Errorf("half the neighbors returned: recall %f", r)
+	}
+	if r := recall(nil, nil); r != 1 {
+		t.Errorf("no exact neighbors: recall %f", r)
+	}
+}
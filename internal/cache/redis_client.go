@@ -1,4 +1,5 @@
-// Package cache provides caching functionality using Redis Stack.
+// Package cache provides the semantic cache service on pluggable vector
+// stores, with Redis Stack as the default.
 package cache
 
 import (
@@ -15,19 +16,47 @@ import (
 // RedisClient wraps the go-redis client with additional functionality
 // for JSON operations and vector search queries.
 type RedisClient struct {
-	client *redis.Client
-	logger *logger.Logger
+	client  redis.UniversalClient
+	logger  *logger.Logger
+	breaker *Breaker
 }
 
-// RedisConfig holds configuration for the Redis connection.
+// RedisConfig holds configuration for the Redis connection. URL addresses a
+// single node; SentinelMaster or ClusterAddrs select a Sentinel-managed or
+// clustered deployment instead.
 type RedisConfig struct {
-	URL            string
-	MaxRetries     int
-	DialTimeout    time.Duration
-	ReadTimeout    time.Duration
-	WriteTimeout   time.Duration
-	PoolSize       int
-	MinIdleConns   int
+	URL          string
+	MaxRetries   int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolSize     int
+	MinIdleConns int
+
+	// Username and Password authenticate with ACL credentials, overriding
+	// any in URL.
+	Username string
+	Password string
+
+	// SentinelMaster is the master name monitored by the Sentinels at
+	// SentinelAddrs, which may require their own password.
+	SentinelMaster   string
+	SentinelAddrs    []string
+	SentinelPassword string
+
+	// ClusterAddrs seeds a Redis Cluster client with one or more nodes.
+	ClusterAddrs []string
+
+	// TLS enables TLS without a rediss:// URL. CA, certificate and key files
+	// imply it; the certificate and key authenticate the client.
+	TLS           bool
+	TLSCAFile     string
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSServerName string
+
+	// Breaker, when set, fails commands fast while Redis is unreachable.
+	Breaker *BreakerConfig
 }
 
 // DefaultRedisConfig returns a RedisConfig with sensible defaults.
@@ -45,27 +74,33 @@ func DefaultRedisConfig(url string) *RedisConfig {
 
 // NewRedisClient creates a new Redis client with the given configuration.
 func NewRedisClient(cfg *RedisConfig, log *logger.Logger) (*RedisClient, error) {
-	opts, err := redis.ParseURL(cfg.URL)
+	opts, err := cfg.universalOptions()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		return nil, err
 	}
 
-	opts.MaxRetries = cfg.MaxRetries
-	opts.DialTimeout = cfg.DialTimeout
-	opts.ReadTimeout = cfg.ReadTimeout
-	opts.WriteTimeout = cfg.WriteTimeout
-	opts.PoolSize = cfg.PoolSize
-	opts.MinIdleConns = cfg.MinIdleConns
-
-	client := redis.NewClient(opts)
+	var client redis.UniversalClient
+	switch {
+	case cfg.SentinelMaster != "":
+		client = redis.NewFailoverClient(opts.Failover())
+	case len(cfg.ClusterAddrs) > 0:
+		// NewUniversalClient would treat a single seed as a standalone node
+		client = redis.NewClusterClient(opts.Cluster())
+	default:
+		client = redis.NewClient(opts.Simple())
+	}
 
-	return &RedisClient{
+	r := &RedisClient{
 		client: client,
 		logger: log,
-	}, nil
+	}
+	if cfg.Breaker != nil {
+		r.breaker = NewBreaker(*cfg.Breaker)
+		client.AddHook(breakerHook{breaker: r.breaker})
+	}
+	return r, nil
 }
 
-
 // Ping checks the Redis connection health.
 func (r *RedisClient) Ping(ctx context.Context) error {
 	_, err := r.client.Ping(ctx).Result()
@@ -86,6 +121,14 @@ func (r *RedisClient) IsHealthy(ctx context.Context) bool {
 	return err == nil
 }
 
+// BreakerState returns the circuit breaker state, or "" without a breaker.
+func (r *RedisClient) BreakerState() string {
+	if r.breaker == nil {
+		return ""
+	}
+	return r.breaker.State()
+}
+
 // JSONSet stores a JSON value at the specified key and path.
 func (r *RedisClient) JSONSet(ctx context.Context, key string, path string, value interface{}) error {
 	data, err := json.Marshal(value)
@@ -138,134 +181,165 @@ func (r *RedisClient) Exists(ctx context.Context, key string) (bool, error) {
 }
 
 
-// SearchResult represents a single result from a vector search.
-type SearchResult struct {
-	Key        string
-	Score      float64
-	Document   []byte
-}
-
 // FTSearch performs a vector similarity search using RediSearch.
-// The query should be a properly formatted RediSearch query string.
-func (r *RedisClient) FTSearch(ctx context.Context, index string, query string, args ...interface{}) ([]SearchResult, error) {
+// The query should be a properly formatted RediSearch query string. Vector
+// scores are converted to similarities for the index's distance metric.
+func (r *RedisClient) FTSearch(ctx context.Context, index string, metric string, query string, args ...interface{}) ([]SearchResult, error) {
 	// Build the FT.SEARCH command arguments
 	cmdArgs := []interface{}{"FT.SEARCH", index, query}
 	cmdArgs = append(cmdArgs, args...)
 
-	cmd := r.client.Do(ctx, cmdArgs...)
-	if cmd.Err() != nil {
-		return nil, fmt.Errorf("FT.SEARCH failed: %w", cmd.Err())
-	}
-
-	// Parse the search results
-	return r.parseSearchResults(cmd)
-}
-
-// parseSearchResults parses the raw FT.SEARCH response into SearchResult structs.
-func (r *RedisClient) parseSearchResults(cmd *redis.Cmd) ([]SearchResult, error) {
-	raw, err := cmd.Slice()
+	raw, err := r.client.Do(ctx, cmdArgs...).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse search results: %w", err)
-	}
-
-	if len(raw) == 0 {
-		return nil, nil
-	}
-
-	// First element is the total count
-	totalCount, ok := raw[0].(int64)
-	if !ok {
-		return nil, fmt.Errorf("unexpected result format: first element is not count")
+		return nil, fmt.Errorf("FT.SEARCH failed: %w", err)
 	}
+	return decodeSearchReply(raw, newSearchReplyLayout(metric, args))
+}
 
-	if totalCount == 0 {
-		return nil, nil
+// distanceToSimilarity converts a vector score to a cosine similarity. IP and
+// L2 indexes hold unit-length vectors, for which the inner product equals the
+// cosine and RediSearch's squared L2 distance is 2 - 2*cosine.
+func distanceToSimilarity(metric string, distance float64) float64 {
+	if metric == MetricL2 {
+		return 1 - distance/2
 	}
+	// COSINE and IP report 1 - cosine and 1 - inner product
+	return 1 - distance
+}
 
-	var results []SearchResult
-
-	// Results come in pairs: key, [field, value, field, value, ...]
-	i := 1
-	for i < len(raw) {
-		if i >= len(raw) {
-			break
-		}
-
-		// Get the key
-		key, ok := raw[i].(string)
-		if !ok {
-			i++
-			continue
-		}
-		i++
+// Vector index algorithms.
+const (
+	IndexHNSW = "HNSW"
+	IndexFlat = "FLAT"
+)
 
-		result := SearchResult{Key: key}
+// Distance metrics of the vector index.
+const (
+	MetricCosine = "COSINE"
+	MetricIP     = "IP"
+	MetricL2     = "L2"
+)
 
-		// Get the fields array
-		if i < len(raw) {
-			if fields, ok := raw[i].([]interface{}); ok {
-				result.Score, result.Document = r.parseSearchFields(fields)
-			}
-			i++
-		}
+// VectorIndexConfig describes the vector field of the cache index.
+type VectorIndexConfig struct {
+	Dimensions int
+	// VectorType is the element type: FLOAT32 (default), FLOAT16 or INT8.
+	VectorType string
+	// Algorithm is HNSW (default) or FLAT, which compares every vector.
+	Algorithm string
+	// Metric is the distance metric: COSINE (default), IP or L2.
+	Metric string
+	// M and EFConstruction tune the HNSW graph; zero keeps the server defaults.
+	M              int
+	EFConstruction int
+	// InitialCap preallocates room for this many vectors; zero keeps the
+	// server default.
+	InitialCap int
+	// Prefix is the key prefix of indexed documents, "cache:" by default.
+	Prefix string
+	// RecreateOnMismatch drops and recreates an existing index whose schema
+	// differs from the expected one instead of failing.
+	RecreateOnMismatch bool
+}
 
-		results = append(results, result)
+// withDefaults fills in the defaults of unset fields.
+func (cfg VectorIndexConfig) withDefaults() VectorIndexConfig {
+	if cfg.VectorType == "" {
+		cfg.VectorType = VectorFloat32
 	}
-
-	return results, nil
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = IndexHNSW
+	}
+	if cfg.Metric == "" {
+		cfg.Metric = MetricCosine
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = keyPrefix
+	}
+	return cfg
 }
 
+// transformsVectors reports whether the index needs vectors other than the
+// embeddings as generated: normalized for IP and L2, or scaled for INT8.
+// FLOAT16 indexes convert the stored floats themselves.
+func (cfg VectorIndexConfig) transformsVectors() bool {
+	cfg = cfg.withDefaults()
+	return cfg.VectorType == VectorInt8 || cfg.Metric != MetricCosine
+}
 
-// parseSearchFields extracts score and document from search result fields.
-func (r *RedisClient) parseSearchFields(fields []interface{}) (float64, []byte) {
-	var score float64
-	var document []byte
+// vectorField names the JSON field the index reads vectors from. Entries keep
+// their embedding as generated; indexes that transform vectors read a
+// transformed copy from "vector".
+func (cfg VectorIndexConfig) vectorField() string {
+	if cfg.transformsVectors() {
+		return "vector"
+	}
+	return "embedding"
+}
 
-	for i := 0; i < len(fields)-1; i += 2 {
-		fieldName, ok := fields[i].(string)
-		if !ok {
-			continue
+// vectorFieldArgs returns the FT.CREATE schema arguments of the embedding field.
+func (cfg VectorIndexConfig) vectorFieldArgs() []interface{} {
+	cfg = cfg.withDefaults()
+	params := []interface{}{
+		"TYPE", cfg.VectorType,
+		"DIM", cfg.Dimensions,
+		"DISTANCE_METRIC", cfg.Metric,
+	}
+	if cfg.Algorithm == IndexHNSW {
+		if cfg.M > 0 {
+			params = append(params, "M", cfg.M)
 		}
-
-		switch fieldName {
-		case "__vector_score":
-			if scoreStr, ok := fields[i+1].(string); ok {
-				fmt.Sscanf(scoreStr, "%f", &score)
-				// Convert distance to similarity (1 - distance for cosine)
-				score = 1 - score
-			}
-		case "$":
-			if docStr, ok := fields[i+1].(string); ok {
-				document = []byte(docStr)
-			}
+		if cfg.EFConstruction > 0 {
+			params = append(params, "EF_CONSTRUCTION", cfg.EFConstruction)
 		}
 	}
-
-	return score, document
+	if cfg.InitialCap > 0 {
+		params = append(params, "INITIAL_CAP", cfg.InitialCap)
+	}
+	args := []interface{}{"$." + cfg.vectorField(), "AS", "embedding", "VECTOR", cfg.Algorithm, len(params)}
+	return append(args, params...)
 }
 
-// CreateVectorIndex creates an HNSW vector index for cache entries.
-func (r *RedisClient) CreateVectorIndex(ctx context.Context, indexName string, dimensions int) error {
+// CreateVectorIndex creates a vector index for cache entries.
+func (r *RedisClient) CreateVectorIndex(ctx context.Context, indexName string, cfg VectorIndexConfig) error {
+	cfg = cfg.withDefaults()
+
 	// Check if index already exists
-	cmd := r.client.Do(ctx, "FT.INFO", indexName)
-	if cmd.Err() == nil {
-		// Index already exists
-		r.logger.Info("vector index already exists", "index", indexName)
-		return nil
+	if _, err := r.FTInfo(ctx, indexName); err == nil {
+		if err := r.addMissingFields(ctx, indexName); err != nil {
+			return err
+		}
+		info, err := r.FTInfo(ctx, indexName)
+		if err != nil {
+			return err
+		}
+		problems := schemaProblems(info, cfg)
+		if len(problems) == 0 {
+			r.logger.Info("vector index already exists", "index", indexName)
+			return nil
+		}
+		if !cfg.RecreateOnMismatch {
+			return fmt.Errorf("index %s %s: drop it, set CACHE_INDEX_AUTO_RECREATE, or change EMBEDDING_VERSION to create a new index and migrate entries",
+				indexName, strings.Join(problems, "; "))
+		}
+		r.logger.Warn("recreating vector index with a different schema", "index", indexName, "problems", strings.Join(problems, "; "))
+		if err := r.DropIndex(ctx, indexName); err != nil {
+			return err
+		}
 	}
 
-	// Create the index with HNSW algorithm
-	createCmd := r.client.Do(ctx,
+	args := []interface{}{
 		"FT.CREATE", indexName,
 		"ON", "JSON",
-		"PREFIX", "1", "cache:",
+		"PREFIX", "1", cfg.Prefix,
 		"SCHEMA",
 		"$.query_hash", "AS", "query_hash", "TAG",
-		"$.embedding", "AS", "embedding", "VECTOR", "HNSW", "6",
-		"TYPE", "FLOAT32",
-		"DIM", dimensions,
-		"DISTANCE_METRIC", "COSINE",
-	)
+	}
+	for _, field := range filterFields {
+		args = append(args, field...)
+	}
+	args = append(args, cfg.vectorFieldArgs()...)
+	createCmd := r.client.Do(ctx, args...)
 
 	if createCmd.Err() != nil {
 		// Check if error is because index already exists
@@ -276,11 +350,86 @@ func (r *RedisClient) CreateVectorIndex(ctx context.Context, indexName string, d
 		return fmt.Errorf("FT.CREATE failed: %w", createCmd.Err())
 	}
 
-	r.logger.Info("created vector index", "index", indexName, "dimensions", dimensions)
+	r.logger.Info("created vector index", "index", indexName, "dimensions", cfg.Dimensions, "type", cfg.VectorType,
+		"algorithm", cfg.Algorithm, "metric", cfg.Metric)
+	return nil
+}
+
+// filterFields are the index attributes used as search pre-filters.
+var filterFields = [][]interface{}{
+	{"$.template_key", "AS", "template_key", "TAG"},
+	{"$.model", "AS", "model", "TAG"},
+	{"$.tenant", "AS", "tenant", "TAG"},
+	{"$.system_hash", "AS", "system_hash", "TAG"},
+	{"$.language", "AS", "language", "TAG"},
+	{"$.created_at", "AS", "created_at", "NUMERIC"},
+	{"$.embedding_model", "AS", "embedding_model", "TAG"},
+	{"$.embedding_version", "AS", "embedding_version", "TAG"},
+}
+
+// addMissingFields adds filter fields to indexes created before they existed.
+// Existing entries are reindexed in the background.
+func (r *RedisClient) addMissingFields(ctx context.Context, indexName string) error {
+	for _, field := range filterFields {
+		args := append([]interface{}{"FT.ALTER", indexName, "SCHEMA", "ADD"}, field...)
+		err := r.client.Do(ctx, args...).Err()
+		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
+			return fmt.Errorf("FT.ALTER failed: %w", err)
+		}
+	}
 	return nil
 }
 
-// Client returns the underlying redis.Client for advanced operations.
-func (r *RedisClient) Client() *redis.Client {
+// Client returns the underlying client for advanced operations. It is a
+// *redis.ClusterClient in cluster mode.
+func (r *RedisClient) Client() redis.UniversalClient {
 	return r.client
 }
+
+// FTInfo returns the attributes reported by FT.INFO for an index. Nested
+// replies are returned as-is; key/value lists are flattened into the map.
+func (r *RedisClient) FTInfo(ctx context.Context, indexName string) (map[string]interface{}, error) {
+	raw, err := r.client.Do(ctx, "FT.INFO", indexName).Result()
+	if err != nil {
+		return nil, fmt.Errorf("FT.INFO failed: %w", err)
+	}
+	info, ok := replyToMap(raw)
+	if !ok {
+		return nil, fmt.Errorf("unexpected FT.INFO reply type %T", raw)
+	}
+	return info, nil
+}
+
+// DropIndex removes an index. Documents are kept so they can be re-indexed.
+func (r *RedisClient) DropIndex(ctx context.Context, indexName string) error {
+	if err := r.client.Do(ctx, "FT.DROPINDEX", indexName).Err(); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "unknown index name") {
+			return nil
+		}
+		return fmt.Errorf("FT.DROPINDEX failed: %w", err)
+	}
+	return nil
+}
+
+// replyToMap converts a RESP2 flat key/value array or a RESP3 map reply into
+// a string-keyed map.
+func replyToMap(raw interface{}) (map[string]interface{}, bool) {
+	out := make(map[string]interface{})
+	switch v := raw.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range v {
+			out[fmt.Sprint(key)] = value
+		}
+	case map[string]interface{}:
+		for key, value := range v {
+			out[key] = value
+		}
+	case []interface{}:
+		for i := 0; i+1 < len(v); i += 2 {
+			out[fmt.Sprint(v[i])] = v[i+1]
+		}
+	default:
+		return nil, false
+	}
+	return out, true
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// universalOptions translates the configuration into go-redis options for a
+// standalone, Sentinel or cluster client.
+func (cfg *RedisConfig) universalOptions() (*redis.UniversalOptions, error) {
+	if cfg.SentinelMaster != "" && len(cfg.ClusterAddrs) > 0 {
+		return nil, errors.New("redis sentinel and cluster modes are mutually exclusive")
+	}
+
+	opts := &redis.UniversalOptions{
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelPassword: cfg.SentinelPassword,
+		MasterName:       cfg.SentinelMaster,
+		MaxRetries:       cfg.MaxRetries,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+	}
+
+	switch {
+	case cfg.SentinelMaster != "":
+		if len(cfg.SentinelAddrs) == 0 {
+			return nil, errors.New("redis sentinel mode requires sentinel addresses")
+		}
+		opts.Addrs = cfg.SentinelAddrs
+	case len(cfg.ClusterAddrs) > 0:
+		opts.Addrs = cfg.ClusterAddrs
+	default:
+		parsed, err := redis.ParseURL(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		opts.Addrs = []string{parsed.Addr}
+		opts.DB = parsed.DB
+		opts.TLSConfig = parsed.TLSConfig
+		if opts.Username == "" && opts.Password == "" {
+			opts.Username, opts.Password = parsed.Username, parsed.Password
+		}
+	}
+
+	tlsConfig, err := cfg.tlsConfig(opts.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	opts.TLSConfig = tlsConfig
+	return opts, nil
+}
+
+// tlsConfig extends base, set by rediss:// URLs, with the configured CA,
+// client certificate and server name. It returns nil when TLS is off.
+func (cfg *RedisConfig) tlsConfig(base *tls.Config) (*tls.Config, error) {
+	enabled := base != nil || cfg.TLS || cfg.TLSCAFile != "" || cfg.TLSCertFile != "" || cfg.TLSKeyFile != ""
+	if !enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		tlsConfig = base.Clone()
+	}
+	if cfg.TLSServerName != "" {
+		tlsConfig.ServerName = cfg.TLSServerName
+	}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Redis CA file %s", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, errors.New("redis TLS client certificate and key must be set together")
+	}
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// ScanKeys calls fn with each batch of keys matching pattern. In cluster mode
+// every master is scanned; fn is never called concurrently.
+func (r *RedisClient) ScanKeys(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	cluster, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return scanNode(ctx, r.client, pattern, fn)
+	}
+
+	var mu sync.Mutex
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		return scanNode(ctx, node, pattern, func(keys []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return fn(keys)
+		})
+	})
+}
+
+// scanNode runs SCAN over a single node.
+func scanNode(ctx context.Context, client redis.Cmdable, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, nextCursor, err := client.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan keys: %w", err)
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		cursor = nextCursor
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// Unlink deletes keys and returns how many existed. In cluster mode keys
+// are unlinked one by one, as they may hash to different slots.
+func (r *RedisClient) Unlink(ctx context.Context, keys ...string) (int64, error) {
+	if _, ok := r.client.(*redis.ClusterClient); !ok {
+		return r.client.Unlink(ctx, keys...).Result()
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Unlink(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, cmd := range cmds {
+		deleted += cmd.Val()
+	}
+	return deleted, nil
+}
@@ -0,0 +1,440 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultIndexName is the base name of the RediSearch index.
+const DefaultIndexName = "cache_idx"
+
+// RedisStoreConfig configures a RedisStore.
+type RedisStoreConfig struct {
+	// IndexName is the base index name. Versioned embedding spaces append
+	// their model and version to it.
+	IndexName string
+	// VectorType is the index element type (FLOAT32, FLOAT16, INT8).
+	VectorType string
+	// Algorithm (HNSW, FLAT), Metric (COSINE, IP, L2), M, EFConstruction and
+	// InitialCap are passed to FT.CREATE; see VectorIndexConfig.
+	Algorithm      string
+	Metric         string
+	M              int
+	EFConstruction int
+	InitialCap     int
+	// EFRuntime is the HNSW candidate list size of each query; zero keeps
+	// the index default. Larger values improve recall at the cost of latency.
+	EFRuntime int
+	// AutoRecreateIndex drops and recreates an existing index whose schema does
+	// not match instead of failing. Entries are reindexed in the background.
+	AutoRecreateIndex bool
+}
+
+// RedisStore keeps entries as RedisJSON documents and searches them with a
+// RediSearch vector index per embedding space.
+type RedisStore struct {
+	redis             *RedisClient
+	baseIndex         string
+	index             VectorIndexConfig
+	efRuntime         int
+	autoRecreateIndex bool
+}
+
+// NewRedisStore creates a store on the given Redis client.
+func NewRedisStore(redis *RedisClient, cfg RedisStoreConfig) *RedisStore {
+	if cfg.IndexName == "" {
+		cfg.IndexName = DefaultIndexName
+	}
+	index := VectorIndexConfig{
+		VectorType:     cfg.VectorType,
+		Algorithm:      cfg.Algorithm,
+		Metric:         cfg.Metric,
+		M:              cfg.M,
+		EFConstruction: cfg.EFConstruction,
+		InitialCap:     cfg.InitialCap,
+	}
+	return &RedisStore{
+		redis:             redis,
+		baseIndex:         cfg.IndexName,
+		index:             index.withDefaults(),
+		efRuntime:         cfg.EFRuntime,
+		autoRecreateIndex: cfg.AutoRecreateIndex,
+	}
+}
+
+// Redis returns the client the store uses.
+func (s *RedisStore) Redis() *RedisClient {
+	return s.redis
+}
+
+// indexName returns the name of the space's index.
+func (s *RedisStore) indexName(space EmbeddingSpace) string {
+	return space.IndexName(s.baseIndex)
+}
+
+// indexConfig returns the expected configuration of the space's index.
+func (s *RedisStore) indexConfig(space EmbeddingSpace) VectorIndexConfig {
+	cfg := s.index
+	cfg.Dimensions = space.Dimensions
+	return cfg
+}
+
+// indexVector prepares an embedding for the index: IP and L2 scores only
+// equal cosine similarities for unit-length vectors.
+func (s *RedisStore) indexVector(vec []float32) []float32 {
+	if s.index.Metric == MetricCosine {
+		return vec
+	}
+	return normalize(vec)
+}
+
+// normalize returns vec scaled to unit length. Zero vectors are returned as-is.
+func normalize(vec []float32) []float32 {
+	var sum float64
+	for _, f := range vec {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := math.Sqrt(sum)
+	out := make([]float32, len(vec))
+	for i, f := range vec {
+		out[i] = float32(float64(f) / norm)
+	}
+	return out
+}
+
+// Setup checks the Redis modules and creates the space's index.
+func (s *RedisStore) Setup(ctx context.Context, space EmbeddingSpace) error {
+	if err := s.redis.CheckModules(ctx); err != nil {
+		return err
+	}
+	indexCfg := s.indexConfig(space)
+	indexCfg.RecreateOnMismatch = s.autoRecreateIndex
+	if err := s.redis.CreateVectorIndex(ctx, s.indexName(space), indexCfg); err != nil {
+		return fmt.Errorf("failed to create vector index: %w", err)
+	}
+	return nil
+}
+
+// Get returns the entry stored under id, or nil if it does not exist.
+func (s *RedisStore) Get(ctx context.Context, id string) (*CacheEntry, error) {
+	data, err := s.redis.JSONGet(ctx, id, "$")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache entry: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	var entries []CacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cache entry: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// indexedVector returns vec as the index reads it: normalized for the
+// index's metric and quantized to its vector type.
+func (s *RedisStore) indexedVector(vec []float32) []float32 {
+	return quantizeEmbedding(s.indexVector(vec), s.index.VectorType)
+}
+
+// Upsert stores entry as a JSON document. The embedding is stored as given;
+// indexes that transform vectors read them from an extra "vector" field.
+func (s *RedisStore) Upsert(ctx context.Context, entry *CacheEntry, ttl time.Duration) error {
+	var doc interface{} = entry
+	if s.index.transformsVectors() {
+		doc = struct {
+			*CacheEntry
+			Vector []float32 `json:"vector"`
+		}{entry, s.indexedVector(entry.Embedding)}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := s.redis.JSONSetRaw(ctx, entry.ID, "$", string(data)); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+
+	// Set TTL for automatic expiration
+	if ttl > 0 {
+		if err := s.redis.Client().Expire(ctx, entry.ID, ttl).Err(); err != nil {
+			s.redis.logger.Error("failed to set TTL", "error", err.Error(), "key", entry.ID)
+		}
+	}
+	return nil
+}
+
+// Search runs a KNN query against the space's index.
+func (s *RedisStore) Search(ctx context.Context, space EmbeddingSpace, embedding []float32, k int, filter SearchFilter) ([]Neighbor, error) {
+	efRuntime := 0
+	if s.index.Algorithm == IndexHNSW {
+		efRuntime = s.efRuntime
+	}
+	results, err := s.redis.FTSearch(ctx, s.indexName(space), s.index.Metric, filter.knnQuery(k, efRuntime),
+		"PARAMS", "2", "vec", vectorBlob(s.indexVector(embedding), s.index.VectorType),
+		"RETURN", "2", "$", vectorScoreField,
+		"SORTBY", vectorScoreField,
+		"LIMIT", "0", k,
+		"DIALECT", "2",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+
+	neighbors := make([]Neighbor, 0, len(results))
+	for _, result := range results {
+		if result.Document == nil {
+			continue
+		}
+		var entry CacheEntry
+		if err := json.Unmarshal(result.Document, &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cache entry %s: %w", result.Key, err)
+		}
+		neighbors = append(neighbors, Neighbor{Entry: &entry, Similarity: result.Score})
+	}
+	return neighbors, nil
+}
+
+// Delete unlinks the entries' keys.
+func (s *RedisStore) Delete(ctx context.Context, ids ...string) (int64, error) {
+	return s.redis.Unlink(ctx, ids...)
+}
+
+// ScanIDs scans the keys matching pattern on every node.
+func (s *RedisStore) ScanIDs(ctx context.Context, pattern string, fn func(ids []string) error) error {
+	return s.redis.ScanKeys(ctx, pattern, fn)
+}
+
+// Scan reads every entry, one pipelined batch of keys at a time.
+func (s *RedisStore) Scan(ctx context.Context, fn func(*CacheEntry) error) error {
+	client := s.redis.Client()
+
+	return s.redis.ScanKeys(ctx, keyPrefix+"*", func(keys []string) error {
+		pipe := client.Pipeline()
+		cmds := make([]*redis.Cmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.Do(ctx, "JSON.GET", key, "$")
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to read cache entries: %w", err)
+		}
+
+		for i, cmd := range cmds {
+			data, err := cmd.Text()
+			if err != nil {
+				continue
+			}
+			var entries []CacheEntry
+			if err := json.Unmarshal([]byte(data), &entries); err != nil || len(entries) == 0 {
+				s.redis.logger.Error("skipping unreadable cache entry", "cache_key", keys[i])
+				continue
+			}
+			if err := fn(&entries[0]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stats reads the access metadata of the entries and, with withBytes, their
+// memory usage.
+func (s *RedisStore) Stats(ctx context.Context, ids []string, withBytes bool) ([]EntryStats, error) {
+	pipe := s.redis.Client().Pipeline()
+	metaCmds := make([]*redis.Cmd, len(ids))
+	sizeCmds := make([]*redis.IntCmd, len(ids))
+	for i, id := range ids {
+		metaCmds[i] = pipe.Do(ctx, "JSON.GET", id, "$.hit_count", "$.last_accessed_at", "$.created_at")
+		if withBytes {
+			sizeCmds[i] = pipe.MemoryUsage(ctx, id)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read entry metadata: %w", err)
+	}
+
+	stats := make([]EntryStats, 0, len(ids))
+	for i, id := range ids {
+		raw, err := metaCmds[i].Text()
+		if err != nil {
+			// Expired or deleted since the scan
+			continue
+		}
+		var meta map[string][]int64
+		if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+			continue
+		}
+
+		stat := EntryStats{ID: id}
+		if v := meta["$.hit_count"]; len(v) > 0 {
+			stat.HitCount = v[0]
+		}
+		if v := meta["$.last_accessed_at"]; len(v) > 0 {
+			stat.LastAccessedAt = v[0]
+		} else if v := meta["$.created_at"]; len(v) > 0 {
+			stat.LastAccessedAt = v[0]
+		}
+		if sizeCmds[i] != nil {
+			stat.Bytes, _ = sizeCmds[i].Result()
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// Touch updates the entry's access metadata in one pipeline.
+func (s *RedisStore) Touch(ctx context.Context, id string, accessedAt, expiresAt int64, ttl time.Duration) error {
+	pipe := s.redis.Client().Pipeline()
+	pipe.Do(ctx, "JSON.NUMINCRBY", id, "$.hit_count", 1)
+	pipe.Do(ctx, "JSON.SET", id, "$.last_accessed_at", accessedAt)
+	if expiresAt > 0 {
+		pipe.Do(ctx, "JSON.SET", id, "$.expires_at", expiresAt)
+	}
+	if ttl > 0 {
+		pipe.Expire(ctx, id, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record cache hit: %w", err)
+	}
+	return nil
+}
+
+// AddFeedback increments a feedback counter of the entry.
+func (s *RedisStore) AddFeedback(ctx context.Context, id string, positive bool) (FeedbackCounts, error) {
+	exists, err := s.redis.Exists(ctx, id)
+	if err != nil {
+		return FeedbackCounts{}, err
+	}
+	if !exists {
+		return FeedbackCounts{}, ErrEntryNotFound
+	}
+
+	field := "$.feedback_down"
+	if positive {
+		field = "$.feedback_up"
+	}
+
+	pipe := s.redis.Client().Pipeline()
+	// Entries written before feedback support lack the counters
+	pipe.Do(ctx, "JSON.SET", id, "$.feedback_up", 0, "NX")
+	pipe.Do(ctx, "JSON.SET", id, "$.feedback_down", 0, "NX")
+	pipe.Do(ctx, "JSON.NUMINCRBY", id, field, 1)
+	get := pipe.Do(ctx, "JSON.GET", id, "$.feedback_up", "$.feedback_down")
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return FeedbackCounts{}, fmt.Errorf("failed to record feedback: %w", err)
+	}
+
+	raw, err := get.Text()
+	if err != nil {
+		return FeedbackCounts{}, ErrEntryNotFound
+	}
+	var counts map[string][]int64
+	if err := json.Unmarshal([]byte(raw), &counts); err != nil {
+		return FeedbackCounts{}, fmt.Errorf("failed to read feedback counts: %w", err)
+	}
+
+	var result FeedbackCounts
+	if v := counts["$.feedback_up"]; len(v) > 0 {
+		result.Up = v[0]
+	}
+	if v := counts["$.feedback_down"]; len(v) > 0 {
+		result.Down = v[0]
+	}
+	return result, nil
+}
+
+// Retag sets the entry's embedding space, and embedding if given, in one
+// transaction. JSON.SET below the root fails for missing keys, so entries
+// deleted in the meantime are not recreated.
+func (s *RedisStore) Retag(ctx context.Context, id string, space EmbeddingSpace, embedding []float32) error {
+	model, version := "", ""
+	if space.Versioned() {
+		model, version = space.Model, space.Version
+	}
+	fields := map[string]interface{}{
+		"$.embedding_model":   model,
+		"$.embedding_version": version,
+	}
+	if embedding != nil {
+		fields["$.embedding"] = embedding
+		if s.index.transformsVectors() {
+			fields["$.vector"] = s.indexedVector(embedding)
+		}
+	}
+
+	pipe := s.redis.Client().TxPipeline()
+	for path, value := range fields {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		pipe.Do(ctx, "JSON.SET", id, path, string(data))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Close closes the Redis client.
+func (s *RedisStore) Close() error {
+	return s.redis.Close()
+}
+
+// CheckIndex verifies the space's index exists and matches the expected schema.
+func (s *RedisStore) CheckIndex(ctx context.Context, space EmbeddingSpace) error {
+	index := s.indexName(space)
+	info, err := s.redis.FTInfo(ctx, index)
+	if err != nil {
+		return err
+	}
+	if problems := schemaProblems(info, s.indexConfig(space)); len(problems) > 0 {
+		return fmt.Errorf("index %s %s", index, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// IndexInfo returns FT.INFO for the space's index.
+func (s *RedisStore) IndexInfo(ctx context.Context, space EmbeddingSpace) (map[string]interface{}, error) {
+	return s.redis.FTInfo(ctx, s.indexName(space))
+}
+
+// RecreateIndex drops the space's index and creates it again with the
+// space's dimensions. Entries whose embeddings do not match them are skipped
+// by the indexer until they are re-embedded.
+func (s *RedisStore) RecreateIndex(ctx context.Context, space EmbeddingSpace) error {
+	index := s.indexName(space)
+	if err := s.redis.DropIndex(ctx, index); err != nil {
+		return err
+	}
+	return s.redis.CreateVectorIndex(ctx, index, s.indexConfig(space))
+}
+
+// IndexDimensions reads the space's index dimensions from FT.INFO.
+func (s *RedisStore) IndexDimensions(ctx context.Context, space EmbeddingSpace) (int, bool, error) {
+	info, err := s.redis.FTInfo(ctx, s.indexName(space))
+	if err != nil {
+		msg := strings.ToLower(err.Error())
+		if strings.Contains(msg, "unknown index name") || strings.Contains(msg, "no such index") {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	dim, _ := indexDimensions(info)
+	return dim, true, nil
+}
+
+// DropIndex drops the space's index.
+func (s *RedisStore) DropIndex(ctx context.Context, space EmbeddingSpace) error {
+	return s.redis.DropIndex(ctx, s.indexName(space))
+}
@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keyPrefix is the key prefix of cache entries and of the index definition.
+const keyPrefix = "cache:"
+
+// requiredModules maps the MODULE LIST names the cache needs to their product names.
+var requiredModules = []struct{ name, product string }{
+	{"search", "RediSearch"},
+	{"ReJSON", "RedisJSON"},
+}
+
+// CheckModules verifies the server has the RediSearch and RedisJSON modules.
+// Servers that do not allow MODULE LIST are assumed to have them.
+func (r *RedisClient) CheckModules(ctx context.Context) error {
+	raw, err := r.client.Do(ctx, "MODULE", "LIST").Result()
+	if err != nil {
+		msg := strings.ToLower(err.Error())
+		if strings.Contains(msg, "unknown command") || strings.Contains(msg, "noperm") || strings.Contains(msg, "no permissions") {
+			r.logger.Warn("cannot list Redis modules, skipping module check", "error", err.Error())
+			return nil
+		}
+		return fmt.Errorf("MODULE LIST failed: %w", err)
+	}
+	return missingModules(raw)
+}
+
+// missingModules checks a MODULE LIST reply for the required modules.
+func missingModules(raw interface{}) error {
+	modules, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected MODULE LIST reply type %T", raw)
+	}
+	loaded := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		if module, ok := replyToMap(m); ok {
+			loaded[strings.ToLower(fmt.Sprint(module["name"]))] = true
+		}
+	}
+
+	var missing []string
+	for _, m := range requiredModules {
+		if !loaded[strings.ToLower(m.name)] {
+			missing = append(missing, m.product)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("redis server is missing the %s module(s); use Redis Stack or Redis 8", strings.Join(missing, " and "))
+	}
+	return nil
+}
+
+// indexAttributes returns the FT.INFO attributes keyed by attribute name.
+func indexAttributes(info map[string]interface{}) map[string]map[string]interface{} {
+	attributes, _ := info["attributes"].([]interface{})
+	out := make(map[string]map[string]interface{}, len(attributes))
+	for _, raw := range attributes {
+		if attr, ok := replyToMap(raw); ok {
+			out[fmt.Sprint(attr["attribute"])] = attr
+		}
+	}
+	return out
+}
+
+// indexDimensions returns the DIM of the embedding attribute from FT.INFO.
+func indexDimensions(info map[string]interface{}) (int, bool) {
+	embedding, ok := indexAttributes(info)["embedding"]
+	if !ok {
+		return 0, false
+	}
+	return attributeInt(embedding, "dim")
+}
+
+// attributeInt returns a numeric detail of an FT.INFO attribute or reply.
+func attributeInt(attr map[string]interface{}, name string) (int, bool) {
+	switch v := attr[name].(type) {
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// schemaProblems compares FT.INFO output with the schema the cache expects
+// and describes each difference. Details missing from older servers' output
+// are not checked.
+func schemaProblems(info map[string]interface{}, cfg VectorIndexConfig) []string {
+	cfg = cfg.withDefaults()
+	var problems []string
+
+	if def, ok := replyToMap(info["index_definition"]); ok {
+		if keyType := fmt.Sprint(def["key_type"]); !strings.EqualFold(keyType, "JSON") {
+			problems = append(problems, fmt.Sprintf("indexes %s keys, not JSON", keyType))
+		}
+		if prefixes, ok := def["prefixes"].([]interface{}); ok && (len(prefixes) != 1 || fmt.Sprint(prefixes[0]) != cfg.Prefix) {
+			problems = append(problems, fmt.Sprintf("has prefixes %v, not [%s]", prefixes, cfg.Prefix))
+		}
+	}
+
+	attributes := indexAttributes(info)
+	expected := []struct{ name, kind string }{{"query_hash", "TAG"}}
+	for _, field := range filterFields {
+		expected = append(expected, struct{ name, kind string }{fmt.Sprint(field[2]), fmt.Sprint(field[3])})
+	}
+	expected = append(expected, struct{ name, kind string }{"embedding", "VECTOR"})
+	for _, field := range expected {
+		attr, ok := attributes[field.name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("has no %s field", field.name))
+			continue
+		}
+		if kind := fmt.Sprint(attr["type"]); !strings.EqualFold(kind, field.kind) {
+			problems = append(problems, fmt.Sprintf("field %s is %s, not %s", field.name, kind, field.kind))
+		}
+	}
+
+	embedding, ok := attributes["embedding"]
+	if !ok {
+		return problems
+	}
+	if identifier, ok := embedding["identifier"]; ok && fmt.Sprint(identifier) != "$."+cfg.vectorField() {
+		problems = append(problems, fmt.Sprintf("reads vectors from %v, not $.%s", identifier, cfg.vectorField()))
+	}
+	if dim, ok := indexDimensions(info); ok && dim != cfg.Dimensions {
+		problems = append(problems, fmt.Sprintf("has %d dimensions, not %d", dim, cfg.Dimensions))
+	}
+	if algorithm, ok := embedding["algorithm"]; ok && !strings.EqualFold(fmt.Sprint(algorithm), cfg.Algorithm) {
+		problems = append(problems, fmt.Sprintf("is a %v index, not %s", algorithm, cfg.Algorithm))
+	}
+	if metric, ok := embedding["distance_metric"]; ok && !strings.EqualFold(fmt.Sprint(metric), cfg.Metric) {
+		problems = append(problems, fmt.Sprintf("uses the %v distance metric, not %s", metric, cfg.Metric))
+	}
+	if dataType, ok := embedding["data_type"]; ok && !strings.EqualFold(fmt.Sprint(dataType), cfg.VectorType) {
+		problems = append(problems, fmt.Sprintf("stores %v vectors, not %s", dataType, cfg.VectorType))
+	}
+	if cfg.Algorithm == IndexHNSW {
+		// Only explicitly configured graph parameters are compared
+		for _, param := range []struct {
+			name  string
+			value int
+		}{{"M", cfg.M}, {"ef_construction", cfg.EFConstruction}} {
+			if actual, ok := attributeInt(embedding, param.name); ok && param.value > 0 && actual != param.value {
+				problems = append(problems, fmt.Sprintf("has %s %d, not %d", param.name, actual, param.value))
+			}
+		}
+	}
+	return problems
+}
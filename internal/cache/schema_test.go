@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestMissingModules verifies MODULE LIST replies are checked for RediSearch
+// and RedisJSON under RESP2 and RESP3.
+func TestMissingModules(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   interface{}
+		missing string
+	}{
+		{"redis stack RESP2", []interface{}{
+			[]interface{}{"name", "search", "ver", int64(20811)},
+			[]interface{}{"name", "ReJSON", "ver", int64(20607)},
+		}, ""},
+		{"redis stack RESP3", []interface{}{
+			map[interface{}]interface{}{"name": "search", "ver": int64(20811)},
+			map[interface{}]interface{}{"name": "ReJSON", "ver": int64(20607)},
+		}, ""},
+		{"no json", []interface{}{
+			[]interface{}{"name", "search", "ver", int64(20811)},
+		}, "RedisJSON"},
+		{"plain redis", []interface{}{}, "RediSearch and RedisJSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := missingModules(tt.reply)
+			if tt.missing == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.missing) {
+				t.Errorf("error = %v, want mention of %s", err, tt.missing)
+			}
+		})
+	}
+}
+
+// TestIndexDimensions verifies the vector DIM is read from FT.INFO attributes
+// under RESP2 and RESP3.
+func TestIndexDimensions(t *testing.T) {
+	resp2 := map[string]interface{}{"attributes": []interface{}{
+		[]interface{}{"identifier", "$.query_hash", "attribute", "query_hash", "type", "TAG"},
+		[]interface{}{"identifier", "$.embedding", "attribute", "embedding", "type", "VECTOR", "dim", int64(1536)},
+	}}
+	if dim, ok := indexDimensions(resp2); !ok || dim != 1536 {
+		t.Errorf("RESP2: got %d, %v", dim, ok)
+	}
+
+	resp3 := map[string]interface{}{"attributes": []interface{}{
+		map[interface{}]interface{}{"attribute": "embedding", "type": "VECTOR", "dim": "768"},
+	}}
+	if dim, ok := indexDimensions(resp3); !ok || dim != 768 {
+		t.Errorf("RESP3: got %d, %v", dim, ok)
+	}
+
+	if _, ok := indexDimensions(map[string]interface{}{}); ok {
+		t.Error("expected no dimensions without attributes")
+	}
+}
+
+// indexInfo builds an FT.INFO reply for an index with the expected schema,
+// changed by edit.
+func indexInfo(edit func(def map[string]interface{}, attrs map[string][]interface{})) map[string]interface{} {
+	def := map[string]interface{}{"key_type": "JSON", "prefixes": []interface{}{"cache:"}}
+	attrs := map[string][]interface{}{
+		"query_hash": {"attribute", "query_hash", "type", "TAG"},
+		"embedding":  {"attribute", "embedding", "type", "VECTOR", "algorithm", "HNSW", "data_type", "FLOAT32", "dim", int64(1536), "distance_metric", "COSINE"},
+	}
+	for _, field := range filterFields {
+		name := field[2].(string)
+		attrs[name] = []interface{}{"attribute", name, "type", field[3]}
+	}
+	if edit != nil {
+		edit(def, attrs)
+	}
+
+	var flatDef []interface{}
+	for k, v := range def {
+		flatDef = append(flatDef, k, v)
+	}
+	var attributes []interface{}
+	for _, attr := range attrs {
+		attributes = append(attributes, attr)
+	}
+	return map[string]interface{}{"index_definition": flatDef, "attributes": attributes}
+}
+
+// TestSchemaProblems verifies FT.INFO output is compared with the expected
+// prefix, fields, dimensions, metric and vector type.
+func TestSchemaProblems(t *testing.T) {
+	cfg := VectorIndexConfig{Dimensions: 1536}
+	tests := []struct {
+		name string
+		edit func(def map[string]interface{}, attrs map[string][]interface{})
+		want string
+	}{
+		{"matching", nil, ""},
+		{"hash keys", func(def map[string]interface{}, _ map[string][]interface{}) { def["key_type"] = "HASH" }, "not JSON"},
+		{"prefix", func(def map[string]interface{}, _ map[string][]interface{}) { def["prefixes"] = []interface{}{"doc:"} }, "prefixes"},
+		{"missing field", func(_ map[string]interface{}, attrs map[string][]interface{}) { delete(attrs, "tenant") }, "no tenant field"},
+		{"field type", func(_ map[string]interface{}, attrs map[string][]interface{}) {
+			attrs["model"] = []interface{}{"attribute", "model", "type", "TEXT"}
+		}, "model is TEXT"},
+		{"dimensions", func(_ map[string]interface{}, attrs map[string][]interface{}) { attrs["embedding"][9] = int64(768) }, "768 dimensions"},
+		{"metric", func(_ map[string]interface{}, attrs map[string][]interface{}) { attrs["embedding"][11] = "L2" }, "L2 distance metric"},
+		{"vector type", func(_ map[string]interface{}, attrs map[string][]interface{}) { attrs["embedding"][7] = "FLOAT16" }, "FLOAT16 vectors"},
+		{"vector path", func(_ map[string]interface{}, attrs map[string][]interface{}) {
+			attrs["embedding"] = append(attrs["embedding"], "identifier", "$.vector")
+		}, "reads vectors from $.vector, not $.embedding"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := strings.Join(schemaProblems(indexInfo(tt.edit), cfg), "; ")
+			if tt.want == "" {
+				if problems != "" {
+					t.Errorf("unexpected problems: %s", problems)
+				}
+				return
+			}
+			if !strings.Contains(problems, tt.want) {
+				t.Errorf("problems = %q, want %q", problems, tt.want)
+			}
+		})
+	}
+
+	// Details older servers do not report are not checked
+	sparse := map[string]interface{}{"attributes": []interface{}{
+		[]interface{}{"attribute", "query_hash", "type", "TAG"},
+		[]interface{}{"attribute", "embedding", "type", "VECTOR"},
+	}}
+	for _, field := range filterFields {
+		sparse["attributes"] = append(sparse["attributes"].([]interface{}), []interface{}{"attribute", field[2], "type", field[3]})
+	}
+	if problems := schemaProblems(sparse, cfg); len(problems) != 0 {
+		t.Errorf("sparse FT.INFO: unexpected problems %v", problems)
+	}
+}
+
+// TestSchemaProblems_Tuning verifies the algorithm and configured HNSW
+// parameters are compared with FT.INFO output.
+func TestSchemaProblems_Tuning(t *testing.T) {
+	hnsw := func(_ map[string]interface{}, attrs map[string][]interface{}) {
+		attrs["embedding"] = append(attrs["embedding"], "M", int64(16), "ef_construction", int64(200))
+	}
+	tests := []struct {
+		name string
+		cfg  VectorIndexConfig
+		want string
+	}{
+		{"server defaults", VectorIndexConfig{Dimensions: 1536}, ""},
+		{"matching parameters", VectorIndexConfig{Dimensions: 1536, M: 16, EFConstruction: 200}, ""},
+		{"flat", VectorIndexConfig{Dimensions: 1536, Algorithm: IndexFlat}, "HNSW index, not FLAT"},
+		{"M", VectorIndexConfig{Dimensions: 1536, M: 32}, "M 16, not 32"},
+		{"ef_construction", VectorIndexConfig{Dimensions: 1536, EFConstruction: 400}, "ef_construction 200, not 400"},
+		{"metric", VectorIndexConfig{Dimensions: 1536, Metric: MetricIP}, "COSINE distance metric, not IP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := strings.Join(schemaProblems(indexInfo(hnsw), tt.cfg), "; ")
+			if tt.want == "" {
+				if problems != "" {
+					t.Errorf("unexpected problems: %s", problems)
+				}
+				return
+			}
+			if !strings.Contains(problems, tt.want) {
+				t.Errorf("problems = %q, want %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+// TestVectorFieldArgs verifies the embedding field's FT.CREATE arguments
+// carry the algorithm, metric and only the parameters that apply.
+func TestVectorFieldArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  VectorIndexConfig
+		want string
+	}{
+		{"defaults", VectorIndexConfig{Dimensions: 4}, "$.embedding AS embedding VECTOR HNSW 6 TYPE FLOAT32 DIM 4 DISTANCE_METRIC COSINE"},
+		{"tuned HNSW", VectorIndexConfig{Dimensions: 4, Metric: MetricL2, M: 32, EFConstruction: 400, InitialCap: 1000},
+			"$.vector AS embedding VECTOR HNSW 12 TYPE FLOAT32 DIM 4 DISTANCE_METRIC L2 M 32 EF_CONSTRUCTION 400 INITIAL_CAP 1000"},
+		{"flat ignores graph parameters", VectorIndexConfig{Dimensions: 4, Algorithm: IndexFlat, VectorType: VectorFloat16, Metric: MetricIP, M: 32},
+			"$.vector AS embedding VECTOR FLAT 6 TYPE FLOAT16 DIM 4 DISTANCE_METRIC IP"},
+		{"half precision reads the embedding", VectorIndexConfig{Dimensions: 4, VectorType: VectorFloat16},
+			"$.embedding AS embedding VECTOR HNSW 6 TYPE FLOAT16 DIM 4 DISTANCE_METRIC COSINE"},
+		{"INT8 reads the scaled copy", VectorIndexConfig{Dimensions: 4, VectorType: VectorInt8},
+			"$.vector AS embedding VECTOR HNSW 6 TYPE INT8 DIM 4 DISTANCE_METRIC COSINE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			for _, arg := range tt.cfg.vectorFieldArgs() {
+				args = append(args, fmt.Sprint(arg))
+			}
+			if got := strings.Join(args, " "); got != tt.want {
+				t.Errorf("vectorFieldArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDistanceToSimilarity verifies the scores of every metric convert to
+// the cosine similarity of the unit-length vectors the index holds.
+func TestDistanceToSimilarity(t *testing.T) {
+	a := normalize([]float32{3, 1, 0.5, -2})
+	b := normalize([]float32{2, 2, -1, -1})
+	var dot, dist float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		dist += float64(a[i]-b[i]) * float64(a[i]-b[i])
+	}
+	cosine := vectorScore(MetricCosine, a, b)
+
+	for metric, distance := range map[string]float64{
+		MetricCosine: 1 - cosine,
+		MetricIP:     1 - dot,
+		MetricL2:     dist,
+	} {
+		if got := distanceToSimilarity(metric, distance); math.Abs(got-cosine) > 1e-6 {
+			t.Errorf("%s: similarity %f, want %f", metric, got, cosine)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// vectorScoreField is the alias KNN queries give the vector distance.
+const vectorScoreField = "__vector_score"
+
+// errSearchReply reports an FT.SEARCH reply that does not have the expected layout.
+var errSearchReply = errors.New("malformed FT.SEARCH reply")
+
+// SearchResult represents a single result from a vector search.
+type SearchResult struct {
+	Key string
+	// Score is the similarity derived from the __vector_score field, or zero
+	// if the field was not requested.
+	Score float64
+	// Document is the JSON document returned as "$", or nil.
+	Document []byte
+	// Fields holds every returned field under its name or RETURN alias,
+	// including __vector_score and "$".
+	Fields map[string]string
+	// Relevance is the document score returned with WITHSCORES.
+	Relevance float64
+}
+
+// searchReplyLayout describes what an FT.SEARCH reply holds for each result.
+type searchReplyLayout struct {
+	withScores bool
+	noContent  bool
+	// expectScore is set when RETURN lists __vector_score, so results
+	// without it are malformed rather than unscored.
+	expectScore bool
+	// metric converts __vector_score into a similarity.
+	metric string
+}
+
+// newSearchReplyLayout derives the reply layout from the FT.SEARCH arguments
+// following the query.
+func newSearchReplyLayout(metric string, args []interface{}) searchReplyLayout {
+	layout := searchReplyLayout{metric: metric}
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(s) {
+		case "WITHSCORES":
+			layout.withScores = true
+		case "NOCONTENT":
+			layout.noContent = true
+		case "RETURN":
+			if i+1 >= len(args) {
+				continue
+			}
+			n, _ := strconv.Atoi(fmt.Sprint(args[i+1]))
+			// RETURN 0 returns no fields, like NOCONTENT
+			if n == 0 {
+				layout.noContent = true
+			}
+			fields := args[i+2:]
+			if n < len(fields) {
+				fields = fields[:n]
+			}
+			for j, field := range fields {
+				aliased := j+1 < len(fields) && strings.EqualFold(fmt.Sprint(fields[j+1]), "AS")
+				if field == vectorScoreField && !aliased {
+					layout.expectScore = true
+				}
+			}
+		}
+	}
+	return layout
+}
+
+// decodeSearchReply decodes a RESP2 array or RESP3 map FT.SEARCH reply.
+func decodeSearchReply(raw interface{}, layout searchReplyLayout) ([]SearchResult, error) {
+	switch reply := raw.(type) {
+	case []interface{}:
+		return decodeSearchReplyRESP2(reply, layout)
+	case map[interface{}]interface{}, map[string]interface{}:
+		fields, _ := replyToMap(reply)
+		return decodeSearchReplyRESP3(fields, layout)
+	default:
+		return nil, fmt.Errorf("%w: unexpected reply type %T", errSearchReply, raw)
+	}
+}
+
+// decodeSearchReplyRESP2 decodes [total, key, [score], [fields], key, ...].
+func decodeSearchReplyRESP2(reply []interface{}, layout searchReplyLayout) ([]SearchResult, error) {
+	if len(reply) == 0 {
+		return nil, fmt.Errorf("%w: empty reply", errSearchReply)
+	}
+	total, ok := reply[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("%w: total is %T, not an integer", errSearchReply, reply[0])
+	}
+
+	stride := 1
+	if layout.withScores {
+		stride++
+	}
+	if !layout.noContent {
+		stride++
+	}
+	items := reply[1:]
+	if len(items)%stride != 0 {
+		return nil, fmt.Errorf("%w: %d elements do not form results of %d", errSearchReply, len(items), stride)
+	}
+	if int64(len(items)/stride) > total {
+		return nil, fmt.Errorf("%w: %d results exceed the total of %d", errSearchReply, len(items)/stride, total)
+	}
+
+	results := make([]SearchResult, 0, len(items)/stride)
+	for i := 0; i < len(items); i += stride {
+		n := len(results)
+		key, err := replyString(items[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: result %d key: %v", errSearchReply, n, err)
+		}
+		result := SearchResult{Key: key}
+		next := i + 1
+		if layout.withScores {
+			if result.Relevance, err = replyFloat(items[next]); err != nil {
+				return nil, fmt.Errorf("%w: result %d score: %v", errSearchReply, n, err)
+			}
+			next++
+		}
+		if !layout.noContent {
+			if err := result.setFields(items[next], layout); err != nil {
+				return nil, fmt.Errorf("%w: result %d (%s): %v", errSearchReply, n, key, err)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// decodeSearchReplyRESP3 decodes {total_results, results: [{id, score,
+// extra_attributes}, ...]}.
+func decodeSearchReplyRESP3(reply map[string]interface{}, layout searchReplyLayout) ([]SearchResult, error) {
+	total, ok := reply["total_results"].(int64)
+	if !ok {
+		return nil, fmt.Errorf("%w: total_results is %T, not an integer", errSearchReply, reply["total_results"])
+	}
+	items, ok := reply["results"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: results is %T, not an array", errSearchReply, reply["results"])
+	}
+	if int64(len(items)) > total {
+		return nil, fmt.Errorf("%w: %d results exceed the total of %d", errSearchReply, len(items), total)
+	}
+
+	results := make([]SearchResult, 0, len(items))
+	for n, item := range items {
+		fields, ok := replyToMap(item)
+		if !ok {
+			return nil, fmt.Errorf("%w: result %d is %T, not a map", errSearchReply, n, item)
+		}
+		key, err := replyString(fields["id"])
+		if err != nil {
+			return nil, fmt.Errorf("%w: result %d id: %v", errSearchReply, n, err)
+		}
+		result := SearchResult{Key: key}
+		if layout.withScores {
+			if result.Relevance, err = replyFloat(fields["score"]); err != nil {
+				return nil, fmt.Errorf("%w: result %d score: %v", errSearchReply, n, err)
+			}
+		}
+		if attributes, ok := fields["extra_attributes"]; ok && !layout.noContent {
+			if err := result.setFields(attributes, layout); err != nil {
+				return nil, fmt.Errorf("%w: result %d (%s): %v", errSearchReply, n, key, err)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// setFields stores the returned fields, a RESP2 name/value array or a RESP3
+// map, and derives Score and Document from them. A nil reply, returned for
+// documents deleted while the search ran, leaves the result without fields.
+func (r *SearchResult) setFields(raw interface{}, layout searchReplyLayout) error {
+	if raw == nil {
+		return nil
+	}
+	if list, ok := raw.([]interface{}); ok && len(list)%2 != 0 {
+		return fmt.Errorf("odd number of field elements (%d)", len(list))
+	}
+	values, ok := replyToMap(raw)
+	if !ok {
+		return fmt.Errorf("fields are %T, not an array or map", raw)
+	}
+
+	r.Fields = make(map[string]string, len(values))
+	for name, value := range values {
+		s, err := replyString(value)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", name, err)
+		}
+		r.Fields[name] = s
+	}
+	if score, ok := r.Fields[vectorScoreField]; ok {
+		distance, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", vectorScoreField, err)
+		}
+		r.Score = distanceToSimilarity(layout.metric, distance)
+	} else if layout.expectScore {
+		return fmt.Errorf("field %s is missing", vectorScoreField)
+	}
+	if doc, ok := r.Fields["$"]; ok {
+		r.Document = []byte(doc)
+	}
+	return nil
+}
+
+// replyString returns a scalar reply element as a string.
+func replyString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	default:
+		return "", fmt.Errorf("%T is not a scalar", v)
+	}
+}
+
+// replyFloat returns a RESP3 double or a numeric string as a float.
+func replyFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("%T is not a number", v)
+	}
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDecodeSearchReply verifies RESP2 and RESP3 FT.SEARCH replies decode
+// into the same results for each reply layout.
+func TestDecodeSearchReply(t *testing.T) {
+	doc := `{"id":"cache:a","user_query":"q"}`
+	tests := []struct {
+		name   string
+		args   []interface{}
+		metric string
+		reply  interface{}
+		want   []SearchResult
+	}{
+		{
+			name:  "RESP2 document and score",
+			args:  []interface{}{"RETURN", "2", "$", "__vector_score", "SORTBY", "__vector_score"},
+			reply: []interface{}{int64(1), "cache:a", []interface{}{"__vector_score", "0.25", "$", doc}},
+			want: []SearchResult{{Key: "cache:a", Score: 0.75, Document: []byte(doc),
+				Fields: map[string]string{"__vector_score": "0.25", "$": doc}}},
+		},
+		{
+			name: "RESP3 document and score",
+			args: []interface{}{"RETURN", "2", "$", "__vector_score"},
+			reply: map[interface{}]interface{}{
+				"attributes":    []interface{}{},
+				"format":        "STRING",
+				"total_results": int64(1),
+				"results": []interface{}{map[interface{}]interface{}{
+					"id":               "cache:a",
+					"extra_attributes": map[interface{}]interface{}{"__vector_score": "0.25", "$": doc},
+					"values":           []interface{}{},
+				}},
+				"warning": []interface{}{},
+			},
+			want: []SearchResult{{Key: "cache:a", Score: 0.75, Document: []byte(doc),
+				Fields: map[string]string{"__vector_score": "0.25", "$": doc}}},
+		},
+		{
+			name:   "RESP2 RETURN aliases without document, L2 metric",
+			args:   []interface{}{"RETURN", "7", "$.query_hash", "AS", "hash", "$.hit_count", "AS", "hits", "__vector_score"},
+			metric: MetricL2,
+			reply: []interface{}{int64(2),
+				"cache:a", []interface{}{"hash", "sha256:a", "hits", "3", "__vector_score", "0.5"},
+				"cache:b", []interface{}{"hash", "sha256:b", "hits", "0", "__vector_score", "1"},
+			},
+			want: []SearchResult{
+				{Key: "cache:a", Score: 0.75, Fields: map[string]string{"hash": "sha256:a", "hits": "3", "__vector_score": "0.5"}},
+				{Key: "cache:b", Score: 0.5, Fields: map[string]string{"hash": "sha256:b", "hits": "0", "__vector_score": "1"}},
+			},
+		},
+		{
+			name: "RESP3 RETURN aliases with numeric values",
+			args: []interface{}{"RETURN", "4", "$.query_hash", "AS", "hash", "$.hit_count", "AS", "hits"},
+			reply: map[interface{}]interface{}{"total_results": int64(5), "results": []interface{}{
+				map[interface{}]interface{}{"id": "cache:a", "extra_attributes": map[interface{}]interface{}{"hash": "sha256:a", "hits": int64(3)}},
+			}},
+			want: []SearchResult{{Key: "cache:a", Fields: map[string]string{"hash": "sha256:a", "hits": "3"}}},
+		},
+		{
+			name:  "RESP2 WITHSCORES",
+			args:  []interface{}{"WITHSCORES", "RETURN", "1", "$"},
+			reply: []interface{}{int64(1), "cache:a", "1.5", []interface{}{"$", doc}},
+			want:  []SearchResult{{Key: "cache:a", Relevance: 1.5, Document: []byte(doc), Fields: map[string]string{"$": doc}}},
+		},
+		{
+			name: "RESP3 WITHSCORES",
+			args: []interface{}{"withscores"},
+			reply: map[interface{}]interface{}{"total_results": int64(1), "results": []interface{}{
+				map[interface{}]interface{}{"id": "cache:a", "score": 1.5, "extra_attributes": map[interface{}]interface{}{"$": doc}},
+			}},
+			want: []SearchResult{{Key: "cache:a", Relevance: 1.5, Document: []byte(doc), Fields: map[string]string{"$": doc}}},
+		},
+		{
+			name:  "RESP2 NOCONTENT",
+			args:  []interface{}{"NOCONTENT"},
+			reply: []interface{}{int64(3), "cache:a", "cache:b"},
+			want:  []SearchResult{{Key: "cache:a"}, {Key: "cache:b"}},
+		},
+		{
+			name:  "RESP2 RETURN 0 with scores",
+			args:  []interface{}{"RETURN", "0", "WITHSCORES"},
+			reply: []interface{}{int64(1), "cache:a", "2"},
+			want:  []SearchResult{{Key: "cache:a", Relevance: 2}},
+		},
+		{
+			name:  "RESP2 document deleted during the search",
+			args:  []interface{}{"RETURN", "2", "$", "__vector_score"},
+			reply: []interface{}{int64(1), "cache:a", nil},
+			want:  []SearchResult{{Key: "cache:a"}},
+		},
+		{
+			name:  "RESP2 no results",
+			reply: []interface{}{int64(0)},
+			want:  []SearchResult{},
+		},
+		{
+			name:  "RESP3 no results",
+			reply: map[interface{}]interface{}{"total_results": int64(0), "results": []interface{}{}},
+			want:  []SearchResult{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSearchReply(tt.reply, newSearchReplyLayout(tt.metric, tt.args))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if math.Abs(got[i].Score-tt.want[i].Score) > 1e-9 {
+					t.Errorf("result %d: score %f, want %f", i, got[i].Score, tt.want[i].Score)
+				}
+				got[i].Score = tt.want[i].Score
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("result %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestDecodeSearchReply_Errors verifies malformed replies are reported
+// instead of skipped.
+func TestDecodeSearchReply_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []interface{}
+		reply interface{}
+		want  string
+	}{
+		{"reply type", nil, "OK", "unexpected reply type string"},
+		{"empty RESP2 reply", nil, []interface{}{}, "empty reply"},
+		{"RESP2 total", nil, []interface{}{"1", "cache:a", []interface{}{}}, "total is string"},
+		{"RESP2 missing fields", nil, []interface{}{int64(2), "cache:a", []interface{}{}, "cache:b"}, "do not form results"},
+		{"RESP2 key type", nil, []interface{}{int64(1), []interface{}{"cache:a"}, []interface{}{}}, "result 0 key"},
+		{"RESP2 more results than total", []interface{}{"NOCONTENT"}, []interface{}{int64(1), "cache:a", "cache:b"}, "exceed the total"},
+		{"RESP2 odd fields", nil, []interface{}{int64(1), "cache:a", []interface{}{"$"}}, "odd number"},
+		{"RESP2 unparsable score", nil, []interface{}{int64(1), "cache:a", []interface{}{"__vector_score", "close"}}, "__vector_score"},
+		{"RESP2 non-scalar field", nil, []interface{}{int64(1), "cache:a", []interface{}{"$", []interface{}{"x"}}}, "field $"},
+		{"RESP2 WITHSCORES score", []interface{}{"WITHSCORES"}, []interface{}{int64(1), "cache:a", "high", []interface{}{}}, "result 0 score"},
+		{"RESP3 total", nil, map[interface{}]interface{}{"results": []interface{}{}}, "total_results"},
+		{"RESP3 results", nil, map[interface{}]interface{}{"total_results": int64(1), "results": "cache:a"}, "results is string"},
+		{"RESP3 result type", nil, map[interface{}]interface{}{"total_results": int64(1), "results": []interface{}{"cache:a"}}, "not a map"},
+		{"RESP3 missing id", nil, map[interface{}]interface{}{"total_results": int64(1), "results": []interface{}{
+			map[interface{}]interface{}{"extra_attributes": map[interface{}]interface{}{}},
+		}}, "result 0 id"},
+		{"RESP3 missing score", []interface{}{"WITHSCORES"}, map[interface{}]interface{}{"total_results": int64(1), "results": []interface{}{
+			map[interface{}]interface{}{"id": "cache:a"},
+		}}, "result 0 score"},
+		{"RESP2 missing score", []interface{}{"RETURN", "2", "$", "__vector_score"},
+			[]interface{}{int64(1), "cache:a", []interface{}{"$", "{}"}}, "__vector_score is missing"},
+		{"RESP3 missing score field", []interface{}{"RETURN", "2", "$", "__vector_score"}, map[interface{}]interface{}{"total_results": int64(1), "results": []interface{}{
+			map[interface{}]interface{}{"id": "cache:a", "extra_attributes": map[interface{}]interface{}{"$": "{}"}},
+		}}, "__vector_score is missing"},
+		{"RESP3 attributes type", nil, map[interface{}]interface{}{"total_results": int64(1), "results": []interface{}{
+			map[interface{}]interface{}{"id": "cache:a", "extra_attributes": "x"},
+		}}, "not an array or map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeSearchReply(tt.reply, newSearchReplyLayout(MetricCosine, tt.args))
+			if !errors.Is(err, errSearchReply) {
+				t.Fatalf("error = %v, want a malformed reply error", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want mention of %q", err, tt.want)
+			}
+		})
+	}
+}
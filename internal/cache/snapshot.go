@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SnapshotVersion is the current NDJSON snapshot format version.
+const SnapshotVersion = 1
+
+// maxSnapshotLine bounds a single NDJSON line (an entry with a large response and embedding).
+const maxSnapshotLine = 64 * 1024 * 1024
+
+// EntryStore is the subset of the cache service needed to snapshot and restore entries.
+type EntryStore interface {
+	Scan(ctx context.Context, fn func(*CacheEntry) error) error
+	Store(ctx context.Context, entry *CacheEntry) error
+}
+
+// SnapshotHeader is the first line of a snapshot and describes how its
+// embeddings were produced.
+type SnapshotHeader struct {
+	Version            int    `json:"snapshot_version"`
+	EmbeddingModel     string `json:"embedding_model"`
+	Dimensions         int    `json:"dimensions"`
+	IncludesEmbeddings bool   `json:"includes_embeddings"`
+	CreatedAt          int64  `json:"created_at"`
+}
+
+// ExportOptions controls what is written to a snapshot.
+type ExportOptions struct {
+	IncludeEmbeddings bool
+	EmbeddingModel    string
+	Dimensions        int
+}
+
+// ImportOptions describes the target cache. Embed is used to regenerate
+// embeddings when the snapshot was produced by a different model or dimension,
+// or was exported without embeddings.
+type ImportOptions struct {
+	EmbeddingModel string
+	Dimensions     int
+	// EmbeddingVersion is the target's embedding version. Entries tagged
+	// with another version are re-embedded.
+	EmbeddingVersion string
+	Embed            func(ctx context.Context, text string) ([]float32, error)
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Imported   int `json:"imported"`
+	Reembedded int `json:"reembedded"`
+	Skipped    int `json:"skipped"`
+}
+
+// Export streams every cache entry to w as NDJSON, preceded by a header line.
+// It returns the number of entries written.
+func Export(ctx context.Context, src EntryStore, w io.Writer, opts ExportOptions) (int, error) {
+	enc := json.NewEncoder(w)
+	header := SnapshotHeader{
+		Version:            SnapshotVersion,
+		EmbeddingModel:     opts.EmbeddingModel,
+		Dimensions:         opts.Dimensions,
+		IncludesEmbeddings: opts.IncludeEmbeddings,
+		CreatedAt:          time.Now().Unix(),
+	}
+	if err := enc.Encode(header); err != nil {
+		return 0, fmt.Errorf("failed to write snapshot header: %w", err)
+	}
+
+	count := 0
+	err := src.Scan(ctx, func(entry *CacheEntry) error {
+		if !opts.IncludeEmbeddings {
+			entry.Embedding = nil
+		}
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write entry %s: %w", entry.ID, err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// Import reads a snapshot produced by Export and stores its entries in dst.
+func Import(ctx context.Context, dst EntryStore, r io.Reader, opts ImportOptions) (ImportResult, error) {
+	var result ImportResult
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSnapshotLine)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return result, fmt.Errorf("failed to read snapshot header: %w", err)
+		}
+		return result, errors.New("snapshot is empty")
+	}
+	var header SnapshotHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version == 0 {
+		return result, errors.New("snapshot header is missing or invalid")
+	}
+	if header.Version > SnapshotVersion {
+		return result, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+
+	sameModel := header.IncludesEmbeddings &&
+		header.EmbeddingModel == opts.EmbeddingModel &&
+		header.Dimensions == opts.Dimensions
+
+	line := 1
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry CacheEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return result, fmt.Errorf("invalid entry on line %d: %w", line, err)
+		}
+
+		otherVersion := entry.EmbeddingVersion != "" && entry.EmbeddingVersion != opts.EmbeddingVersion
+		if !sameModel || otherVersion || len(entry.Embedding) != opts.Dimensions {
+			if opts.Embed == nil {
+				result.Skipped++
+				continue
+			}
+			vec, err := opts.Embed(ctx, entry.QueryText)
+			if err != nil {
+				return result, fmt.Errorf("failed to re-embed entry %s: %w", entry.ID, err)
+			}
+			entry.Embedding = vec
+			result.Reembedded++
+		}
+
+		// Expiry is recomputed from the target's TTL
+		entry.ExpiresAt = 0
+		if err := dst.Store(ctx, &entry); err != nil {
+			return result, fmt.Errorf("failed to store entry %s: %w", entry.ID, err)
+		}
+		result.Imported++
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// memoryEntryStore is an in-memory EntryStore for snapshot tests.
+type memoryEntryStore struct {
+	entries []*CacheEntry
+}
+
+func (m *memoryEntryStore) Scan(ctx context.Context, fn func(*CacheEntry) error) error {
+	for _, e := range m.entries {
+		copied := *e
+		if err := fn(&copied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryEntryStore) Store(ctx context.Context, entry *CacheEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func snapshotSource() *memoryEntryStore {
+	return &memoryEntryStore{entries: []*CacheEntry{
+		{ID: "cache:a", QueryHash: "sha256:a", QueryText: "capital of France", Embedding: []float32{1, 0, 0}, LLMResponse: `{"id":"a"}`, CreatedAt: 100, HitCount: 4},
+		{ID: "cache:b", QueryHash: "sha256:b", QueryText: "capital of Spain", Embedding: []float32{0, 1, 0}, LLMResponse: `{"id":"b"}`, CreatedAt: 200},
+	}}
+}
+
+// TestSnapshot_RoundTrip verifies entries survive export and import unchanged
+// when the target uses the same embedding model.
+func TestSnapshot_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	count, err := Export(context.Background(), snapshotSource(), &buf, ExportOptions{
+		IncludeEmbeddings: true, EmbeddingModel: "model-a", Dimensions: 3,
+	})
+	if err != nil || count != 2 {
+		t.Fatalf("export failed: count=%d err=%v", count, err)
+	}
+
+	dst := &memoryEntryStore{}
+	embedCalled := false
+	result, err := Import(context.Background(), dst, &buf, ImportOptions{
+		EmbeddingModel: "model-a",
+		Dimensions:     3,
+		Embed: func(ctx context.Context, text string) ([]float32, error) {
+			embedCalled = true
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.Imported != 2 || result.Reembedded != 0 || embedCalled {
+		t.Errorf("unexpected import result %+v (embed called: %v)", result, embedCalled)
+	}
+	if dst.entries[0].QueryText != "capital of France" || dst.entries[0].HitCount != 4 || len(dst.entries[0].Embedding) != 3 {
+		t.Errorf("entry not restored: %+v", dst.entries[0])
+	}
+}
+
+// TestSnapshot_ReembedsOnModelChange verifies entries are re-embedded when the
+// target model differs or the snapshot has no embeddings.
+func TestSnapshot_ReembedsOnModelChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		exportOpts ExportOptions
+	}{
+		{"different model", ExportOptions{IncludeEmbeddings: true, EmbeddingModel: "model-a", Dimensions: 3}},
+		{"without embeddings", ExportOptions{IncludeEmbeddings: false, EmbeddingModel: "model-b", Dimensions: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := Export(context.Background(), snapshotSource(), &buf, tt.exportOpts); err != nil {
+				t.Fatalf("export failed: %v", err)
+			}
+
+			dst := &memoryEntryStore{}
+			result, err := Import(context.Background(), dst, &buf, ImportOptions{
+				EmbeddingModel: "model-b",
+				Dimensions:     4,
+				Embed: func(ctx context.Context, text string) ([]float32, error) {
+					return []float32{0.1, 0.2, 0.3, 0.4}, nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("import failed: %v", err)
+			}
+			if result.Reembedded != 2 {
+				t.Errorf("expected 2 re-embedded entries, got %+v", result)
+			}
+			for _, e := range dst.entries {
+				if len(e.Embedding) != 4 {
+					t.Errorf("entry %s has %d dimensions, want 4", e.ID, len(e.Embedding))
+				}
+			}
+		})
+	}
+}
+
+// TestSnapshot_ReembedsOtherVersion verifies entries tagged with another
+// embedding version are re-embedded even when the model name matches.
+func TestSnapshot_ReembedsOtherVersion(t *testing.T) {
+	src := snapshotSource()
+	src.entries[0].EmbeddingModel, src.entries[0].EmbeddingVersion = "model-a", "1"
+	var buf bytes.Buffer
+	if _, err := Export(context.Background(), src, &buf, ExportOptions{IncludeEmbeddings: true, EmbeddingModel: "model-a", Dimensions: 3}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	result, err := Import(context.Background(), &memoryEntryStore{}, &buf, ImportOptions{
+		EmbeddingModel:   "model-a",
+		Dimensions:       3,
+		EmbeddingVersion: "2",
+		Embed: func(ctx context.Context, text string) ([]float32, error) {
+			return []float32{0, 0, 1}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.Imported != 2 || result.Reembedded != 1 {
+		t.Errorf("expected 1 of 2 entries re-embedded, got %+v", result)
+	}
+}
+
+// TestSnapshot_InvalidHeader verifies imports reject input without a snapshot header.
+func TestSnapshot_InvalidHeader(t *testing.T) {
+	_, err := Import(context.Background(), &memoryEntryStore{}, strings.NewReader(`{"id":"cache:a"}`+"\n"), ImportOptions{})
+	if err == nil {
+		t.Error("expected error for missing snapshot header")
+	}
+}
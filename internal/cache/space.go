@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EmbeddingSpace identifies the model that produced the cache's embeddings.
+// Vectors from different spaces cannot be compared, so each versioned space
+// has its own index and its entries are tagged with its model and version.
+//
+// A space without a Version is unversioned: it uses the base index name and
+// holds the entries written before embedding spaces were recorded.
+type EmbeddingSpace struct {
+	Model string
+	// Version distinguishes incompatible embeddings from the same model name,
+	// such as a change of dimensions.
+	Version    string
+	Dimensions int
+}
+
+// Versioned reports whether entries in the space are tagged.
+func (s EmbeddingSpace) Versioned() bool {
+	return s.Version != ""
+}
+
+// IndexName returns the name of the space's index, e.g.
+// "cache_idx:text-embedding-3-small:v2".
+func (s EmbeddingSpace) IndexName(base string) string {
+	if !s.Versioned() {
+		return base
+	}
+	return base + ":" + indexNamePart(s.Model) + ":" + indexNamePart(s.Version)
+}
+
+func (s EmbeddingSpace) String() string {
+	if !s.Versioned() {
+		return fmt.Sprintf("%s (unversioned, %d dims)", s.Model, s.Dimensions)
+	}
+	return fmt.Sprintf("%s@%s (%d dims)", s.Model, s.Version, s.Dimensions)
+}
+
+// sameAs reports whether s and other share an index and entry tags.
+func (s EmbeddingSpace) sameAs(other EmbeddingSpace) bool {
+	return s.IndexName("") == other.IndexName("")
+}
+
+// owns reports whether entry's embedding belongs to the space.
+func (s EmbeddingSpace) owns(entry *CacheEntry) bool {
+	if !s.Versioned() {
+		return entry.EmbeddingModel == "" && entry.EmbeddingVersion == ""
+	}
+	return entry.EmbeddingModel == s.Model && entry.EmbeddingVersion == s.Version
+}
+
+// scope restricts a search filter to the space's entries.
+func (s EmbeddingSpace) scope(filter SearchFilter) SearchFilter {
+	if s.Versioned() {
+		filter.EmbeddingModel, filter.EmbeddingVersion = s.Model, s.Version
+	}
+	return filter
+}
+
+// indexNamePart lowercases s and replaces characters other than letters,
+// digits, '.', '_' and '-' so it can be embedded in an index name.
+func indexNamePart(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, s)
+}
@@ -0,0 +1,45 @@
+package cache
+
+import "testing"
+
+// TestEmbeddingSpaceIndexName verifies versioned spaces get their own index.
+func TestEmbeddingSpaceIndexName(t *testing.T) {
+	tests := []struct {
+		space EmbeddingSpace
+		want  string
+	}{
+		{EmbeddingSpace{Model: "text-embedding-ada-002"}, "cache_idx"},
+		{EmbeddingSpace{Model: "text-embedding-3-small", Version: "v2"}, "cache_idx:text-embedding-3-small:v2"},
+		{EmbeddingSpace{Model: "BAAI/bge-m3", Version: "1 024"}, "cache_idx:baai-bge-m3:1-024"},
+	}
+	for _, tt := range tests {
+		if got := tt.space.IndexName("cache_idx"); got != tt.want {
+			t.Errorf("IndexName(%+v) = %q, want %q", tt.space, got, tt.want)
+		}
+	}
+}
+
+// TestEmbeddingSpaceOwns verifies entries are attributed to the space that
+// embedded them, with untagged entries in the unversioned space.
+func TestEmbeddingSpaceOwns(t *testing.T) {
+	legacy := EmbeddingSpace{Model: "ada"}
+	current := EmbeddingSpace{Model: "small", Version: "1"}
+
+	untagged := &CacheEntry{}
+	tagged := &CacheEntry{EmbeddingModel: "small", EmbeddingVersion: "1"}
+	otherVersion := &CacheEntry{EmbeddingModel: "small", EmbeddingVersion: "2"}
+
+	if !legacy.owns(untagged) || legacy.owns(tagged) {
+		t.Error("unversioned space should own only untagged entries")
+	}
+	if !current.owns(tagged) || current.owns(untagged) || current.owns(otherVersion) {
+		t.Error("versioned space should own only entries with its model and version")
+	}
+
+	if f := legacy.scope(SearchFilter{Tenant: "acme"}); f.EmbeddingModel != "" || f.Tenant != "acme" {
+		t.Errorf("unversioned scope = %+v", f)
+	}
+	if f := current.scope(SearchFilter{}); f.EmbeddingModel != "small" || f.EmbeddingVersion != "1" {
+		t.Errorf("versioned scope = %+v", f)
+	}
+}
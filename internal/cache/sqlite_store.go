@@ -0,0 +1,427 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"semantic-cache-gateway/internal/embedding"
+	"semantic-cache-gateway/internal/logger"
+)
+
+// DefaultSQLiteSweepInterval is how often a SQLiteStore removes expired entries.
+const DefaultSQLiteSweepInterval = time.Minute
+
+// sqliteSchema creates the entries table. The entry's JSON holds every field
+// but its embedding, which is kept as little-endian float32s. The columns
+// that filters, hits, feedback and retagging touch are kept apart from the
+// JSON and take precedence over it.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+	id                TEXT PRIMARY KEY,
+	entry             BLOB NOT NULL,
+	embedding         BLOB NOT NULL,
+	dims              INTEGER NOT NULL,
+	template_key      TEXT NOT NULL DEFAULT '',
+	model             TEXT NOT NULL DEFAULT '',
+	tenant            TEXT NOT NULL DEFAULT '',
+	system_hash       TEXT NOT NULL DEFAULT '',
+	language          TEXT NOT NULL DEFAULT '',
+	embedding_model   TEXT NOT NULL DEFAULT '',
+	embedding_version TEXT NOT NULL DEFAULT '',
+	created_at        INTEGER NOT NULL DEFAULT 0,
+	hit_count         INTEGER NOT NULL DEFAULT 0,
+	last_accessed_at  INTEGER NOT NULL DEFAULT 0,
+	expires_at        INTEGER NOT NULL DEFAULT 0,
+	feedback_up       INTEGER NOT NULL DEFAULT 0,
+	feedback_down     INTEGER NOT NULL DEFAULT 0,
+	deadline          INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS entries_deadline ON entries (deadline) WHERE deadline > 0;
+`
+
+// sqliteLive selects the entries whose deadline, in Unix nanoseconds, has
+// not passed. Its argument is the current time.
+const sqliteLive = "(deadline = 0 OR deadline > ?)"
+
+// sqliteColumns are the columns scanned by scanSQLiteEntry.
+const sqliteColumns = "entry, embedding, embedding_model, embedding_version, hit_count, last_accessed_at, expires_at, feedback_up, feedback_down"
+
+// SQLiteStoreConfig configures a SQLiteStore.
+type SQLiteStoreConfig struct {
+	// Path of the database file; it is created if it does not exist.
+	Path string
+	// SweepInterval is how often expired entries are removed from the file.
+	SweepInterval time.Duration
+}
+
+// SQLiteStore persists entries in an embedded SQLite database file. Filters
+// are applied by SQL and the remaining embeddings are compared by brute
+// force, so searches read every embedding of the partition. It needs no
+// external service, and the file may be opened by several processes on one
+// host, such as the gateway and its CLI commands.
+//
+// Expired entries are skipped by every read and removed every SweepInterval.
+type SQLiteStore struct {
+	path   string
+	db     *sql.DB
+	logger *logger.Logger
+	now    func() time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSQLiteStore opens the database file, creates its table and starts sweeping.
+func NewSQLiteStore(cfg SQLiteStoreConfig, log *logger.Logger) (*SQLiteStore, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("sqlite store path is empty")
+	}
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = DefaultSQLiteSweepInterval
+	}
+
+	db, err := sql.Open("sqlite", cfg.Path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database %s: %w", cfg.Path, err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open cache database %s: %w", cfg.Path, err)
+	}
+	s := &SQLiteStore{
+		path:   cfg.Path,
+		db:     db,
+		logger: log,
+		now:    time.Now,
+		done:   make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(cfg.SweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				if _, err := s.sweep(); err != nil {
+					s.logger.Error("failed to sweep cache database", "path", s.path, "error", err.Error())
+				}
+			}
+		}
+	}()
+	return s, nil
+}
+
+// sweep removes expired entries and returns how many there were.
+func (s *SQLiteStore) sweep() (int64, error) {
+	res, err := s.db.Exec("DELETE FROM entries WHERE deadline > 0 AND deadline <= ?", s.now().UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	removed, err := res.RowsAffected()
+	if removed > 0 {
+		s.logger.Debug("expired cache entries swept", "path", s.path, "removed", removed)
+	}
+	return removed, err
+}
+
+// decodeVector unpacks the little-endian float32s of float32SliceToBytes.
+func decodeVector(data []byte) []float32 {
+	vec := make([]float32, len(data)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vec
+}
+
+// scanSQLiteEntry decodes a row of sqliteColumns.
+func scanSQLiteEntry(row interface{ Scan(...interface{}) error }) (*CacheEntry, error) {
+	var data, vec []byte
+	var entry CacheEntry
+	var embeddingModel, embeddingVersion string
+	var hitCount, lastAccessedAt, expiresAt, feedbackUp, feedbackDown int64
+	if err := row.Scan(&data, &vec, &embeddingModel, &embeddingVersion, &hitCount, &lastAccessedAt, &expiresAt, &feedbackUp, &feedbackDown); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	entry.Embedding = decodeVector(vec)
+	entry.EmbeddingModel, entry.EmbeddingVersion = embeddingModel, embeddingVersion
+	entry.HitCount, entry.LastAccessedAt, entry.ExpiresAt = hitCount, lastAccessedAt, expiresAt
+	entry.FeedbackUp, entry.FeedbackDown = feedbackUp, feedbackDown
+	return &entry, nil
+}
+
+// Setup does nothing; the table holds entries of every space.
+func (s *SQLiteStore) Setup(ctx context.Context, space EmbeddingSpace) error {
+	return nil
+}
+
+// Get returns the entry stored under id, or nil.
+func (s *SQLiteStore) Get(ctx context.Context, id string) (*CacheEntry, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+sqliteColumns+" FROM entries WHERE id = ? AND "+sqliteLive, id, s.now().UnixNano())
+	entry, err := scanSQLiteEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// Upsert writes the entry, replacing any previous one.
+func (s *SQLiteStore) Upsert(ctx context.Context, entry *CacheEntry, ttl time.Duration) error {
+	var deadline int64
+	if ttl > 0 {
+		deadline = s.now().Add(ttl).UnixNano()
+	}
+	stored := *entry
+	stored.Embedding = nil
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT OR REPLACE INTO entries (
+		id, entry, embedding, dims, template_key, model, tenant, system_hash, language,
+		embedding_model, embedding_version, created_at, hit_count, last_accessed_at,
+		expires_at, feedback_up, feedback_down, deadline
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, data, float32SliceToBytes(entry.Embedding), len(entry.Embedding),
+		entry.TemplateKey, entry.Model, entry.Tenant, entry.SystemHash, entry.Language,
+		entry.EmbeddingModel, entry.EmbeddingVersion, entry.CreatedAt, entry.HitCount,
+		entry.LastAccessedAt, entry.ExpiresAt, entry.FeedbackUp, entry.FeedbackDown, deadline)
+	return err
+}
+
+// Search compares query with the embeddings of every entry matching the
+// filter and loads the k most similar. Entries with embeddings of other
+// dimensions are skipped, as an index would.
+func (s *SQLiteStore) Search(ctx context.Context, space EmbeddingSpace, query []float32, k int, filter SearchFilter) ([]Neighbor, error) {
+	where, args := filter.sqlWhere()
+	args = append([]interface{}{len(query), s.now().UnixNano()}, args...)
+	rows, err := s.db.QueryContext(ctx, "SELECT id, embedding FROM entries WHERE dims = ? AND "+sqliteLive+" AND "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	type candidate struct {
+		id         string
+		similarity float64
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var id string
+		var vec []byte
+		if err := rows.Scan(&id, &vec); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, candidate{id, embedding.CosineSimilarity(query, decodeVector(vec))})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	neighbors := make([]Neighbor, 0, len(candidates))
+	for _, c := range candidates {
+		entry, err := s.Get(ctx, c.id)
+		if err != nil {
+			return nil, err
+		}
+		// Deleted since the embeddings were read
+		if entry == nil {
+			continue
+		}
+		neighbors = append(neighbors, Neighbor{Entry: entry, Similarity: c.similarity})
+	}
+	return neighbors, nil
+}
+
+// Delete removes the entries and returns how many existed.
+func (s *SQLiteStore) Delete(ctx context.Context, ids ...string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var deleted int64
+	now := s.now().UnixNano()
+	for _, id := range ids {
+		var live int64
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM entries WHERE id = ? AND "+sqliteLive, id, now).Scan(&live); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM entries WHERE id = ?", id); err != nil {
+			return 0, err
+		}
+		deleted += live
+	}
+	return deleted, tx.Commit()
+}
+
+// ScanIDs calls fn with the IDs matching pattern. The IDs are collected
+// first, so fn may modify the store.
+func (s *SQLiteStore) ScanIDs(ctx context.Context, pattern string, fn func(ids []string) error) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM entries WHERE "+sqliteLive+" ORDER BY id", s.now().UnixNano())
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		if matched, _ := path.Match(pattern, id); matched {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(ids); start += scanBatchSize {
+		end := start + scanBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := fn(ids[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scan calls fn with every entry.
+func (s *SQLiteStore) Scan(ctx context.Context, fn func(*CacheEntry) error) error {
+	return s.ScanIDs(ctx, "*", func(ids []string) error {
+		for _, id := range ids {
+			entry, err := s.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			if entry == nil {
+				continue
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Stats returns the entries' access metadata. Sizes are those of the
+// entry's JSON and embedding in the file.
+func (s *SQLiteStore) Stats(ctx context.Context, ids []string, withBytes bool) ([]EntryStats, error) {
+	stats := make([]EntryStats, 0, len(ids))
+	if len(ids) == 0 {
+		return stats, nil
+	}
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, s.now().UnixNano())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	rows, err := s.db.QueryContext(ctx, `SELECT id, hit_count, CASE WHEN last_accessed_at = 0 THEN created_at ELSE last_accessed_at END,
+		length(entry) + length(embedding) FROM entries WHERE `+sqliteLive+` AND id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var stat EntryStats
+		if err := rows.Scan(&stat.ID, &stat.HitCount, &stat.LastAccessedAt, &stat.Bytes); err != nil {
+			return nil, err
+		}
+		if !withBytes {
+			stat.Bytes = 0
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// Touch updates the entry's access metadata.
+func (s *SQLiteStore) Touch(ctx context.Context, id string, accessedAt, expiresAt int64, ttl time.Duration) error {
+	now := s.now()
+	var deadline int64
+	if ttl > 0 {
+		deadline = now.Add(ttl).UnixNano()
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE entries SET hit_count = hit_count + 1, last_accessed_at = ?,
+		expires_at = CASE WHEN ? > 0 THEN ? ELSE expires_at END,
+		deadline = CASE WHEN ? > 0 THEN ? ELSE deadline END
+		WHERE id = ? AND `+sqliteLive,
+		accessedAt, expiresAt, expiresAt, deadline, deadline, id, now.UnixNano())
+	return err
+}
+
+// AddFeedback increments a feedback counter of the entry.
+func (s *SQLiteStore) AddFeedback(ctx context.Context, id string, positive bool) (FeedbackCounts, error) {
+	column := "feedback_down"
+	if positive {
+		column = "feedback_up"
+	}
+	var counts FeedbackCounts
+	err := s.db.QueryRowContext(ctx, "UPDATE entries SET "+column+" = "+column+" + 1 WHERE id = ? AND "+sqliteLive+
+		" RETURNING feedback_up, feedback_down", id, s.now().UnixNano()).Scan(&counts.Up, &counts.Down)
+	if errors.Is(err, sql.ErrNoRows) {
+		return FeedbackCounts{}, ErrEntryNotFound
+	}
+	return counts, err
+}
+
+// Retag moves the entry into the space.
+func (s *SQLiteStore) Retag(ctx context.Context, id string, space EmbeddingSpace, vec []float32) error {
+	var model, version string
+	if space.Versioned() {
+		model, version = space.Model, space.Version
+	}
+	now := s.now().UnixNano()
+	if vec == nil {
+		_, err := s.db.ExecContext(ctx, "UPDATE entries SET embedding_model = ?, embedding_version = ? WHERE id = ? AND "+sqliteLive,
+			model, version, id, now)
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE entries SET embedding_model = ?, embedding_version = ?, embedding = ?, dims = ? WHERE id = ? AND "+sqliteLive,
+		model, version, float32SliceToBytes(vec), len(vec), id, now)
+	return err
+}
+
+// Close stops sweeping and closes the file. Entries stay on disk.
+func (s *SQLiteStore) Close() error {
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.wg.Wait()
+	return s.db.Close()
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"semantic-cache-gateway/internal/logger"
+)
+
+// openSQLiteStore opens a SQLiteStore on path and closes it when the test
+// ends, unless the test closed it already.
+func openSQLiteStore(t *testing.T, path string) *SQLiteStore {
+	t.Helper()
+	store, err := NewSQLiteStore(SQLiteStoreConfig{Path: path, SweepInterval: time.Hour}, logger.New())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// TestSQLiteStore_Reopen verifies entries and their updates survive a
+// restart and are searchable again.
+func TestSQLiteStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.sqlite")
+	store := openSQLiteStore(t, path)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:x", Embedding: []float32{1, 0}, LLMResponse: "x", Tenant: "acme"}, time.Hour)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:y", Embedding: []float32{0, 1}, LLMResponse: "y"}, 0)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:deleted", Embedding: []float32{1, 1}}, 0)
+	store.Touch(ctx, "cache:x", 1234, 0, 0)
+	if _, err := store.AddFeedback(ctx, "cache:y", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AddFeedback(ctx, "cache:missing", true); err != ErrEntryNotFound {
+		t.Errorf("AddFeedback(missing) error = %v, want ErrEntryNotFound", err)
+	}
+	store.Retag(ctx, "cache:y", EmbeddingSpace{Model: "m", Version: "2"}, []float32{0, 2})
+	if n, _ := store.Delete(ctx, "cache:deleted", "cache:missing"); n != 1 {
+		t.Errorf("Delete() = %d, want 1", n)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store = openSQLiteStore(t, path)
+	x, _ := store.Get(ctx, "cache:x")
+	if x == nil || x.HitCount != 1 || x.LastAccessedAt != 1234 || x.LLMResponse != "x" || x.Tenant != "acme" {
+		t.Fatalf("touched entry not restored: %+v", x)
+	}
+	y, _ := store.Get(ctx, "cache:y")
+	if y == nil || y.FeedbackDown != 1 || y.EmbeddingVersion != "2" || y.Embedding[1] != 2 {
+		t.Fatalf("rated and retagged entry not restored: %+v", y)
+	}
+	if entry, _ := store.Get(ctx, "cache:deleted"); entry != nil {
+		t.Error("deleted entry came back")
+	}
+
+	stats, err := store.Stats(ctx, []string{"cache:x", "cache:y", "cache:deleted"}, true)
+	if err != nil || len(stats) != 2 {
+		t.Fatalf("Stats() = %+v, %v", stats, err)
+	}
+	for _, stat := range stats {
+		if stat.Bytes == 0 {
+			t.Errorf("Stats() measured no bytes for %s", stat.ID)
+		}
+	}
+}
+
+// TestSQLiteStore_Search verifies searches rank entries, apply filters in
+// SQL and skip embeddings of other dimensions.
+func TestSQLiteStore_Search(t *testing.T) {
+	ctx := context.Background()
+	store := openSQLiteStore(t, filepath.Join(t.TempDir(), "cache.sqlite"))
+	for _, entry := range []*CacheEntry{
+		{ID: "cache:x", Embedding: []float32{1, 0}, Tenant: "acme", CreatedAt: 100},
+		{ID: "cache:xy", Embedding: []float32{1, 1}, Tenant: "acme", CreatedAt: 200},
+		{ID: "cache:y", Embedding: []float32{0, 1}, Tenant: "other", CreatedAt: 200},
+		{ID: "cache:3d", Embedding: []float32{1, 0, 0}, Tenant: "acme", CreatedAt: 200},
+	} {
+		if err := store.Upsert(ctx, entry, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		query  []float32
+		k      int
+		filter SearchFilter
+		want   []string
+	}{
+		{"nearest first", []float32{1, 0}, 2, SearchFilter{}, []string{"cache:x", "cache:xy"}},
+		{"tenant", []float32{0, 1}, 5, SearchFilter{Tenant: "acme"}, []string{"cache:xy", "cache:x"}},
+		{"tenant and age", []float32{0, 1}, 5, SearchFilter{Tenant: "acme", MinCreatedAt: 150}, []string{"cache:xy"}},
+		{"other dimensions", []float32{1, 0, 0}, 5, SearchFilter{}, []string{"cache:3d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			neighbors, err := store.Search(ctx, EmbeddingSpace{}, tt.query, tt.k, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, n := range neighbors {
+				got = append(got, n.Entry.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Search() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestSQLiteStore_Expiry verifies expired entries are hidden from reads,
+// that a touch can extend them, and that sweeps remove them from the file.
+func TestSQLiteStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := openSQLiteStore(t, filepath.Join(t.TempDir(), "cache.sqlite"))
+	store.now = func() time.Time { return now }
+	store.Upsert(ctx, &CacheEntry{ID: "cache:short", Embedding: []float32{1, 0}}, time.Minute)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:touched", Embedding: []float32{1, 0}}, time.Minute)
+	store.Upsert(ctx, &CacheEntry{ID: "cache:forever", Embedding: []float32{1, 0}}, 0)
+
+	now = now.Add(30 * time.Second)
+	store.Touch(ctx, "cache:touched", now.Unix(), 0, time.Minute)
+	now = now.Add(45 * time.Second)
+
+	if entry, _ := store.Get(ctx, "cache:short"); entry != nil {
+		t.Error("expected the entry to expire")
+	}
+	if neighbors, _ := store.Search(ctx, EmbeddingSpace{}, []float32{1, 0}, 5, SearchFilter{}); len(neighbors) != 2 {
+		t.Errorf("Search() found %d entries, want the touched and permanent ones", len(neighbors))
+	}
+	var ids []string
+	store.ScanIDs(ctx, "cache:*", func(batch []string) error {
+		ids = append(ids, batch...)
+		return nil
+	})
+	if len(ids) != 2 {
+		t.Errorf("ScanIDs() = %v, want the touched and permanent entries", ids)
+	}
+
+	removed, err := store.sweep()
+	if err != nil || removed != 1 {
+		t.Errorf("sweep() = %d, %v, want the short entry removed", removed, err)
+	}
+}
+
+// TestCacheService_SQLiteStore verifies the cache service stores, finds
+// and deletes entries through a SQLite file.
+func TestCacheService_SQLiteStore(t *testing.T) {
+	ctx := context.Background()
+	store := openSQLiteStore(t, filepath.Join(t.TempDir(), "cache.sqlite"))
+	svc, err := NewCacheService(store, logger.New(), DefaultCacheServiceConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Close()
+	if err := svc.Store(ctx, testEntry("aa", 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := svc.CheckExactMatch(ctx, "sha256:aa"); err != nil || entry == nil || entry.LLMResponse != `{"answer":"aa"}` {
+		t.Fatalf("CheckExactMatch() = %+v, %v", entry, err)
+	}
+	if hit, _, err := svc.SearchSimilar(ctx, []float32{1, 0.1}, 0.9, SearchFilter{}); err != nil || hit == nil {
+		t.Fatalf("SearchSimilar() = %v, %v", hit, err)
+	}
+	if n, err := svc.DeleteMatching(ctx, "a*"); err != nil || n != 1 {
+		t.Errorf("DeleteMatching() = %d, %v", n, err)
+	}
+}
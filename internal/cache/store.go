@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errNotIndexed is returned for index operations on stores without a search index.
+var errNotIndexed = errors.New("cache store does not keep a search index")
+
+// VectorStore persists cache entries and finds the nearest neighbors of an
+// embedding among them. Entries are keyed by their ID, a cache key such as
+// "cache:<hash>". Stores keep responses as the cache service encoded them.
+type VectorStore interface {
+	// Setup prepares the store to hold and search entries of the embedding
+	// space, for example by creating its index.
+	Setup(ctx context.Context, space EmbeddingSpace) error
+	// Get returns the entry stored under id, or nil if there is none.
+	Get(ctx context.Context, id string) (*CacheEntry, error)
+	// Upsert stores entry under entry.ID, replacing any previous entry. A
+	// positive ttl removes the entry after that long.
+	Upsert(ctx context.Context, entry *CacheEntry, ttl time.Duration) error
+	// Search returns the k entries of the space matching the filter that are
+	// most similar to embedding, best first.
+	Search(ctx context.Context, space EmbeddingSpace, embedding []float32, k int, filter SearchFilter) ([]Neighbor, error)
+	// Delete removes the entries and returns how many existed.
+	Delete(ctx context.Context, ids ...string) (int64, error)
+	// ScanIDs calls fn with batches of the IDs matching the glob pattern.
+	ScanIDs(ctx context.Context, pattern string, fn func(ids []string) error) error
+	// Scan calls fn for every entry. Entries removed during the scan are skipped.
+	Scan(ctx context.Context, fn func(*CacheEntry) error) error
+	// Stats returns eviction metadata for the entries that still exist.
+	// Sizes are only measured when withBytes is set.
+	Stats(ctx context.Context, ids []string, withBytes bool) ([]EntryStats, error)
+	// Touch records a hit at accessedAt. A positive expiresAt replaces the
+	// entry's expiry, and ttl its remaining lifetime in the store.
+	Touch(ctx context.Context, id string, accessedAt, expiresAt int64, ttl time.Duration) error
+	// AddFeedback adds a rating to an entry and returns its updated counts,
+	// or ErrEntryNotFound.
+	AddFeedback(ctx context.Context, id string, positive bool) (FeedbackCounts, error)
+	// Retag moves an existing entry into the space, replacing its embedding
+	// when one is given. Entries deleted in the meantime are not recreated.
+	Retag(ctx context.Context, id string, space EmbeddingSpace, embedding []float32) error
+	Close() error
+}
+
+// IndexedStore is a VectorStore that keeps a search index per embedding
+// space. The cache service checks and rebuilds the index through it, and
+// drops a previous space's index once its entries have been migrated.
+type IndexedStore interface {
+	VectorStore
+	// CheckIndex verifies the space's index matches the expected schema.
+	CheckIndex(ctx context.Context, space EmbeddingSpace) error
+	// IndexInfo describes the space's index.
+	IndexInfo(ctx context.Context, space EmbeddingSpace) (map[string]interface{}, error)
+	// RecreateIndex drops the space's index and creates it again. Entries are kept.
+	RecreateIndex(ctx context.Context, space EmbeddingSpace) error
+	// IndexDimensions returns the vector dimensions of the space's index,
+	// zero if they are not reported, and whether the index exists.
+	IndexDimensions(ctx context.Context, space EmbeddingSpace) (int, bool, error)
+	// DropIndex removes the space's index but keeps its entries.
+	DropIndex(ctx context.Context, space EmbeddingSpace) error
+}
+
+// EntryStats is the metadata that drives eviction.
+type EntryStats struct {
+	ID             string
+	HitCount       int64
+	LastAccessedAt int64
+	Bytes          int64
+}
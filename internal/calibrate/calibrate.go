@@ -0,0 +1,287 @@
+// Package calibrate picks a similarity threshold from labeled prompt pairs.
+//
+// Each pair says whether two prompts should share a cached answer. The pairs
+// are embedded, their cosine similarity computed, and precision/recall/F1
+// measured across candidate thresholds using the same rule as the cache: a
+// pair matches when its similarity is strictly above the threshold.
+package calibrate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"semantic-cache-gateway/internal/embedding"
+)
+
+// Pair is a labeled pair of prompts.
+type Pair struct {
+	A           string `json:"prompt_a"`
+	B           string `json:"prompt_b"`
+	ShouldMatch bool   `json:"should_match"`
+}
+
+// ScoredPair is a pair with the cosine similarity of its embeddings.
+type ScoredPair struct {
+	Pair
+	Similarity float64
+}
+
+// Metrics is the confusion matrix and derived scores at one threshold.
+type Metrics struct {
+	Threshold float64 `json:"threshold"`
+	TP        int     `json:"true_positives"`
+	FP        int     `json:"false_positives"`
+	FN        int     `json:"false_negatives"`
+	TN        int     `json:"true_negatives"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// EmbedFunc produces an embedding for text.
+type EmbedFunc func(ctx context.Context, text string) ([]float32, error)
+
+// ReadPairs parses pairs as JSONL (objects with prompt_a, prompt_b and
+// should_match) or CSV (prompt_a,prompt_b,should_match with an optional
+// header row). The format is detected from the first non-blank character.
+func ReadPairs(r io.Reader) ([]Pair, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("no pairs found")
+			}
+			return nil, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			if b[0] == '{' {
+				return readJSONL(br)
+			}
+			return readCSV(br)
+		}
+		br.ReadByte()
+	}
+}
+
+func readJSONL(r io.Reader) ([]Pair, error) {
+	var pairs []Pair
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var p Pair
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := validatePair(p); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		pairs = append(pairs, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, errors.New("no pairs found")
+	}
+	return pairs, nil
+}
+
+func readCSV(r io.Reader) ([]Pair, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []Pair
+	for i, record := range records {
+		label, err := parseLabel(record[2])
+		if err != nil {
+			if i == 0 {
+				continue // header row
+			}
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		p := Pair{A: record[0], B: record[1], ShouldMatch: label}
+		if err := validatePair(p); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		pairs = append(pairs, p)
+	}
+	if len(pairs) == 0 {
+		return nil, errors.New("no pairs found")
+	}
+	return pairs, nil
+}
+
+// parseLabel accepts true/false, 1/0 and yes/no.
+func parseLabel(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return false, fmt.Errorf("should_match must be true or false, got %q", s)
+	}
+	return v, nil
+}
+
+func validatePair(p Pair) error {
+	if strings.TrimSpace(p.A) == "" || strings.TrimSpace(p.B) == "" {
+		return errors.New("prompt_a and prompt_b must not be empty")
+	}
+	return nil
+}
+
+// Score embeds every distinct prompt once and returns each pair's similarity.
+func Score(ctx context.Context, embed EmbedFunc, pairs []Pair) ([]ScoredPair, error) {
+	vectors := make(map[string][]float32)
+	vectorFor := func(text string) ([]float32, error) {
+		if v, ok := vectors[text]; ok {
+			return v, nil
+		}
+		v, err := embed(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %q: %w", text, err)
+		}
+		vectors[text] = v
+		return v, nil
+	}
+
+	scored := make([]ScoredPair, len(pairs))
+	for i, p := range pairs {
+		a, err := vectorFor(p.A)
+		if err != nil {
+			return nil, err
+		}
+		b, err := vectorFor(p.B)
+		if err != nil {
+			return nil, err
+		}
+		if len(a) != len(b) {
+			return nil, fmt.Errorf("embedding dimensions differ: %d and %d", len(a), len(b))
+		}
+		scored[i] = ScoredPair{Pair: p, Similarity: embedding.CosineSimilarity(a, b)}
+	}
+	return scored, nil
+}
+
+// Thresholds returns candidate thresholds from min to max in steps of step.
+func Thresholds(min, max, step float64) []float64 {
+	var out []float64
+	for i := 0; ; i++ {
+		t := math.Round((min+float64(i)*step)*1e6) / 1e6
+		if t > max+1e-9 {
+			break
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// Sweep computes metrics for each threshold.
+func Sweep(scored []ScoredPair, thresholds []float64) []Metrics {
+	out := make([]Metrics, len(thresholds))
+	for i, t := range thresholds {
+		m := Metrics{Threshold: t}
+		for _, p := range scored {
+			matched := p.Similarity > t
+			switch {
+			case matched && p.ShouldMatch:
+				m.TP++
+			case matched && !p.ShouldMatch:
+				m.FP++
+			case !matched && p.ShouldMatch:
+				m.FN++
+			default:
+				m.TN++
+			}
+		}
+		if m.TP+m.FP > 0 {
+			m.Precision = float64(m.TP) / float64(m.TP+m.FP)
+		} else {
+			// Nothing matched, so no wrong answers were served
+			m.Precision = 1
+		}
+		if m.TP+m.FN > 0 {
+			m.Recall = float64(m.TP) / float64(m.TP+m.FN)
+		}
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		out[i] = m
+	}
+	return out
+}
+
+// Recommend picks the threshold with the best F1 among those reaching
+// minPrecision. Ties go to the higher threshold, since a wrong hit costs
+// more than a miss. It reports false when no threshold reaches minPrecision.
+func Recommend(metrics []Metrics, minPrecision float64) (Metrics, bool) {
+	candidates := make([]Metrics, 0, len(metrics))
+	for _, m := range metrics {
+		if m.Precision >= minPrecision {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		return Metrics{}, false
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].F1 != candidates[j].F1 {
+			return candidates[i].F1 > candidates[j].F1
+		}
+		return candidates[i].Threshold > candidates[j].Threshold
+	})
+	return candidates[0], true
+}
+
+// WriteEnvFile sets key=value in a dotenv file, replacing an existing
+// assignment or appending one. The file is created if it does not exist.
+func WriteEnvFile(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	assignment := key + "=" + value
+	replaced := false
+	for i, line := range lines {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(line), "export ")
+		if strings.HasPrefix(trimmed, key+"=") {
+			lines[i] = assignment
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, assignment)
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+}
@@ -0,0 +1,148 @@
+package calibrate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReadPairs verifies both input formats, labels and header handling.
+func TestReadPairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Pair
+		wantErr bool
+	}{
+		{
+			name:  "csv with header",
+			input: "prompt_a,prompt_b,should_match\n\"Hi, there\",Hello,yes\nCats,Dogs,0\n",
+			want:  []Pair{{"Hi, there", "Hello", true}, {"Cats", "Dogs", false}},
+		},
+		{
+			name:  "csv without header",
+			input: "a,b,true\n",
+			want:  []Pair{{"a", "b", true}},
+		},
+		{
+			name:  "jsonl",
+			input: "\n{\"prompt_a\":\"a\",\"prompt_b\":\"b\",\"should_match\":true}\n\n{\"prompt_a\":\"c\",\"prompt_b\":\"d\"}\n",
+			want:  []Pair{{"a", "b", true}, {"c", "d", false}},
+		},
+		{name: "bad label", input: "a,b,true\nc,d,maybe\n", wantErr: true},
+		{name: "empty prompt", input: `{"prompt_a":"","prompt_b":"b"}`, wantErr: true},
+		{name: "empty input", input: "  \n", wantErr: true},
+		{name: "header only", input: "prompt_a,prompt_b,should_match\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadPairs(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadPairs failed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d pairs, got %d", len(tt.want), len(got))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("pair %d: got %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestScore verifies similarities are computed and prompts are embedded once.
+func TestScore(t *testing.T) {
+	vectors := map[string][]float32{
+		"a": {1, 0},
+		"b": {2, 0},
+		"c": {0, 3},
+	}
+	calls := 0
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		calls++
+		if v, ok := vectors[text]; ok {
+			return v, nil
+		}
+		return nil, errors.New("unknown prompt")
+	}
+
+	scored, err := Score(context.Background(), embed, []Pair{{"a", "b", true}, {"a", "c", false}})
+	if err != nil {
+		t.Fatalf("Score failed: %v", err)
+	}
+	if scored[0].Similarity < 0.9999 || scored[1].Similarity > 0.0001 {
+		t.Errorf("unexpected similarities: %v, %v", scored[0].Similarity, scored[1].Similarity)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 embedding calls, got %d", calls)
+	}
+
+	if _, err := Score(context.Background(), embed, []Pair{{"a", "missing", true}}); err == nil {
+		t.Error("expected embedding error to be returned")
+	}
+}
+
+// TestSweepAndRecommend verifies the confusion matrix and threshold choice.
+func TestSweepAndRecommend(t *testing.T) {
+	scored := []ScoredPair{
+		{Pair{ShouldMatch: true}, 0.97},
+		{Pair{ShouldMatch: true}, 0.93},
+		{Pair{ShouldMatch: true}, 0.88},
+		{Pair{ShouldMatch: false}, 0.91},
+		{Pair{ShouldMatch: false}, 0.80},
+	}
+	metrics := Sweep(scored, Thresholds(0.85, 0.95, 0.05))
+	if len(metrics) != 3 {
+		t.Fatalf("expected 3 thresholds, got %d", len(metrics))
+	}
+
+	at90 := metrics[1]
+	if at90.Threshold != 0.9 || at90.TP != 2 || at90.FP != 1 || at90.FN != 1 || at90.TN != 1 {
+		t.Errorf("unexpected metrics at 0.90: %+v", at90)
+	}
+
+	best, ok := Recommend(metrics, 0)
+	if !ok || best.Threshold != 0.85 {
+		t.Errorf("expected 0.85 to have the best F1, got %+v", best)
+	}
+
+	strict, ok := Recommend(metrics, 1.0)
+	if !ok || strict.Threshold != 0.95 || strict.FP != 0 {
+		t.Errorf("expected 0.95 as the only perfect-precision threshold, got %+v", strict)
+	}
+
+	if _, ok := Recommend(Sweep(scored, []float64{0.5}), 1.0); ok {
+		t.Error("expected no recommendation when precision is unreachable")
+	}
+}
+
+// TestWriteEnvFile verifies existing assignments are replaced and others kept.
+func TestWriteEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+
+	if err := WriteEnvFile(path, "SIMILARITY_THRESHOLD", "0.91"); err != nil {
+		t.Fatalf("WriteEnvFile failed: %v", err)
+	}
+	os.WriteFile(path, []byte("PORT=8080\nexport SIMILARITY_THRESHOLD=0.95\n# comment\n"), 0o600)
+	if err := WriteEnvFile(path, "SIMILARITY_THRESHOLD", "0.88"); err != nil {
+		t.Fatalf("WriteEnvFile failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "PORT=8080\nSIMILARITY_THRESHOLD=0.88\n# comment\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
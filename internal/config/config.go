@@ -4,6 +4,14 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/handler"
+	"semantic-cache-gateway/internal/lexical"
+	"semantic-cache-gateway/internal/models"
+	"semantic-cache-gateway/internal/verify"
 )
 
 type Config struct {
@@ -12,7 +20,125 @@ type Config struct {
 	SimilarityThreshold float64
 	Port                int
 	EmbeddingAPIKey     string
+	EmbeddingURL        string
+	EmbeddingModel      string
+	EmbeddingDimensions int
 	UpstreamAPIKey      string
+	// EmbeddingVersion names the embedding space; change it together with
+	// EMBEDDING_PREVIOUS_* to migrate the cache to a new model or dimension.
+	EmbeddingVersion string
+	// AdminToken protects the /admin and /debug endpoints, which answer 403
+	// when it is empty.
+	AdminToken string
+	// AdminImportMaxBytes bounds the snapshot body accepted by /admin/cache/import.
+	AdminImportMaxBytes int64
+	// DebugExplain honors the X-Cache-Explain request header on the chat
+	// endpoint for requests that carry AdminToken.
+	DebugExplain bool
+
+	// Redis deployment: Sentinel or cluster instead of REDIS_URL, ACL user and TLS
+	RedisUsername         string
+	RedisPassword         string
+	RedisSentinelMaster   string
+	RedisSentinelAddrs    []string
+	RedisSentinelPassword string
+	RedisClusterAddrs     []string
+	RedisTLS              bool
+	RedisTLSCAFile        string
+	RedisTLSCertFile      string
+	RedisTLSKeyFile       string
+	RedisTLSServerName    string
+	// Without Redis the gateway passes requests through and keeps retrying
+	RedisRetryInterval   time.Duration
+	RedisBreakerFailures int
+	RedisBreakerCooldown time.Duration
+
+	// Where entries are kept: redis (RediSearch), bolt or sqlite (a local
+	// file) or memory (this process only)
+	CacheBackend             string
+	CacheBoltPath            string
+	CacheBoltSweepInterval   time.Duration
+	CacheBoltFlushInterval   time.Duration
+	CacheSQLitePath          string
+	CacheSQLiteSweepInterval time.Duration
+
+	// Entry lifetime
+	CacheTTL         time.Duration
+	CacheSlidingTTL  bool
+	CacheStaleWindow time.Duration
+
+	// Size limits and eviction
+	CacheMaxEntries       int
+	CacheMaxBytes         int64
+	CacheEvictionPolicy   string
+	CacheEvictionInterval time.Duration
+
+	// In-process L1 tier serving exact matches in front of Redis
+	CacheL1MaxEntries int
+	CacheL1TTL        time.Duration
+
+	// Storage footprint
+	CacheCompression         string
+	CacheCompressionMinBytes int
+	CacheVectorType          string
+
+	// Recreate a cache index whose schema does not match instead of failing
+	CacheIndexAutoRecreate bool
+
+	// Vector index tuning; zero sizes keep the RediSearch defaults
+	CacheIndexAlgorithm     string
+	CacheIndexMetric        string
+	CacheIndexInitialCap    int
+	CacheHNSWM              int
+	CacheHNSWEFConstruction int
+	CacheHNSWEFRuntime      int
+
+	// Shadow mode: semantic hits are compared with upstream instead of served
+	SemanticShadowMode       bool
+	ShadowAgreementThreshold float64
+
+	// Feedback on cache hits via POST /feedback; off while FeedbackWindow is 0
+	FeedbackWindow           time.Duration
+	FeedbackMaxHits          int
+	FeedbackMaxComplaints    int
+	FeedbackThresholdPenalty float64
+
+	// Query normalization before hashing and embedding
+	QueryNormalization []string
+	QueryStopWords     []string
+	// PromptTemplatesFile is a JSON file of prompt templates (see internal/templates)
+	PromptTemplatesFile string
+
+	// Search scoping: partition attributes, tenant header, entry age and lexical checks
+	CachePartitionBy   []string
+	TenantHeader       string
+	CacheSearchMaxAge  time.Duration
+	LexicalGuardChecks []string
+
+	// Embedding migration from a previous model, version or dimension
+	EmbeddingPreviousModel      string
+	EmbeddingPreviousVersion    string
+	EmbeddingPreviousDimensions int
+	EmbeddingMigrationBatch     int
+	EmbeddingMigrationInterval  time.Duration
+
+	// Verification of gray-zone semantic hits
+	HitVerifier           string
+	VerifierHighThreshold float64
+	VerifierTimeout       time.Duration
+	VerifierCacheSize     int
+	// VerifierModel is the chat model asked by the llm verifier
+	VerifierModel string
+	// VerifierURL and VerifierMinScore configure the reranker verifier
+	VerifierURL      string
+	VerifierMinScore float64
+
+	// Cacheability policy
+	CacheMaxTemperature float64
+	CacheMaxN           int
+	CacheAllowTools     bool
+	CacheAllowUserField bool
+	CacheFinishReasons  []string
 }
 
 const (
@@ -20,17 +146,105 @@ const (
 	DefaultRedisURL            = "redis://localhost:6379"
 	DefaultSimilarityThreshold = 0.95
 	DefaultPort                = 8080
+	DefaultEmbeddingURL        = "https://api.openai.com/v1/embeddings"
+	DefaultEmbeddingModel      = "text-embedding-ada-002"
+	DefaultEmbeddingDimensions = 1536
+	DefaultEmbeddingVersion    = "1"
+	DefaultMigrationBatch      = 100
+	DefaultMigrationInterval   = time.Second
+	DefaultAdminImportMaxBytes = 512 << 20
+	DefaultCacheMaxTemperature = 0.0
+	DefaultCacheMaxN           = 1
+	DefaultCacheBackend        = "redis"
+	DefaultCacheBoltPath       = "cache.db"
+	DefaultBoltSweepInterval   = time.Minute
+	DefaultBoltFlushInterval   = 5 * time.Second
+	DefaultCacheSQLitePath     = "cache.sqlite"
+	DefaultCacheTTL            = 24 * time.Hour
+	DefaultEvictionPolicy      = "lru"
+	DefaultEvictionInterval    = time.Minute
+	DefaultCompression         = "none"
+	DefaultCompressionMinBytes = 1024
+	DefaultVectorType          = "FLOAT32"
+	DefaultIndexAlgorithm      = "HNSW"
+	DefaultIndexMetric         = "COSINE"
+	DefaultShadowAgreement     = 0.9
+	DefaultFeedbackComplaints  = 3
+	DefaultFeedbackPenalty     = 0.02
+	DefaultVerifierCacheSize   = 10000
+	DefaultRedisRetryInterval  = 5 * time.Second
+	DefaultL1TTL               = time.Minute
 )
 
 // Load reads configuration from environment variables with defaults.
 func Load() (*Config, error) {
 	cfg := &Config{
-		UpstreamURL:         getEnvOrDefault("UPSTREAM_URL", DefaultUpstreamURL),
-		RedisURL:            getEnvOrDefault("REDIS_URL", DefaultRedisURL),
-		EmbeddingAPIKey:     os.Getenv("EMBEDDING_API_KEY"),
-		UpstreamAPIKey:      os.Getenv("UPSTREAM_API_KEY"),
-		SimilarityThreshold: DefaultSimilarityThreshold,
-		Port:                DefaultPort,
+		UpstreamURL:              getEnvOrDefault("UPSTREAM_URL", DefaultUpstreamURL),
+		RedisURL:                 getEnvOrDefault("REDIS_URL", DefaultRedisURL),
+		RedisUsername:            os.Getenv("REDIS_USERNAME"),
+		RedisPassword:            os.Getenv("REDIS_PASSWORD"),
+		RedisSentinelMaster:      os.Getenv("REDIS_SENTINEL_MASTER"),
+		RedisSentinelAddrs:       getEnvList("REDIS_SENTINEL_ADDRS", nil),
+		RedisSentinelPassword:    os.Getenv("REDIS_SENTINEL_PASSWORD"),
+		RedisClusterAddrs:        getEnvList("REDIS_CLUSTER_ADDRS", nil),
+		RedisTLSCAFile:           os.Getenv("REDIS_TLS_CA_FILE"),
+		RedisTLSCertFile:         os.Getenv("REDIS_TLS_CERT_FILE"),
+		RedisTLSKeyFile:          os.Getenv("REDIS_TLS_KEY_FILE"),
+		RedisTLSServerName:       os.Getenv("REDIS_TLS_SERVER_NAME"),
+		RedisRetryInterval:       DefaultRedisRetryInterval,
+		RedisBreakerFailures:     cache.DefaultBreakerFailures,
+		RedisBreakerCooldown:     cache.DefaultBreakerCooldown,
+		EmbeddingAPIKey:          os.Getenv("EMBEDDING_API_KEY"),
+		EmbeddingURL:             getEnvOrDefault("EMBEDDING_URL", DefaultEmbeddingURL),
+		EmbeddingModel:           getEnvOrDefault("EMBEDDING_MODEL", DefaultEmbeddingModel),
+		EmbeddingDimensions:      DefaultEmbeddingDimensions,
+		EmbeddingVersion:         getEnvOrDefault("EMBEDDING_VERSION", DefaultEmbeddingVersion),
+		EmbeddingPreviousModel:   os.Getenv("EMBEDDING_PREVIOUS_MODEL"),
+		EmbeddingPreviousVersion: os.Getenv("EMBEDDING_PREVIOUS_VERSION"),
+		EmbeddingMigrationBatch:  DefaultMigrationBatch,
+		AdminToken:               os.Getenv("ADMIN_TOKEN"),
+		AdminImportMaxBytes:      DefaultAdminImportMaxBytes,
+		UpstreamAPIKey:           os.Getenv("UPSTREAM_API_KEY"),
+		SimilarityThreshold:      DefaultSimilarityThreshold,
+		Port:                     DefaultPort,
+		CacheBackend:             strings.ToLower(getEnvOrDefault("CACHE_BACKEND", DefaultCacheBackend)),
+		CacheBoltPath:            getEnvOrDefault("CACHE_BOLT_PATH", DefaultCacheBoltPath),
+		CacheBoltSweepInterval:   DefaultBoltSweepInterval,
+		CacheBoltFlushInterval:   DefaultBoltFlushInterval,
+		CacheSQLitePath:          getEnvOrDefault("CACHE_SQLITE_PATH", DefaultCacheSQLitePath),
+		CacheSQLiteSweepInterval: cache.DefaultSQLiteSweepInterval,
+		CacheTTL:                 DefaultCacheTTL,
+		CacheEvictionPolicy:      getEnvOrDefault("CACHE_EVICTION_POLICY", DefaultEvictionPolicy),
+		CacheEvictionInterval:    DefaultEvictionInterval,
+		CacheL1MaxEntries:        cache.DefaultL1MaxEntries,
+		CacheL1TTL:               DefaultL1TTL,
+		CacheCompression:         strings.ToLower(getEnvOrDefault("CACHE_COMPRESSION", DefaultCompression)),
+		CacheCompressionMinBytes: DefaultCompressionMinBytes,
+		CacheVectorType:          strings.ToUpper(getEnvOrDefault("CACHE_VECTOR_TYPE", DefaultVectorType)),
+		CacheIndexAlgorithm:      strings.ToUpper(getEnvOrDefault("CACHE_INDEX_ALGORITHM", DefaultIndexAlgorithm)),
+		CacheIndexMetric:         strings.ToUpper(getEnvOrDefault("CACHE_INDEX_METRIC", DefaultIndexMetric)),
+		ShadowAgreementThreshold: DefaultShadowAgreement,
+		FeedbackMaxHits:          handler.DefaultFeedbackMaxHits,
+		FeedbackMaxComplaints:    DefaultFeedbackComplaints,
+		FeedbackThresholdPenalty: DefaultFeedbackPenalty,
+		QueryNormalization:       getEnvList("QUERY_NORMALIZATION", models.DefaultNormalizeSteps),
+		QueryStopWords:           getEnvList("QUERY_STOP_WORDS", nil),
+		PromptTemplatesFile:      os.Getenv("PROMPT_TEMPLATES_FILE"),
+		CachePartitionBy:         getEnvList("CACHE_PARTITION_BY", nil),
+		TenantHeader:             getEnvOrDefault("TENANT_HEADER", handler.DefaultTenantHeader),
+		LexicalGuardChecks:       getEnvList("LEXICAL_GUARD", nil),
+		HitVerifier:              strings.ToLower(getEnvOrDefault("HIT_VERIFIER", verify.KindNone)),
+		VerifierHighThreshold:    handler.DefaultVerifyHighThreshold,
+		VerifierTimeout:          handler.DefaultVerifyTimeout,
+		VerifierCacheSize:        DefaultVerifierCacheSize,
+		VerifierModel:            getEnvOrDefault("VERIFIER_MODEL", verify.DefaultLLMModel),
+		VerifierURL:              os.Getenv("VERIFIER_URL"),
+		VerifierMinScore:         verify.DefaultRerankerMinScore,
+		CacheMaxTemperature:      DefaultCacheMaxTemperature,
+		CacheMaxN:                DefaultCacheMaxN,
+		CacheAllowTools:          false,
+		CacheAllowUserField:      true,
+		CacheFinishReasons:       getEnvList("CACHE_FINISH_REASONS", []string{"stop"}),
 	}
 
 	if thresholdStr := os.Getenv("SIMILARITY_THRESHOLD"); thresholdStr != "" {
@@ -49,6 +263,135 @@ func Load() (*Config, error) {
 		cfg.Port = port
 	}
 
+	if err := parseIntEnv("EMBEDDING_DIMENSIONS", &cfg.EmbeddingDimensions); err != nil {
+		return nil, err
+	}
+	cfg.EmbeddingPreviousDimensions = cfg.EmbeddingDimensions
+	cfg.EmbeddingMigrationInterval = DefaultMigrationInterval
+	if err := parseIntEnv("EMBEDDING_PREVIOUS_DIMENSIONS", &cfg.EmbeddingPreviousDimensions); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("EMBEDDING_MIGRATION_BATCH", &cfg.EmbeddingMigrationBatch); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("EMBEDDING_MIGRATION_INTERVAL", &cfg.EmbeddingMigrationInterval); err != nil {
+		return nil, err
+	}
+	if err := parseBoolEnv("REDIS_TLS", &cfg.RedisTLS); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("REDIS_RETRY_INTERVAL", &cfg.RedisRetryInterval); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("REDIS_BREAKER_FAILURES", &cfg.RedisBreakerFailures); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("REDIS_BREAKER_COOLDOWN", &cfg.RedisBreakerCooldown); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("CACHE_TTL", &cfg.CacheTTL); err != nil {
+		return nil, err
+	}
+	if err := parseBoolEnv("CACHE_SLIDING_TTL", &cfg.CacheSlidingTTL); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("CACHE_STALE_WINDOW", &cfg.CacheStaleWindow); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("CACHE_MAX_ENTRIES", &cfg.CacheMaxEntries); err != nil {
+		return nil, err
+	}
+	if err := parseInt64Env("CACHE_MAX_BYTES", &cfg.CacheMaxBytes); err != nil {
+		return nil, err
+	}
+	if err := parseInt64Env("ADMIN_IMPORT_MAX_BYTES", &cfg.AdminImportMaxBytes); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("CACHE_EVICTION_INTERVAL", &cfg.CacheEvictionInterval); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("CACHE_BOLT_SWEEP_INTERVAL", &cfg.CacheBoltSweepInterval); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("CACHE_BOLT_FLUSH_INTERVAL", &cfg.CacheBoltFlushInterval); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("CACHE_SQLITE_SWEEP_INTERVAL", &cfg.CacheSQLiteSweepInterval); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("CACHE_L1_MAX_ENTRIES", &cfg.CacheL1MaxEntries); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("CACHE_L1_TTL", &cfg.CacheL1TTL); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("CACHE_COMPRESSION_MIN_BYTES", &cfg.CacheCompressionMinBytes); err != nil {
+		return nil, err
+	}
+	if err := parseBoolEnv("CACHE_INDEX_AUTO_RECREATE", &cfg.CacheIndexAutoRecreate); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("CACHE_INDEX_INITIAL_CAP", &cfg.CacheIndexInitialCap); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("CACHE_HNSW_M", &cfg.CacheHNSWM); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("CACHE_HNSW_EF_CONSTRUCTION", &cfg.CacheHNSWEFConstruction); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("CACHE_HNSW_EF_RUNTIME", &cfg.CacheHNSWEFRuntime); err != nil {
+		return nil, err
+	}
+	if err := parseFloatEnv("CACHE_MAX_TEMPERATURE", &cfg.CacheMaxTemperature); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("CACHE_MAX_N", &cfg.CacheMaxN); err != nil {
+		return nil, err
+	}
+	if err := parseBoolEnv("CACHE_ALLOW_TOOLS", &cfg.CacheAllowTools); err != nil {
+		return nil, err
+	}
+	if err := parseBoolEnv("CACHE_ALLOW_USER_FIELD", &cfg.CacheAllowUserField); err != nil {
+		return nil, err
+	}
+	if err := parseBoolEnv("DEBUG_EXPLAIN", &cfg.DebugExplain); err != nil {
+		return nil, err
+	}
+	if err := parseBoolEnv("SEMANTIC_SHADOW_MODE", &cfg.SemanticShadowMode); err != nil {
+		return nil, err
+	}
+	if err := parseFloatEnv("SHADOW_AGREEMENT_THRESHOLD", &cfg.ShadowAgreementThreshold); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("CACHE_SEARCH_MAX_AGE", &cfg.CacheSearchMaxAge); err != nil {
+		return nil, err
+	}
+	if err := parseFloatEnv("VERIFIER_HIGH_THRESHOLD", &cfg.VerifierHighThreshold); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("VERIFIER_TIMEOUT", &cfg.VerifierTimeout); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("VERIFIER_CACHE_SIZE", &cfg.VerifierCacheSize); err != nil {
+		return nil, err
+	}
+	if err := parseFloatEnv("VERIFIER_MIN_SCORE", &cfg.VerifierMinScore); err != nil {
+		return nil, err
+	}
+	if err := parseDurationEnv("FEEDBACK_WINDOW", &cfg.FeedbackWindow); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("FEEDBACK_MAX_HITS", &cfg.FeedbackMaxHits); err != nil {
+		return nil, err
+	}
+	if err := parseIntEnv("FEEDBACK_MAX_COMPLAINTS", &cfg.FeedbackMaxComplaints); err != nil {
+		return nil, err
+	}
+	if err := parseFloatEnv("FEEDBACK_THRESHOLD_PENALTY", &cfg.FeedbackThresholdPenalty); err != nil {
+		return nil, err
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
@@ -64,12 +407,173 @@ func (c *Config) Validate() error {
 	if c.RedisURL == "" {
 		return errors.New("REDIS_URL is required")
 	}
+	if c.DebugExplain && c.AdminToken == "" {
+		return errors.New("DEBUG_EXPLAIN requires ADMIN_TOKEN")
+	}
+	if c.RedisSentinelMaster != "" && len(c.RedisClusterAddrs) > 0 {
+		return errors.New("REDIS_SENTINEL_MASTER and REDIS_CLUSTER_ADDRS cannot both be set")
+	}
+	if c.RedisSentinelMaster != "" && len(c.RedisSentinelAddrs) == 0 {
+		return errors.New("REDIS_SENTINEL_ADDRS is required with REDIS_SENTINEL_MASTER")
+	}
+	if (c.RedisTLSCertFile == "") != (c.RedisTLSKeyFile == "") {
+		return errors.New("REDIS_TLS_CERT_FILE and REDIS_TLS_KEY_FILE must be set together")
+	}
+	if c.RedisRetryInterval < 0 {
+		return errors.New("REDIS_RETRY_INTERVAL must not be negative")
+	}
+	if c.RedisBreakerFailures < 0 {
+		return errors.New("REDIS_BREAKER_FAILURES must not be negative")
+	}
+	if c.RedisBreakerCooldown <= 0 {
+		return errors.New("REDIS_BREAKER_COOLDOWN must be positive")
+	}
 	if c.SimilarityThreshold < 0.0 || c.SimilarityThreshold > 1.0 {
 		return errors.New("SIMILARITY_THRESHOLD must be between 0.0 and 1.0")
 	}
 	if c.Port < 1 || c.Port > 65535 {
 		return errors.New("PORT must be between 1 and 65535")
 	}
+	if c.EmbeddingDimensions < 1 {
+		return errors.New("EMBEDDING_DIMENSIONS must be positive")
+	}
+	if c.EmbeddingVersion == "" {
+		return errors.New("EMBEDDING_VERSION is required")
+	}
+	if c.EmbeddingPreviousModel != "" {
+		if c.EmbeddingPreviousModel == c.EmbeddingModel && c.EmbeddingPreviousVersion == c.EmbeddingVersion {
+			return errors.New("EMBEDDING_PREVIOUS_MODEL and EMBEDDING_PREVIOUS_VERSION must differ from the current model and version")
+		}
+		if c.EmbeddingPreviousDimensions < 1 {
+			return errors.New("EMBEDDING_PREVIOUS_DIMENSIONS must be positive")
+		}
+	}
+	if c.EmbeddingMigrationBatch < 1 || c.EmbeddingMigrationInterval <= 0 {
+		return errors.New("EMBEDDING_MIGRATION_BATCH and EMBEDDING_MIGRATION_INTERVAL must be positive")
+	}
+	if c.ShadowAgreementThreshold <= 0 || c.ShadowAgreementThreshold > 1 {
+		return errors.New("SHADOW_AGREEMENT_THRESHOLD must be greater than 0 and at most 1")
+	}
+	if c.FeedbackWindow < 0 || c.FeedbackMaxComplaints < 0 {
+		return errors.New("FEEDBACK_WINDOW and FEEDBACK_MAX_COMPLAINTS must not be negative")
+	}
+	if c.FeedbackMaxHits <= 0 {
+		return errors.New("FEEDBACK_MAX_HITS must be positive")
+	}
+	if c.FeedbackThresholdPenalty < 0 || c.FeedbackThresholdPenalty > 1 {
+		return errors.New("FEEDBACK_THRESHOLD_PENALTY must be between 0.0 and 1.0")
+	}
+	if _, err := models.NewNormalizer(c.NormalizeConfig()); err != nil {
+		return errors.New("QUERY_NORMALIZATION: " + err.Error())
+	}
+	for _, name := range c.CachePartitionBy {
+		if !contains(cache.Partitions, name) {
+			return errors.New("CACHE_PARTITION_BY entries must be one of " + strings.Join(cache.Partitions, ", "))
+		}
+	}
+	if c.CacheSearchMaxAge < 0 {
+		return errors.New("CACHE_SEARCH_MAX_AGE must not be negative")
+	}
+	if _, err := lexical.New(c.LexicalGuardChecks); err != nil {
+		return errors.New("LEXICAL_GUARD: " + err.Error())
+	}
+	switch c.HitVerifier {
+	case verify.KindNone, verify.KindLLM:
+	case verify.KindReranker:
+		if c.VerifierURL == "" {
+			return errors.New("VERIFIER_URL is required when HIT_VERIFIER is reranker")
+		}
+	default:
+		return errors.New("HIT_VERIFIER must be none, llm or reranker")
+	}
+	if c.HitVerifier != verify.KindNone && c.VerifierHighThreshold <= c.SimilarityThreshold {
+		return errors.New("VERIFIER_HIGH_THRESHOLD must be greater than SIMILARITY_THRESHOLD")
+	}
+	if c.VerifierHighThreshold > 1 {
+		return errors.New("VERIFIER_HIGH_THRESHOLD must be at most 1")
+	}
+	if c.VerifierTimeout <= 0 || c.VerifierCacheSize < 0 {
+		return errors.New("VERIFIER_TIMEOUT must be positive and VERIFIER_CACHE_SIZE must not be negative")
+	}
+	if c.CacheTTL < 0 {
+		return errors.New("CACHE_TTL must not be negative")
+	}
+	if c.CacheStaleWindow < 0 {
+		return errors.New("CACHE_STALE_WINDOW must not be negative")
+	}
+	switch c.CacheBackend {
+	case "redis", "bolt", "sqlite", "memory":
+	default:
+		return errors.New("CACHE_BACKEND must be redis, bolt, sqlite or memory")
+	}
+	if c.CacheBackend == "bolt" {
+		if c.CacheBoltPath == "" {
+			return errors.New("CACHE_BOLT_PATH must be set when CACHE_BACKEND is bolt")
+		}
+		if c.CacheBoltSweepInterval <= 0 || c.CacheBoltFlushInterval <= 0 {
+			return errors.New("CACHE_BOLT_SWEEP_INTERVAL and CACHE_BOLT_FLUSH_INTERVAL must be positive")
+		}
+	}
+	if c.CacheBackend == "sqlite" {
+		if c.CacheSQLitePath == "" {
+			return errors.New("CACHE_SQLITE_PATH must be set when CACHE_BACKEND is sqlite")
+		}
+		if c.CacheSQLiteSweepInterval <= 0 {
+			return errors.New("CACHE_SQLITE_SWEEP_INTERVAL must be positive")
+		}
+	}
+	if c.AdminImportMaxBytes <= 0 {
+		return errors.New("ADMIN_IMPORT_MAX_BYTES must be positive")
+	}
+	if c.CacheMaxEntries < 0 || c.CacheMaxBytes < 0 {
+		return errors.New("CACHE_MAX_ENTRIES and CACHE_MAX_BYTES must not be negative")
+	}
+	if c.CacheEvictionPolicy != "lru" && c.CacheEvictionPolicy != "lfu" {
+		return errors.New("CACHE_EVICTION_POLICY must be lru or lfu")
+	}
+	if c.CacheEvictionInterval <= 0 {
+		return errors.New("CACHE_EVICTION_INTERVAL must be positive")
+	}
+	if c.CacheL1MaxEntries < 0 {
+		return errors.New("CACHE_L1_MAX_ENTRIES must not be negative")
+	}
+	if c.CacheL1TTL <= 0 {
+		return errors.New("CACHE_L1_TTL must be positive")
+	}
+	switch c.CacheCompression {
+	case "none", "gzip", "zstd":
+	default:
+		return errors.New("CACHE_COMPRESSION must be none, gzip or zstd")
+	}
+	switch c.CacheVectorType {
+	case "FLOAT32", "FLOAT16", "INT8":
+	default:
+		return errors.New("CACHE_VECTOR_TYPE must be FLOAT32, FLOAT16 or INT8")
+	}
+	switch c.CacheIndexAlgorithm {
+	case "HNSW", "FLAT":
+	default:
+		return errors.New("CACHE_INDEX_ALGORITHM must be HNSW or FLAT")
+	}
+	switch c.CacheIndexMetric {
+	case "COSINE":
+	case "IP", "L2":
+		// INT8 vectors are scaled per entry, which only preserves angles
+		if c.CacheVectorType == "INT8" {
+			return errors.New("CACHE_INDEX_METRIC IP and L2 need FLOAT32 or FLOAT16 vectors")
+		}
+	default:
+		return errors.New("CACHE_INDEX_METRIC must be COSINE, IP or L2")
+	}
+	if c.CacheIndexInitialCap < 0 || c.CacheHNSWM < 0 || c.CacheHNSWEFConstruction < 0 || c.CacheHNSWEFRuntime < 0 {
+		return errors.New("CACHE_INDEX_INITIAL_CAP and CACHE_HNSW_* must not be negative")
+	}
+	if c.CacheMaxTemperature < 0.0 || c.CacheMaxTemperature > 2.0 {
+		return errors.New("CACHE_MAX_TEMPERATURE must be between 0.0 and 2.0")
+	}
+	if c.CacheMaxN < 1 {
+		return errors.New("CACHE_MAX_N must be at least 1")
+	}
 	return nil
 }
 
@@ -79,3 +583,96 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseFloatEnv(key string, dst *float64) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.New(key + " must be a valid float")
+	}
+	*dst = f
+	return nil
+}
+
+func parseIntEnv(key string, dst *int) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New(key + " must be a valid integer")
+	}
+	*dst = n
+	return nil
+}
+
+func parseDurationEnv(key string, dst *time.Duration) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.New(key + " must be a valid duration (e.g. 24h, 30m)")
+	}
+	*dst = d
+	return nil
+}
+
+func parseInt64Env(key string, dst *int64) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return errors.New(key + " must be a valid integer")
+	}
+	*dst = n
+	return nil
+}
+
+func parseBoolEnv(key string, dst *bool) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return errors.New(key + " must be a valid boolean")
+	}
+	*dst = b
+	return nil
+}
+
+// NormalizeConfig returns the query normalization settings.
+func (c *Config) NormalizeConfig() models.NormalizeConfig {
+	return models.NormalizeConfig{Steps: c.QueryNormalization, StopWords: c.QueryStopWords}
+}
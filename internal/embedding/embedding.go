@@ -138,3 +138,8 @@ func (s *Service) Generate(ctx context.Context, text string) ([]float32, error)
 func (s *Service) Dimensions() int {
 	return s.config.Dimensions
 }
+
+// ModelName returns the embedding model used by the service.
+func (s *Service) ModelName() string {
+	return s.config.ModelName
+}
@@ -0,0 +1,18 @@
+package embedding
+
+import "math"
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0 if
+// either is a zero vector. The vectors must have the same length.
+func CosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
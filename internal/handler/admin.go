@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"semantic-cache-gateway/internal/cache"
+)
+
+// ExportHandler streams all cache entries as NDJSON.
+// Embeddings are included unless the request sets ?embeddings=false.
+func ExportHandler(store cache.EntryStore, opts cache.ExportOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed, use GET"})
+			return
+		}
+
+		exportOpts := opts
+		exportOpts.IncludeEmbeddings = true
+		if v := r.URL.Query().Get("embeddings"); v != "" {
+			include, err := strconv.ParseBool(v)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "embeddings must be true or false"})
+				return
+			}
+			exportOpts.IncludeEmbeddings = include
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="cache-snapshot.ndjson"`)
+		// Errors after the first write can only be reported by truncating the stream
+		cache.Export(r.Context(), store, w, exportOpts)
+	}
+}
+
+// ImportHandler restores cache entries from an NDJSON snapshot in the request
+// body, which may be at most maxBytes long.
+func ImportHandler(store cache.EntryStore, opts cache.ImportOptions, maxBytes int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed, use POST"})
+			return
+		}
+
+		result, err := cache.Import(r.Context(), store, http.MaxBytesReader(w, r.Body, maxBytes), opts)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]interface{}{"error": err.Error(), "result": result})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error(), "result": result})
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// EntryAdmin reads and deletes single cache entries.
+type EntryAdmin interface {
+	Get(ctx context.Context, id string) (*cache.CacheEntry, error)
+	Delete(ctx context.Context, ids ...string) (int64, error)
+}
+
+// EntryHandler serves /admin/cache/entries/{id}. GET returns the entry,
+// including its feedback counts, without the embedding; DELETE removes it.
+func EntryHandler(store EntryAdmin, prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, prefix)
+		if id == "" || strings.Contains(id, "/") {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "entry ID required"})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			entry, err := store.Get(r.Context(), id)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if entry == nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "entry not found"})
+				return
+			}
+			entry.Embedding = nil
+			writeJSON(w, http.StatusOK, entry)
+		case http.MethodDelete:
+			deleted, err := store.Delete(r.Context(), id)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if deleted == 0 {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "entry not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed, use GET or DELETE"})
+		}
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
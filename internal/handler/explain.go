@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/middleware"
+	"semantic-cache-gateway/internal/models"
+)
+
+// ExplainHeader asks the chat endpoint to explain its cache decision instead
+// of answering. It is honored only when Config.ExplainHeader is set and the
+// request carries the admin token in AdminTokenHeader.
+const ExplainHeader = "X-Cache-Explain"
+
+// AdminTokenHeader carries the admin token on chat requests, whose
+// Authorization header holds the upstream API key.
+const AdminTokenHeader = "X-Admin-Token"
+
+// defaultExplainK is the number of neighbors reported when none is requested.
+const defaultExplainK = 5
+
+// maxExplainK bounds the neighbors a single explain request can ask for.
+const maxExplainK = 100
+
+// Decisions reported in Explanation.Decision.
+const (
+	DecisionExactHit    = "exact_hit"
+	DecisionSemanticHit = "semantic_hit"
+	DecisionMiss        = "miss"
+	// DecisionNeedsVerification is a semantic hit the verifier must confirm.
+	// Explain never calls the verifier.
+	DecisionNeedsVerification = "needs_verification"
+)
+
+// ExplainedEntry is a cache entry considered while explaining a request.
+type ExplainedEntry struct {
+	ID             string  `json:"id"`
+	QueryText      string  `json:"query_text"`
+	Similarity     float64 `json:"similarity"`
+	AboveThreshold bool    `json:"above_threshold"`
+	Stale          bool    `json:"stale"`
+	HitCount       int64   `json:"hit_count"`
+	FeedbackUp     int64   `json:"feedback_up,omitempty"`
+	FeedbackDown   int64   `json:"feedback_down,omitempty"`
+	// TemplateMismatch marks entries stored for a different prompt template,
+	// which are never served for this request.
+	TemplateMismatch bool `json:"template_mismatch,omitempty"`
+	// LexicalMismatch names the lexical guard check the entry failed.
+	LexicalMismatch string `json:"lexical_mismatch,omitempty"`
+	// NeedsVerification marks gray-zone entries served only if the verifier agrees.
+	NeedsVerification bool `json:"needs_verification,omitempty"`
+}
+
+// servable reports whether the pipeline would serve the entry as a semantic hit.
+func (e *ExplainedEntry) servable() bool {
+	return e.AboveThreshold && !e.TemplateMismatch && e.LexicalMismatch == ""
+}
+
+// Explanation describes what the pipeline would do for a request.
+type Explanation struct {
+	QueryText      string           `json:"query_text"`
+	NormalizedText string           `json:"normalized_text"`
+	QueryHash      string           `json:"query_hash"`
+	TemplateID     string           `json:"template_id,omitempty"`
+	Cacheable      bool             `json:"cacheable"`
+	PolicyReason   string           `json:"policy_reason,omitempty"`
+	ExactMatch     *ExplainedEntry  `json:"exact_match"`
+	Threshold      float64          `json:"threshold"`
+	Neighbors      []ExplainedEntry `json:"neighbors"`
+	// PreviousSpace is the best match among entries an embedding migration
+	// has not moved yet, when the current space has no servable match.
+	PreviousSpace   *ExplainedEntry `json:"previous_space,omitempty"`
+	Decision        string          `json:"decision"`
+	EmbedLatencyMs  float64         `json:"embed_latency_ms"`
+	SearchLatencyMs float64         `json:"search_latency_ms"`
+	Errors          []string        `json:"errors,omitempty"`
+}
+
+// ExplainHandler returns a handler for /debug/explain. It accepts a chat
+// completion request body and reports the cache decision without calling
+// upstream. The number of neighbors is set with ?k=.
+func (h *CacheHandler) ExplainHandler() http.Handler {
+	return middleware.BodyBufferMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed, use POST"})
+			return
+		}
+
+		var chatReq models.ChatCompletionRequest
+		if err := json.Unmarshal(middleware.GetBufferedBody(r.Context()), &chatReq); err != nil {
+			h.writeError(w, http.StatusBadRequest, "Invalid request format", "invalid_request_error")
+			return
+		}
+		queryText := models.ExtractQueryText(&chatReq)
+		if queryText == "" {
+			h.writeError(w, http.StatusBadRequest, "No user messages found in request", "invalid_request_error")
+			return
+		}
+
+		k, err := explainK(r.URL.Query().Get("k"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, h.explain(r.Context(), &chatReq, h.newQuery(r, &chatReq, queryText), k))
+	}))
+}
+
+// explainK parses the requested neighbor count.
+func explainK(v string) (int, error) {
+	if v == "" {
+		return defaultExplainK, nil
+	}
+	k, err := strconv.Atoi(v)
+	if err != nil || k < 1 || k > maxExplainK {
+		return 0, errInvalidK
+	}
+	return k, nil
+}
+
+var errInvalidK = errors.New("k must be an integer between 1 and " + strconv.Itoa(maxExplainK))
+
+// explain runs the lookup steps of the pipeline and records what each found.
+// Errors are reported in the explanation rather than failing the request, the
+// same way the pipeline degrades to a miss.
+func (h *CacheHandler) explain(
+	ctx context.Context,
+	chatReq *models.ChatCompletionRequest,
+	query *cacheQuery,
+	k int,
+) *Explanation {
+	now := time.Now()
+	exp := &Explanation{
+		QueryText:      query.Text,
+		NormalizedText: query.Lookup,
+		QueryHash:      query.Hash,
+		TemplateID:     query.TemplateID,
+		Threshold:      h.threshold,
+		Neighbors:      []ExplainedEntry{},
+		Decision:       DecisionMiss,
+	}
+
+	decision := h.policy.CheckRequest(chatReq)
+	exp.Cacheable, exp.PolicyReason = decision.Cacheable, decision.Reason
+
+	exactMatch, err := h.cache.CheckExactMatch(ctx, query.Hash)
+	if err != nil {
+		exp.Errors = append(exp.Errors, "exact match check failed: "+err.Error())
+	} else if exactMatch != nil {
+		exp.ExactMatch = explainEntry(exactMatch, 1.0, h.threshold, now)
+		exp.ExactMatch.AboveThreshold = true
+		exp.Decision = DecisionExactHit
+	}
+
+	embedStart := time.Now()
+	embeddingVec, err := h.embedding.Generate(ctx, query.Lookup)
+	exp.EmbedLatencyMs = time.Since(embedStart).Seconds() * 1000
+	if err != nil {
+		exp.Errors = append(exp.Errors, "embedding generation failed: "+err.Error())
+		return exp
+	}
+
+	searchStart := time.Now()
+	neighbors, err := h.cache.SearchTopK(ctx, embeddingVec, k, query.filter())
+	exp.SearchLatencyMs = time.Since(searchStart).Seconds() * 1000
+	if err != nil {
+		exp.Errors = append(exp.Errors, "vector search failed: "+err.Error())
+		return exp
+	}
+
+	for _, n := range neighbors {
+		e := explainEntry(n.Entry, n.Similarity, h.effectiveThreshold(n.Entry.FeedbackUp, n.Entry.FeedbackDown), now)
+		e.TemplateMismatch = !query.matches(n.Entry)
+		if ok, check := h.lexicalGuard.Check(query.Text, n.Entry.QueryText); !ok {
+			e.LexicalMismatch = check
+		}
+		e.NeedsVerification = e.servable() && h.needsVerification(n.Similarity)
+		exp.Neighbors = append(exp.Neighbors, *e)
+	}
+	if exp.Decision == DecisionMiss && len(exp.Neighbors) > 0 && exp.Neighbors[0].servable() {
+		exp.Decision = DecisionSemanticHit
+		if exp.Neighbors[0].NeedsVerification {
+			exp.Decision = DecisionNeedsVerification
+		}
+	}
+	if exp.Decision == DecisionMiss && h.previous.active() {
+		h.explainPreviousSpace(ctx, exp, query, embeddingVec, now)
+	}
+	return exp
+}
+
+// explainPreviousSpace reports the match the pipeline would find in the
+// previous embedding space.
+func (h *CacheHandler) explainPreviousSpace(ctx context.Context, exp *Explanation, query *cacheQuery, current []float32, now time.Time) {
+	vec, err := h.previousVector(ctx, query, current)
+	if err != nil {
+		exp.Errors = append(exp.Errors, "previous space embedding failed: "+err.Error())
+		return
+	}
+	entry, similarity, err := h.previous.Index.SearchSimilar(ctx, vec, h.threshold, query.filter())
+	if err != nil {
+		exp.Errors = append(exp.Errors, "previous space search failed: "+err.Error())
+		return
+	}
+	if entry == nil {
+		return
+	}
+	e := explainEntry(entry, similarity, h.effectiveThreshold(entry.FeedbackUp, entry.FeedbackDown), now)
+	e.TemplateMismatch = !query.matches(entry)
+	if ok, check := h.lexicalGuard.Check(query.Text, entry.QueryText); !ok {
+		e.LexicalMismatch = check
+	}
+	e.NeedsVerification = e.servable() && h.needsVerification(similarity)
+	exp.PreviousSpace = e
+	if e.servable() {
+		exp.Decision = DecisionSemanticHit
+		if e.NeedsVerification {
+			exp.Decision = DecisionNeedsVerification
+		}
+	}
+}
+
+func explainEntry(entry *cache.CacheEntry, similarity, threshold float64, now time.Time) *ExplainedEntry {
+	return &ExplainedEntry{
+		ID:             entry.ID,
+		QueryText:      entry.QueryText,
+		Similarity:     similarity,
+		AboveThreshold: similarity > threshold,
+		Stale:          entry.IsStale(now),
+		HitCount:       entry.HitCount,
+		FeedbackUp:     entry.FeedbackUp,
+		FeedbackDown:   entry.FeedbackDown,
+	}
+}
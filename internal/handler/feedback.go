@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+)
+
+// DefaultFeedbackMaxHits bounds the hit log when Config.FeedbackMaxHits is not set.
+const DefaultFeedbackMaxHits = 100000
+
+// FeedbackStore records ratings on cache entries and removes bad ones.
+type FeedbackStore interface {
+	RecordFeedback(ctx context.Context, id string, positive bool) (cache.FeedbackCounts, error)
+	Delete(ctx context.Context, ids ...string) (int64, error)
+}
+
+// FeedbackRequest is the body of POST /feedback.
+type FeedbackRequest struct {
+	RequestID string `json:"request_id"`
+	// Rating is "up" or "down".
+	Rating string `json:"rating"`
+}
+
+// FeedbackResponse reports the entry's counts after the rating.
+type FeedbackResponse struct {
+	RequestID string `json:"request_id"`
+	EntryID   string `json:"entry_id"`
+	cache.FeedbackCounts
+	// EffectiveThreshold is the similarity a semantic match needs to be served from the entry.
+	EffectiveThreshold float64 `json:"effective_threshold"`
+	Deleted            bool    `json:"deleted"`
+}
+
+// hitLog remembers which entry served each request ID for a limited window.
+// It holds at most maxEntries request IDs; the oldest are forgotten first.
+type hitLog struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxEntries int
+	hits       map[string]*list.Element
+	order      *list.List // oldest first
+}
+
+type hitRecord struct {
+	requestID string
+	entryID   string
+	expires   time.Time
+}
+
+func newHitLog(window time.Duration, maxEntries int) *hitLog {
+	return &hitLog{
+		window:     window,
+		maxEntries: maxEntries,
+		hits:       make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// remember records that requestID was served from entryID.
+func (l *hitLog) remember(requestID, entryID string) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.hits[requestID]; ok {
+		l.order.Remove(el)
+	}
+	l.hits[requestID] = l.order.PushBack(hitRecord{requestID: requestID, entryID: entryID, expires: now.Add(l.window)})
+
+	// Records share one window, so the oldest expire first
+	for el := l.order.Front(); el != nil; el = l.order.Front() {
+		rec := el.Value.(hitRecord)
+		if l.order.Len() <= l.maxEntries && !now.After(rec.expires) {
+			break
+		}
+		l.order.Remove(el)
+		delete(l.hits, rec.requestID)
+	}
+}
+
+// take returns the entry that served requestID and forgets it, so each
+// request can be rated once.
+func (l *hitLog) take(requestID string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.hits[requestID]
+	if !ok {
+		return "", false
+	}
+	l.order.Remove(el)
+	delete(l.hits, requestID)
+	rec := el.Value.(hitRecord)
+	if time.Now().After(rec.expires) {
+		return "", false
+	}
+	return rec.entryID, true
+}
+
+// effectiveThreshold raises the similarity threshold for an entry by the
+// penalty for each complaint not offset by positive feedback.
+func (h *CacheHandler) effectiveThreshold(up, down int64) float64 {
+	complaints := down - up
+	if complaints <= 0 {
+		return h.threshold
+	}
+	return math.Min(1, h.threshold+float64(complaints)*h.feedbackPenalty)
+}
+
+// FeedbackHandler returns a handler for POST /feedback. It links a request
+// ID from a cache hit back to the entry that served it and records the
+// rating. Entries reaching the complaint limit are deleted.
+func (h *CacheHandler) FeedbackHandler(store FeedbackStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed, use POST"})
+			return
+		}
+		if h.hits == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "feedback is disabled"})
+			return
+		}
+
+		var req FeedbackRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil || req.RequestID == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "body must be {\"request_id\": ..., \"rating\": \"up\" or \"down\"}"})
+			return
+		}
+		if req.Rating != "up" && req.Rating != "down" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "rating must be up or down"})
+			return
+		}
+
+		entryID, ok := h.hits.take(req.RequestID)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown, expired or already rated request ID"})
+			return
+		}
+
+		counts, err := store.RecordFeedback(r.Context(), entryID, req.Rating == "up")
+		if errors.Is(err, cache.ErrEntryNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cache entry no longer exists"})
+			return
+		}
+		if err != nil {
+			h.logger.Error("failed to record feedback", "error", err.Error(), "cache_key", entryID)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to record feedback"})
+			return
+		}
+
+		resp := FeedbackResponse{
+			RequestID:          req.RequestID,
+			EntryID:            entryID,
+			FeedbackCounts:     counts,
+			EffectiveThreshold: h.effectiveThreshold(counts.Up, counts.Down),
+		}
+		if h.feedbackMaxComplaints > 0 && counts.Down-counts.Up >= int64(h.feedbackMaxComplaints) {
+			if _, err := store.Delete(r.Context(), entryID); err != nil {
+				h.logger.Error("failed to delete entry after complaints", "error", err.Error(), "cache_key", entryID)
+			} else {
+				resp.Deleted = true
+			}
+		}
+
+		h.logger.Info("feedback recorded",
+			"request_id", req.RequestID,
+			"cache_key", entryID,
+			"rating", req.Rating,
+			"feedback_up", counts.Up,
+			"feedback_down", counts.Down,
+			"deleted", resp.Deleted,
+		)
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
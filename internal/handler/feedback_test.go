@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestHitLog verifies request IDs resolve once and expire after the window.
+func TestHitLog(t *testing.T) {
+	log := newHitLog(time.Hour, 10)
+	log.remember("req-1", "cache:a")
+
+	if id, ok := log.take("req-1"); !ok || id != "cache:a" {
+		t.Fatalf("expected cache:a, got %q (ok=%v)", id, ok)
+	}
+	if _, ok := log.take("req-1"); ok {
+		t.Error("expected a request ID to be rated only once")
+	}
+	if _, ok := log.take("req-unknown"); ok {
+		t.Error("expected unknown request ID to be rejected")
+	}
+
+	expired := newHitLog(-time.Second, 10)
+	expired.remember("req-2", "cache:b")
+	if _, ok := expired.take("req-2"); ok {
+		t.Error("expected expired request ID to be rejected")
+	}
+}
+
+// TestHitLog_MaxEntries verifies the log forgets the oldest request IDs
+// beyond its size limit.
+func TestHitLog_MaxEntries(t *testing.T) {
+	log := newHitLog(time.Hour, 2)
+	log.remember("req-1", "cache:a")
+	log.remember("req-2", "cache:b")
+	log.remember("req-3", "cache:c")
+
+	if len(log.hits) != 2 || log.order.Len() != 2 {
+		t.Fatalf("expected 2 remembered hits, got %d", len(log.hits))
+	}
+	if _, ok := log.take("req-1"); ok {
+		t.Error("expected the oldest request ID to be forgotten")
+	}
+	for _, id := range []string{"req-2", "req-3"} {
+		if _, ok := log.take(id); !ok {
+			t.Errorf("expected %s to be remembered", id)
+		}
+	}
+}
+
+// TestEffectiveThreshold verifies net complaints raise the threshold up to 1.
+func TestEffectiveThreshold(t *testing.T) {
+	h := &CacheHandler{threshold: 0.9, feedbackPenalty: 0.02}
+
+	tests := []struct {
+		name     string
+		up, down int64
+		want     float64
+	}{
+		{"no feedback", 0, 0, 0.9},
+		{"positive only", 3, 0, 0.9},
+		{"offset complaints", 2, 2, 0.9},
+		{"net complaints", 1, 3, 0.94},
+		{"capped", 0, 10, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.effectiveThreshold(tt.up, tt.down); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("effectiveThreshold(%d, %d) = %v, want %v", tt.up, tt.down, got, tt.want)
+			}
+		})
+	}
+}
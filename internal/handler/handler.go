@@ -2,32 +2,126 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"semantic-cache-gateway/internal/cache"
 	"semantic-cache-gateway/internal/embedding"
+	"semantic-cache-gateway/internal/lexical"
 	"semantic-cache-gateway/internal/logger"
 	"semantic-cache-gateway/internal/middleware"
 	"semantic-cache-gateway/internal/models"
+	"semantic-cache-gateway/internal/policy"
 	"semantic-cache-gateway/internal/proxy"
+	"semantic-cache-gateway/internal/templates"
+	"semantic-cache-gateway/internal/verify"
 )
 
 // CacheHandler orchestrates the caching pipeline for LLM requests.
 type CacheHandler struct {
-	cache       cache.CacheService
-	embedding   embedding.EmbeddingService
-	proxy       proxy.UpstreamProxy
-	logger      *logger.Logger
-	threshold   float64
+	cache         cache.CacheService
+	embedding     embedding.EmbeddingService
+	proxy         proxy.UpstreamProxy
+	logger        *logger.Logger
+	threshold     float64
+	policy        *policy.Policy
+	explainHeader bool
+	adminToken    string
+	normalizer    *models.Normalizer
+	templates     *templates.Set
+
+	// partitionBy lists the cache.Partition* attributes searches are scoped to.
+	partitionBy  []string
+	tenantHeader string
+	searchMaxAge time.Duration
+	lexicalGuard *lexical.Guard
+
+	// previous serves entries an embedding migration has not moved yet; nil when none runs.
+	previous *PreviousSpace
+
+	// verifier checks semantic hits at or below verifyHighThreshold; nil serves them directly.
+	verifier            verify.HitVerifier
+	verifyHighThreshold float64
+	verifyTimeout       time.Duration
+
+	// shadowMode records semantic hits without serving them.
+	shadowMode      bool
+	shadowAgreement float64
+
+	// hits maps request IDs of cache hits to entry IDs for feedback; nil disables feedback.
+	hits                  *hitLog
+	feedbackPenalty       float64
+	feedbackMaxComplaints int
+
+	// revalidating tracks entry IDs with a background refresh in flight.
+	revalidating sync.Map
 }
 
+// revalidateTimeout bounds the background upstream call for stale entries.
+const revalidateTimeout = 60 * time.Second
+
 // Config holds configuration for the cache handler.
 type Config struct {
 	SimilarityThreshold float64
+	// Policy decides which upstream responses are stored. Defaults to policy.DefaultConfig().
+	Policy *policy.Policy
+	// ExplainHeader lets clients send X-Cache-Explain: 1 to get an
+	// explanation of the cache decision instead of a completion.
+	ExplainHeader bool
+	// AdminToken must accompany X-Cache-Explain, since explanations show
+	// other clients' cached queries. Empty disables the header.
+	AdminToken string
+	// Normalizer rewrites query text before hashing and embedding. Nil leaves it unchanged.
+	Normalizer *models.Normalizer
+	// Templates split templated prompts so only their meaningful part is
+	// hashed and embedded and their variables must match exactly.
+	Templates *templates.Set
+	// PartitionBy scopes exact and semantic matches to requests with the same
+	// attributes (cache.PartitionModel, PartitionTenant, PartitionSystemPrompt,
+	// PartitionLanguage).
+	PartitionBy []string
+	// TenantHeader names the tenant. Defaults to DefaultTenantHeader.
+	TenantHeader string
+	// SearchMaxAge excludes entries older than this from vector search. Zero disables.
+	SearchMaxAge time.Duration
+	// LexicalGuard rejects semantic hits whose prompts differ in numbers,
+	// negation or named entities. Nil disables.
+	LexicalGuard *lexical.Guard
+	// PreviousSpace is searched when the current embedding space has no
+	// match while an embedding migration runs. Nil disables.
+	PreviousSpace *PreviousSpace
+	// Verifier checks gray-zone semantic hits, those with a similarity above
+	// SimilarityThreshold but not above VerifyHighThreshold. Nil disables.
+	Verifier verify.HitVerifier
+	// VerifyHighThreshold is the similarity above which hits skip the
+	// verifier. Defaults to DefaultVerifyHighThreshold.
+	VerifyHighThreshold float64
+	// VerifyTimeout bounds each verifier call. Defaults to DefaultVerifyTimeout.
+	VerifyTimeout time.Duration
+	// ShadowMode forwards semantic hits upstream instead of serving them and
+	// compares the cached answer to the fresh one. Exact hits are still served.
+	ShadowMode bool
+	// ShadowAgreementThreshold is the answer similarity counted as agreement.
+	// Defaults to DefaultShadowAgreementThreshold.
+	ShadowAgreementThreshold float64
+	// FeedbackWindow is how long a hit's request ID can be rated via POST /feedback.
+	// Zero disables feedback.
+	FeedbackWindow time.Duration
+	// FeedbackMaxHits bounds how many hits are remembered for rating; the
+	// oldest are forgotten first. Defaults to 100000.
+	FeedbackMaxHits int
+	// FeedbackThresholdPenalty raises an entry's similarity threshold for each
+	// net negative rating.
+	FeedbackThresholdPenalty float64
+	// FeedbackMaxComplaints deletes an entry once its net negative ratings
+	// reach this count. Zero never deletes.
+	FeedbackMaxComplaints int
 }
 
 // New creates a new CacheHandler with the given dependencies.
@@ -42,13 +136,83 @@ func New(
 	if cfg != nil && cfg.SimilarityThreshold > 0 {
 		threshold = cfg.SimilarityThreshold
 	}
+	cachePolicy := policy.New(policy.DefaultConfig())
+	if cfg != nil && cfg.Policy != nil {
+		cachePolicy = cfg.Policy
+	}
+	shadowAgreement := DefaultShadowAgreementThreshold
+	if cfg != nil && cfg.ShadowAgreementThreshold > 0 {
+		shadowAgreement = cfg.ShadowAgreementThreshold
+	}
+	var normalizer *models.Normalizer
+	var promptTemplates *templates.Set
+	var partitionBy []string
+	var searchMaxAge time.Duration
+	var lexicalGuard *lexical.Guard
+	var previous *PreviousSpace
+	var adminToken string
+	if cfg != nil {
+		normalizer, promptTemplates = cfg.Normalizer, cfg.Templates
+		partitionBy, searchMaxAge, lexicalGuard = cfg.PartitionBy, cfg.SearchMaxAge, cfg.LexicalGuard
+		previous, adminToken = cfg.PreviousSpace, cfg.AdminToken
+	}
+	tenantHeader := DefaultTenantHeader
+	if cfg != nil && cfg.TenantHeader != "" {
+		tenantHeader = cfg.TenantHeader
+	}
+	var verifier verify.HitVerifier
+	verifyHighThreshold, verifyTimeout := DefaultVerifyHighThreshold, DefaultVerifyTimeout
+	if cfg != nil && cfg.Verifier != nil {
+		verifier = cfg.Verifier
+		if cfg.VerifyHighThreshold > 0 {
+			verifyHighThreshold = cfg.VerifyHighThreshold
+		}
+		if cfg.VerifyTimeout > 0 {
+			verifyTimeout = cfg.VerifyTimeout
+		}
+	}
+	var hits *hitLog
+	var feedbackPenalty float64
+	var feedbackMaxComplaints int
+	if cfg != nil && cfg.FeedbackWindow > 0 {
+		maxHits := cfg.FeedbackMaxHits
+		if maxHits <= 0 {
+			maxHits = DefaultFeedbackMaxHits
+		}
+		hits = newHitLog(cfg.FeedbackWindow, maxHits)
+		feedbackPenalty = cfg.FeedbackThresholdPenalty
+		feedbackMaxComplaints = cfg.FeedbackMaxComplaints
+	}
 
 	return &CacheHandler{
-		cache:     cacheService,
-		embedding: embeddingService,
-		proxy:     upstreamProxy,
-		logger:    log,
-		threshold: threshold,
+		cache:         cacheService,
+		embedding:     embeddingService,
+		proxy:         upstreamProxy,
+		logger:        log,
+		threshold:     threshold,
+		policy:        cachePolicy,
+		explainHeader: cfg != nil && cfg.ExplainHeader,
+		adminToken:    adminToken,
+		normalizer:    normalizer,
+		templates:     promptTemplates,
+
+		partitionBy:  partitionBy,
+		tenantHeader: tenantHeader,
+		searchMaxAge: searchMaxAge,
+		lexicalGuard: lexicalGuard,
+
+		previous: previous,
+
+		verifier:            verifier,
+		verifyHighThreshold: verifyHighThreshold,
+		verifyTimeout:       verifyTimeout,
+
+		shadowMode:      cfg != nil && cfg.ShadowMode,
+		shadowAgreement: shadowAgreement,
+
+		hits:                  hits,
+		feedbackPenalty:       feedbackPenalty,
+		feedbackMaxComplaints: feedbackMaxComplaints,
 	}
 }
 
@@ -88,18 +252,39 @@ func (h *CacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Compute SHA-256 hash for exact match lookup
-	queryHash := models.ComputeQueryHash(queryText)
-	log.Info("query extracted", "query_hash", queryHash, "query_length", len(queryText))
+	// Split templated prompts and compute the SHA-256 hash of the normalized
+	// text for exact match lookup; the original text is kept for storage and display
+	query := h.newQuery(r, &chatReq, queryText)
+	log.Info("query extracted", "query_hash", query.Hash, "query_length", len(queryText), "template_id", query.TemplateID)
+
+	// Explain mode reports the cache decision without calling upstream
+	if h.explainHeader && r.Header.Get(ExplainHeader) == "1" {
+		w.Header().Set("X-Request-ID", requestID)
+		if !middleware.ValidToken(r.Header.Get(AdminTokenHeader), h.adminToken) {
+			h.writeError(w, http.StatusUnauthorized, "X-Cache-Explain requires the admin token in "+AdminTokenHeader, "authentication_error")
+			h.logError(log, requestID, startTime, "explain request without a valid admin token")
+			return
+		}
+		writeJSON(w, http.StatusOK, h.explain(ctx, &chatReq, query, defaultExplainK))
+		log.Info("request explained", "query_hash", query.Hash)
+		return
+	}
 
 	// Step 1: Check for exact hash match
-	exactMatch, err := h.cache.CheckExactMatch(ctx, queryHash)
+	exactMatch, err := h.cache.CheckExactMatch(ctx, query.Hash)
+	if errors.Is(err, cache.ErrUnavailable) {
+		// Redis is down: pass through without paying for an embedding
+		RecordBypass()
+		log.Warn("cache unavailable, passing request through")
+		h.forwardToUpstream(w, r, bodyBytes, &chatReq, log, requestID, startTime, query, nil)
+		return
+	}
 	if err != nil {
 		log.Error("exact match check failed", "error", err.Error())
 		// Continue to embedding on cache error (graceful degradation)
 	} else if exactMatch != nil {
 		// Cache hit on exact match
-		h.serveCachedResponse(w, exactMatch, log, requestID, startTime, 1.0)
+		h.serveHit(w, r, bodyBytes, &chatReq, exactMatch, log, requestID, startTime, 1.0, true)
 		return
 	}
 
@@ -107,13 +292,13 @@ func (h *CacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Step 2: Generate embedding for vector search
 	embedStart := time.Now()
-	embeddingVec, err := h.embedding.Generate(ctx, queryText)
+	embeddingVec, err := h.embedding.Generate(ctx, query.Lookup)
 	embedLatency := time.Since(embedStart).Seconds() * 1000
 
 	if err != nil {
 		log.Error("embedding generation failed", "error", err.Error(), "embed_latency_ms", embedLatency)
 		// Forward to upstream on embedding failure (graceful degradation)
-		h.forwardToUpstream(w, r, bodyBytes, log, requestID, startTime, queryHash, queryText, nil)
+		h.forwardToUpstream(w, r, bodyBytes, &chatReq, log, requestID, startTime, query, nil)
 		return
 	}
 
@@ -121,30 +306,184 @@ func (h *CacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Step 3: Perform vector similarity search
 	searchStart := time.Now()
-	similarEntry, similarity, err := h.cache.SearchSimilar(ctx, embeddingVec, h.threshold)
+	similarEntry, similarity, err := h.cache.SearchSimilar(ctx, embeddingVec, h.threshold, query.filter())
 	searchLatency := time.Since(searchStart).Seconds() * 1000
 
 	if err != nil {
 		log.Error("vector search failed", "error", err.Error(), "search_latency_ms", searchLatency)
 		// Forward to upstream on search failure (graceful degradation)
-		h.forwardToUpstream(w, r, bodyBytes, log, requestID, startTime, queryHash, queryText, embeddingVec)
+		h.forwardToUpstream(w, r, bodyBytes, &chatReq, log, requestID, startTime, query, embeddingVec)
 		return
 	}
 
 	log.Info("vector search completed", "search_latency_ms", searchLatency, "similarity", similarity)
 
+	if similarEntry == nil && h.previous.active() {
+		// Entries not yet re-embedded are only found in the previous index
+		similarEntry, similarity = h.searchPreviousSpace(ctx, query, embeddingVec, log)
+	}
+
+	if similarEntry != nil && !query.matches(similarEntry) {
+		// Untemplated searches are not pre-filtered, so drop templated entries here
+		log.Info("semantic hit rejected: prompt template differs", "cache_key", similarEntry.ID)
+		similarEntry = nil
+	}
+
+	if similarEntry != nil {
+		if ok, check := h.lexicalGuard.Check(query.Text, similarEntry.QueryText); !ok {
+			// Close in meaning but different in fact, e.g. "2023" vs "2024"
+			log.Info("semantic hit rejected by lexical guard", "cache_key", similarEntry.ID, "similarity", similarity, "check", check)
+			RecordLexicalReject()
+			similarEntry = nil
+		}
+	}
+
+	if similarEntry != nil && similarity <= h.effectiveThreshold(similarEntry.FeedbackUp, similarEntry.FeedbackDown) {
+		// Negative feedback raised this entry's threshold above the match
+		log.Info("semantic hit demoted by feedback",
+			"cache_key", similarEntry.ID,
+			"similarity", similarity,
+			"feedback_down", similarEntry.FeedbackDown,
+		)
+		similarEntry = nil
+	}
+
+	if similarEntry != nil && h.needsVerification(similarity) && !h.verifyHit(ctx, r, query, similarEntry, similarity, log) {
+		// Gray-zone match the verifier did not confirm
+		similarEntry = nil
+	}
+
+	if similarEntry != nil && h.shadowMode {
+		// Shadow mode: answer from upstream and compare with the cached answer
+		log.Info("semantic hit recorded in shadow mode", "cache_key", similarEntry.ID, "similarity", similarity)
+		RecordShadowHit()
+		if freshBody := h.forwardToUpstream(w, r, bodyBytes, &chatReq, log, requestID, startTime, query, embeddingVec); freshBody != nil {
+			h.compareShadow(similarEntry, similarity, freshBody, log)
+		}
+		return
+	}
+
 	if similarEntry != nil {
 		// Cache hit on semantic match
-		h.serveCachedResponse(w, similarEntry, log, requestID, startTime, similarity)
+		h.serveHit(w, r, bodyBytes, &chatReq, similarEntry, log, requestID, startTime, similarity, false)
 		return
 	}
 
 	// Step 4: Cache miss - forward to upstream
 	log.Info("cache miss, forwarding to upstream")
-	h.forwardToUpstream(w, r, bodyBytes, log, requestID, startTime, queryHash, queryText, embeddingVec)
+	h.forwardToUpstream(w, r, bodyBytes, &chatReq, log, requestID, startTime, query, embeddingVec)
 }
 
 
+// serveHit serves a cache hit. Fresh entries have their TTL slid forward;
+// stale entries are served immediately. Stale exact hits are refreshed in the
+// background; semantic ones are not, since replaying a paraphrase would store
+// the answer to another question under the entry.
+func (h *CacheHandler) serveHit(
+	w http.ResponseWriter,
+	r *http.Request,
+	bodyBytes []byte,
+	chatReq *models.ChatCompletionRequest,
+	entry *cache.CacheEntry,
+	log *logger.Logger,
+	requestID string,
+	startTime time.Time,
+	similarity float64,
+	exact bool,
+) {
+	if entry.IsStale(time.Now()) {
+		h.serveCachedResponse(w, entry, log, requestID, startTime, similarity, "STALE")
+		if exact {
+			h.revalidate(r, bodyBytes, chatReq, entry, log)
+		}
+		return
+	}
+
+	h.serveCachedResponse(w, entry, log, requestID, startTime, similarity, "HIT")
+	if entry.Tier == cache.TierL1 {
+		// Access metadata is recorded when the entry is next read from
+		// Redis, at the latest once it ages out of L1
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.cache.Touch(ctx, entry); err != nil {
+			log.Error("failed to refresh cache entry TTL", "error", err.Error(), "cache_key", entry.ID)
+		}
+	}()
+}
+
+// revalidate replays the request upstream in the background and overwrites
+// the stale entry with the fresh response. The request must be the one the
+// entry was stored for. Only one refresh per entry runs at a time.
+func (h *CacheHandler) revalidate(
+	r *http.Request,
+	bodyBytes []byte,
+	chatReq *models.ChatCompletionRequest,
+	stale *cache.CacheEntry,
+	log *logger.Logger,
+) {
+	if _, inFlight := h.revalidating.LoadOrStore(stale.ID, struct{}{}); inFlight {
+		return
+	}
+	method, target, header := r.Method, r.URL.String(), r.Header.Clone()
+	// Copied now because recording this hit updates the entry concurrently
+	hitCount, feedbackUp, feedbackDown := stale.HitCount, stale.FeedbackUp, stale.FeedbackDown
+
+	go func() {
+		defer h.revalidating.Delete(stale.ID)
+		ctx, cancel := context.WithTimeout(context.Background(), revalidateTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(bodyBytes))
+		if err != nil {
+			log.Error("failed to build revalidation request", "error", err.Error())
+			return
+		}
+		req.Header = header
+
+		resp, err := h.proxy.Forward(ctx, req)
+		if err != nil {
+			log.Error("revalidation upstream request failed", "error", err.Error(), "cache_key", stale.ID)
+			return
+		}
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			log.Error("failed to read revalidation response", "error", err.Error(), "cache_key", stale.ID)
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			log.Info("revalidation skipped", "status_code", resp.StatusCode, "cache_key", stale.ID)
+			return
+		}
+		if decision := h.policy.Evaluate(chatReq, respBody); !decision.Cacheable {
+			log.Info("revalidated response not cached", "reason", decision.Reason, "cache_key", stale.ID)
+			return
+		}
+
+		h.cache.StoreAsync(&cache.CacheEntry{
+			ID:           stale.ID,
+			QueryHash:    stale.QueryHash,
+			QueryText:    stale.QueryText,
+			TemplateKey:  stale.TemplateKey,
+			Model:        stale.Model,
+			Tenant:       stale.Tenant,
+			SystemHash:   stale.SystemHash,
+			Language:     stale.Language,
+			Embedding:    stale.Embedding,
+			LLMResponse:  string(respBody),
+			CreatedAt:    time.Now().Unix(),
+			HitCount:     hitCount,
+			FeedbackUp:   feedbackUp,
+			FeedbackDown: feedbackDown,
+		})
+		log.Info("stale cache entry revalidated", "cache_key", stale.ID)
+	}()
+}
+
 // serveCachedResponse writes a cached response to the client.
 func (h *CacheHandler) serveCachedResponse(
 	w http.ResponseWriter,
@@ -153,18 +492,33 @@ func (h *CacheHandler) serveCachedResponse(
 	requestID string,
 	startTime time.Time,
 	similarity float64,
+	cacheStatus string,
 ) {
 	totalLatency := time.Since(startTime).Seconds() * 1000
 
 	// Record stats
-	RecordHit(int64(totalLatency))
+	if cacheStatus == "STALE" {
+		RecordStaleHit(int64(totalLatency))
+	} else {
+		RecordHit(int64(totalLatency))
+	}
+	tier := entry.Tier
+	if tier == "" {
+		tier = cache.TierL2
+	}
+	RecordTierHit(tier)
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("X-Cache-Status", "HIT")
+	w.Header().Set("X-Cache-Status", cacheStatus)
+	w.Header().Set("X-Cache-Tier", tier)
 	w.Header().Set("X-Request-ID", requestID)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(entry.LLMResponse)) // Convert string back to bytes
 
+	if h.hits != nil {
+		h.hits.remember(requestID, entry.ID)
+	}
+
 	log.LogRequest(logger.RequestLog{
 		RequestID:       requestID,
 		Status:          "cache_hit",
@@ -174,17 +528,18 @@ func (h *CacheHandler) serveCachedResponse(
 }
 
 // forwardToUpstream forwards the request to the upstream LLM and caches the response.
+// It returns the response body when upstream answered 200 OK, and nil otherwise.
 func (h *CacheHandler) forwardToUpstream(
 	w http.ResponseWriter,
 	r *http.Request,
 	bodyBytes []byte,
+	chatReq *models.ChatCompletionRequest,
 	log *logger.Logger,
 	requestID string,
 	startTime time.Time,
-	queryHash string,
-	queryText string,
+	query *cacheQuery,
 	embeddingVec []float32,
-) {
+) []byte {
 	// Restore the request body for forwarding
 	middleware.RestoreBody(r)
 
@@ -200,7 +555,7 @@ func (h *CacheHandler) forwardToUpstream(
 			TotalLatencyMs: totalLatency,
 			Error:          err.Error(),
 		})
-		return
+		return nil
 	}
 	defer resp.Body.Close()
 
@@ -216,7 +571,7 @@ func (h *CacheHandler) forwardToUpstream(
 			TotalLatencyMs: totalLatency,
 			Error:          err.Error(),
 		})
-		return
+		return nil
 	}
 
 	// Copy response headers
@@ -232,17 +587,9 @@ func (h *CacheHandler) forwardToUpstream(
 
 	totalLatency := time.Since(startTime).Seconds() * 1000
 
-	// Store in cache asynchronously (only if we have embedding and response is successful)
+	// Store in cache asynchronously (only if we have embedding and the policy allows it)
 	if embeddingVec != nil && resp.StatusCode == http.StatusOK {
-		entry := &cache.CacheEntry{
-			QueryHash:   queryHash,
-			QueryText:   queryText,
-			Embedding:   embeddingVec,
-			LLMResponse: string(respBody), // Store as string
-			CreatedAt:   time.Now().Unix(),
-		}
-		h.cache.StoreAsync(entry)
-		log.Info("cache entry queued for storage", "query_hash", queryHash)
+		h.storeIfCacheable(chatReq, respBody, log, query, embeddingVec)
 	}
 
 	log.LogRequest(logger.RequestLog{
@@ -253,6 +600,41 @@ func (h *CacheHandler) forwardToUpstream(
 
 	// Record stats
 	RecordMiss(int64(totalLatency))
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return respBody
+}
+
+// storeIfCacheable queues the response for storage when the cacheability
+// policy accepts both the request and the response.
+func (h *CacheHandler) storeIfCacheable(
+	chatReq *models.ChatCompletionRequest,
+	respBody []byte,
+	log *logger.Logger,
+	query *cacheQuery,
+	embeddingVec []float32,
+) {
+	if decision := h.policy.Evaluate(chatReq, respBody); !decision.Cacheable {
+		log.Info("response not cached", "reason", decision.Reason, "query_hash", query.Hash)
+		return
+	}
+
+	entry := &cache.CacheEntry{
+		QueryHash:   query.Hash,
+		QueryText:   query.Text,
+		TemplateKey: query.TemplateKey,
+		Model:       query.Model,
+		Tenant:      query.Tenant,
+		SystemHash:  query.SystemHash,
+		Language:    query.Language,
+		Embedding:   embeddingVec,
+		LLMResponse: string(respBody), // Store as string
+		CreatedAt:   time.Now().Unix(),
+	}
+	h.cache.StoreAsync(entry)
+	log.Info("cache entry queued for storage", "query_hash", query.Hash)
 }
 
 
@@ -288,29 +670,62 @@ func (h *CacheHandler) logError(log *logger.Logger, requestID string, startTime
 	})
 }
 
-// HealthHandler returns a simple health check handler.
-func HealthHandler(redisClient interface{ IsHealthy(context.Context) bool }) http.HandlerFunc {
+// IndexChecker verifies the cache index matches the expected schema.
+type IndexChecker interface {
+	CheckIndex(ctx context.Context) error
+}
+
+// HealthHandler returns a simple health check handler. redisClient is nil
+// for cache stores that do not use Redis. When index is set the cache index
+// schema is checked as well. The gateway keeps serving requests
+// while degraded, passing them through to upstream, so it still answers 200.
+func HealthHandler(redisClient interface{ IsHealthy(context.Context) bool }, index IndexChecker) http.HandlerFunc {
+	return healthHandler(redisClient, index, http.StatusOK)
+}
+
+// ReadyHandler reports the same status as HealthHandler but answers 503
+// while degraded, for load balancers that should prefer replicas that cache.
+func ReadyHandler(redisClient interface{ IsHealthy(context.Context) bool }, index IndexChecker) http.HandlerFunc {
+	return healthHandler(redisClient, index, http.StatusServiceUnavailable)
+}
+
+// healthHandler reports Redis and index status, answering degradedCode while
+// either is unavailable.
+func healthHandler(redisClient interface{ IsHealthy(context.Context) bool }, index IndexChecker, degradedCode int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
 		status := struct {
-			Status string `json:"status"`
-			Redis  string `json:"redis"`
+			Status  string `json:"status"`
+			Redis   string `json:"redis,omitempty"`
+			Breaker string `json:"breaker,omitempty"`
+			Index   string `json:"index,omitempty"`
 		}{
 			Status: "healthy",
-			Redis:  "connected",
 		}
 
+		if redisClient != nil {
+			status.Redis = "connected"
+		}
 		if redisClient != nil && !redisClient.IsHealthy(ctx) {
 			status.Status = "degraded"
 			status.Redis = "disconnected"
+		} else if index != nil {
+			status.Index = "ok"
+			if err := index.CheckIndex(ctx); err != nil {
+				status.Status = "degraded"
+				status.Index = err.Error()
+			}
+		}
+		if b, ok := redisClient.(interface{ BreakerState() string }); ok {
+			status.Breaker = b.BreakerState()
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if status.Status == "healthy" {
 			w.WriteHeader(http.StatusOK)
 		} else {
-			w.WriteHeader(http.StatusServiceUnavailable)
+			w.WriteHeader(degradedCode)
 		}
 		json.NewEncoder(w).Encode(status)
 	}
@@ -14,51 +14,90 @@ import (
 	"time"
 
 	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/embedding"
+	"semantic-cache-gateway/internal/lexical"
 	"semantic-cache-gateway/internal/logger"
 	"semantic-cache-gateway/internal/middleware"
+	"semantic-cache-gateway/internal/mockllm"
 	"semantic-cache-gateway/internal/models"
+	"semantic-cache-gateway/internal/proxy"
+	"semantic-cache-gateway/internal/templates"
+	"semantic-cache-gateway/internal/verify"
 )
 
 // mockCacheService implements cache.CacheService for testing
 type mockCacheService struct {
-	exactMatchEntry   *cache.CacheEntry
-	exactMatchErr     error
-	similarEntry      *cache.CacheEntry
-	similarScore      float64
-	similarErr        error
-	storedEntries     []*cache.CacheEntry
-	checkExactCalled  bool
+	exactMatchEntry     *cache.CacheEntry
+	exactMatchErr       error
+	similarEntry        *cache.CacheEntry
+	similarScore        float64
+	similarErr          error
+	neighbors           []cache.Neighbor
+	storedEntries       []*cache.CacheEntry
+	checkExactCalled    bool
+	checkedHash         string
+	searchFilter        cache.SearchFilter
 	searchSimilarCalled bool
+	// stored, when set, also receives every entry passed to StoreAsync, so
+	// tests can wait for background writes.
+	stored chan *cache.CacheEntry
+	// touched, when set, receives every entry passed to Touch.
+	touched chan *cache.CacheEntry
 }
 
 func (m *mockCacheService) CheckExactMatch(ctx context.Context, queryHash string) (*cache.CacheEntry, error) {
 	m.checkExactCalled = true
+	m.checkedHash = queryHash
 	return m.exactMatchEntry, m.exactMatchErr
 }
 
-func (m *mockCacheService) SearchSimilar(ctx context.Context, embedding []float32, threshold float64) (*cache.CacheEntry, float64, error) {
+func (m *mockCacheService) SearchSimilar(ctx context.Context, embedding []float32, threshold float64, filter cache.SearchFilter) (*cache.CacheEntry, float64, error) {
 	m.searchSimilarCalled = true
+	m.searchFilter = filter
 	return m.similarEntry, m.similarScore, m.similarErr
 }
 
+func (m *mockCacheService) SearchTopK(ctx context.Context, embedding []float32, k int, filter cache.SearchFilter) ([]cache.Neighbor, error) {
+	if len(m.neighbors) > k {
+		return m.neighbors[:k], m.similarErr
+	}
+	return m.neighbors, m.similarErr
+}
+
 func (m *mockCacheService) StoreAsync(entry *cache.CacheEntry) {
+	if m.stored != nil {
+		m.stored <- entry
+		return
+	}
 	m.storedEntries = append(m.storedEntries, entry)
 }
 
-func (m *mockCacheService) Close() error {
+func (m *mockCacheService) Touch(ctx context.Context, entry *cache.CacheEntry) error {
+	if m.touched != nil {
+		m.touched <- entry
+	}
 	return nil
 }
 
+func (m *mockCacheService) Clear(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockCacheService) Close() error {
+	return nil
+}
 
 // mockEmbeddingService implements embedding.EmbeddingService for testing
 type mockEmbeddingService struct {
 	embedding []float32
 	err       error
 	called    bool
+	text      string
 }
 
 func (m *mockEmbeddingService) Generate(ctx context.Context, text string) ([]float32, error) {
 	m.called = true
+	m.text = text
 	return m.embedding, m.err
 }
 
@@ -77,9 +116,11 @@ func (m *mockUpstreamProxy) Forward(ctx context.Context, req *http.Request) (*ht
 // createTestRequest creates a test HTTP request with a chat completion body
 func createTestRequest(t *testing.T, messages []models.Message) *http.Request {
 	t.Helper()
+	temperature := 0.0
 	body := models.ChatCompletionRequest{
-		Model:    "gpt-4",
-		Messages: messages,
+		Model:       "gpt-4",
+		Messages:    messages,
+		Temperature: &temperature,
 	}
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
@@ -87,7 +128,7 @@ func createTestRequest(t *testing.T, messages []models.Message) *http.Request {
 	}
 	req := httptest.NewRequest(http.MethodPost, "/chat/completions", bytes.NewReader(bodyBytes))
 	req.Header.Set("Content-Type", "application/json")
-	
+
 	// Apply body buffer middleware context
 	ctx := middleware.SetBufferedBody(req.Context(), bodyBytes)
 	req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
@@ -128,13 +169,12 @@ func generateTestEmbedding() []float32 {
 	return embedding
 }
 
-
 // TestIntegration_CacheHit_ExactMatch tests the cache hit scenario with exact hash match.
 // Requirements: 1.1, 4.2, 4.3
 func TestIntegration_CacheHit_ExactMatch(t *testing.T) {
 	// Setup cached response
 	cachedResponse := `{"id":"cached-123","choices":[{"message":{"content":"cached response"}}]}`
-	
+
 	mockCache := &mockCacheService{
 		exactMatchEntry: &cache.CacheEntry{
 			ID:          "cache:test-hash",
@@ -193,13 +233,12 @@ func TestIntegration_CacheHit_ExactMatch(t *testing.T) {
 	}
 }
 
-
 // TestIntegration_CacheHit_SemanticMatch tests the cache hit scenario with vector similarity match.
 // Requirements: 1.1, 4.2, 4.3
 func TestIntegration_CacheHit_SemanticMatch(t *testing.T) {
 	// Setup cached response for semantic match
 	cachedResponse := `{"id":"semantic-123","choices":[{"message":{"content":"semantic cached response"}}]}`
-	
+
 	mockCache := &mockCacheService{
 		exactMatchEntry: nil, // No exact match
 		similarEntry: &cache.CacheEntry{
@@ -265,7 +304,6 @@ func TestIntegration_CacheHit_SemanticMatch(t *testing.T) {
 	}
 }
 
-
 // TestIntegration_CacheMiss tests the cache miss scenario where request is forwarded to upstream.
 // Requirements: 1.1, 4.2, 4.3
 func TestIntegration_CacheMiss(t *testing.T) {
@@ -277,7 +315,7 @@ func TestIntegration_CacheMiss(t *testing.T) {
 	mockEmbed := &mockEmbeddingService{
 		embedding: generateTestEmbedding(),
 	}
-	
+
 	upstreamResponse := createMockLLMResponse("This is the upstream response")
 	mockProxy := &mockUpstreamProxy{
 		response: upstreamResponse,
@@ -334,7 +372,6 @@ func TestIntegration_CacheMiss(t *testing.T) {
 	}
 }
 
-
 // TestIntegration_GracefulDegradation_RedisFailure tests graceful degradation when Redis fails.
 // Requirements: 6.4
 func TestIntegration_GracefulDegradation_RedisFailure(t *testing.T) {
@@ -346,7 +383,7 @@ func TestIntegration_GracefulDegradation_RedisFailure(t *testing.T) {
 	mockEmbed := &mockEmbeddingService{
 		embedding: generateTestEmbedding(),
 	}
-	
+
 	upstreamResponse := createMockLLMResponse("Upstream response when Redis is down")
 	mockProxy := &mockUpstreamProxy{
 		response: upstreamResponse,
@@ -391,7 +428,7 @@ func TestIntegration_GracefulDegradation_EmbeddingFailure(t *testing.T) {
 	mockEmbed := &mockEmbeddingService{
 		err: errors.New("embedding API unavailable"),
 	}
-	
+
 	upstreamResponse := createMockLLMResponse("Upstream response when embedding fails")
 	mockProxy := &mockUpstreamProxy{
 		response: upstreamResponse,
@@ -430,7 +467,6 @@ func TestIntegration_GracefulDegradation_EmbeddingFailure(t *testing.T) {
 	}
 }
 
-
 // TestIntegration_UpstreamError tests error handling when upstream LLM fails.
 // Requirements: 1.4
 func TestIntegration_UpstreamError(t *testing.T) {
@@ -495,7 +531,7 @@ func TestIntegration_InvalidRequest(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	ctx := middleware.SetBufferedBody(req.Context(), invalidBody)
 	req = req.WithContext(ctx)
-	
+
 	rr := httptest.NewRecorder()
 
 	// Execute
@@ -546,7 +582,6 @@ func TestIntegration_NoUserMessages(t *testing.T) {
 	}
 }
 
-
 // TestIntegration_CacheStorageOnMiss tests that cache entries are stored after cache miss.
 // Requirements: 5.1, 5.2, 5.3
 func TestIntegration_CacheStorageOnMiss(t *testing.T) {
@@ -558,7 +593,7 @@ func TestIntegration_CacheStorageOnMiss(t *testing.T) {
 	mockEmbed := &mockEmbeddingService{
 		embedding: testEmbedding,
 	}
-	
+
 	upstreamResponse := createMockLLMResponse("Response to be cached")
 	mockProxy := &mockUpstreamProxy{
 		response: upstreamResponse,
@@ -638,7 +673,7 @@ func TestIntegration_SimilarityThreshold(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cachedResponse := `{"id":"test","choices":[]}`
-			
+
 			var similarEntry *cache.CacheEntry
 			if tt.expectCacheHit {
 				similarEntry = &cache.CacheEntry{
@@ -655,7 +690,7 @@ func TestIntegration_SimilarityThreshold(t *testing.T) {
 			mockEmbed := &mockEmbeddingService{
 				embedding: generateTestEmbedding(),
 			}
-			
+
 			upstreamResponse := createMockLLMResponse("Upstream response")
 			mockProxy := &mockUpstreamProxy{
 				response: upstreamResponse,
@@ -690,3 +725,908 @@ func TestIntegration_SimilarityThreshold(t *testing.T) {
 		})
 	}
 }
+
+// TestIntegration_UncacheableResponseNotStored tests that truncated upstream
+// responses are returned to the client but not written to the cache.
+func TestIntegration_UncacheableResponseNotStored(t *testing.T) {
+	mockCache := &mockCacheService{}
+	mockEmbed := &mockEmbeddingService{
+		embedding: generateTestEmbedding(),
+	}
+	truncated := `{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"Once upon"},"finish_reason":"length"}]}`
+	mockProxy := &mockUpstreamProxy{
+		response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(truncated)),
+			Header:     make(http.Header),
+		},
+	}
+	log := logger.New()
+
+	handler := New(mockCache, mockEmbed, mockProxy, log, nil)
+
+	req := createTestRequest(t, []models.Message{
+		{Role: "user", Content: "Tell me a long story"},
+	})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rr.Code)
+	}
+	if rr.Body.String() != truncated {
+		t.Errorf("response body mismatch: got %s", rr.Body.String())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if len(mockCache.storedEntries) != 0 {
+		t.Errorf("expected truncated response not to be stored, got %d entries", len(mockCache.storedEntries))
+	}
+}
+
+// TestIntegration_StaleWhileRevalidate tests that an expired entry is served
+// immediately as STALE and refreshed from upstream in the background.
+func TestIntegration_StaleWhileRevalidate(t *testing.T) {
+	staleResponse := `{"id":"stale","choices":[{"message":{"content":"old answer"},"finish_reason":"stop"}]}`
+	mockCache := &mockCacheService{
+		exactMatchEntry: &cache.CacheEntry{
+			ID:           "cache:stale-hash",
+			QueryHash:    "sha256:stalehash",
+			QueryText:    "What is the latest version?",
+			Embedding:    generateTestEmbedding(),
+			LLMResponse:  staleResponse,
+			CreatedAt:    time.Now().Add(-48 * time.Hour).Unix(),
+			ExpiresAt:    time.Now().Add(-time.Hour).Unix(),
+			HitCount:     7,
+			FeedbackUp:   2,
+			FeedbackDown: 1,
+		},
+		stored: make(chan *cache.CacheEntry, 1),
+	}
+	mockEmbed := &mockEmbeddingService{}
+	mockProxy := &mockUpstreamProxy{
+		response: createMockLLMResponse("new answer"),
+	}
+	log := logger.New()
+
+	handler := New(mockCache, mockEmbed, mockProxy, log, nil)
+
+	req := createTestRequest(t, []models.Message{
+		{Role: "user", Content: "What is the latest version?"},
+	})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != "STALE" {
+		t.Errorf("expected X-Cache-Status STALE, got %s", cacheStatus)
+	}
+	if rr.Body.String() != staleResponse {
+		t.Errorf("expected stale body to be served, got %s", rr.Body.String())
+	}
+
+	// Wait for background revalidation
+	var refreshed *cache.CacheEntry
+	select {
+	case refreshed = <-mockCache.stored:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stale entry to be revalidated and stored")
+	}
+
+	if !mockProxy.called {
+		t.Fatal("expected upstream to be called to revalidate the stale entry")
+	}
+	if refreshed.HitCount != 7 || refreshed.FeedbackUp != 2 || refreshed.FeedbackDown != 1 {
+		t.Errorf("expected hit and feedback counts to be kept, got hits=%d up=%d down=%d",
+			refreshed.HitCount, refreshed.FeedbackUp, refreshed.FeedbackDown)
+	}
+	if refreshed.ID != "cache:stale-hash" {
+		t.Errorf("expected refreshed entry to replace %s, got %s", "cache:stale-hash", refreshed.ID)
+	}
+	if !strings.Contains(refreshed.LLMResponse, "new answer") {
+		t.Errorf("expected refreshed entry to hold the new response, got %s", refreshed.LLMResponse)
+	}
+}
+
+// TestIntegration_StaleSemanticHit verifies a stale semantic hit is served
+// as STALE but not revalidated, since the request is a paraphrase of the
+// entry's query.
+func TestIntegration_StaleSemanticHit(t *testing.T) {
+	mockCache := &mockCacheService{
+		similarEntry: &cache.CacheEntry{
+			ID:          "cache:stale-hash",
+			QueryHash:   "sha256:stalehash",
+			QueryText:   "What is the latest version?",
+			Embedding:   generateTestEmbedding(),
+			LLMResponse: `{"id":"stale","choices":[{"message":{"content":"old answer"},"finish_reason":"stop"}]}`,
+			ExpiresAt:   time.Now().Add(-time.Hour).Unix(),
+		},
+		similarScore: 0.97,
+		stored:       make(chan *cache.CacheEntry, 1),
+	}
+	mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("new answer")}
+	handler := New(mockCache, &mockEmbeddingService{}, mockProxy, logger.New(), nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: "Which version is the newest?"}}))
+
+	if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != "STALE" {
+		t.Errorf("expected X-Cache-Status STALE, got %s", cacheStatus)
+	}
+	select {
+	case entry := <-mockCache.stored:
+		t.Errorf("expected no revalidation of a semantic hit, got %s stored", entry.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestIntegration_MockLLMStack runs the handler against the real embedding
+// client and upstream proxy, both pointed at the offline mock LLM server.
+func TestIntegration_MockLLMStack(t *testing.T) {
+	cfg := mockllm.DefaultConfig()
+	cfg.Dimensions = 128
+	cfg.CompletionLatency = 0
+	cfg.EmbeddingLatency = 0
+	server := httptest.NewServer(mockllm.New(cfg))
+	defer server.Close()
+
+	embedSvc := embedding.NewService(embedding.Config{
+		APIEndpoint: server.URL + "/v1/embeddings",
+		ModelName:   "mock-embedding",
+		Dimensions:  cfg.Dimensions,
+	})
+	upstream, err := proxy.New(proxy.ProxyConfig{UpstreamURL: server.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	mockCache := &mockCacheService{}
+	handler := New(mockCache, embedSvc, upstream, logger.New(), nil)
+
+	req := createTestRequest(t, []models.Message{
+		{Role: "user", Content: "Why is the sky blue?"},
+	})
+	req.URL.Path = "/v1/chat/completions"
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != "MISS" {
+		t.Errorf("expected X-Cache-Status MISS, got %s", cacheStatus)
+	}
+	if !strings.Contains(rr.Body.String(), "Why is the sky blue?") {
+		t.Errorf("expected mock answer in body, got %s", rr.Body.String())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if len(mockCache.storedEntries) != 1 {
+		t.Fatalf("expected 1 stored entry, got %d", len(mockCache.storedEntries))
+	}
+	if dims := len(mockCache.storedEntries[0].Embedding); dims != cfg.Dimensions {
+		t.Errorf("expected %d-dimension embedding, got %d", cfg.Dimensions, dims)
+	}
+}
+
+// TestIntegration_ExplainHeader tests that X-Cache-Explain reports the
+// neighbors and decision without calling upstream.
+func TestIntegration_ExplainHeader(t *testing.T) {
+	mockCache := &mockCacheService{
+		neighbors: []cache.Neighbor{
+			{Entry: &cache.CacheEntry{ID: "cache:a", QueryText: "What's the capital of France?"}, Similarity: 0.97},
+			{Entry: &cache.CacheEntry{ID: "cache:b", QueryText: "What's the capital of Germany?"}, Similarity: 0.91},
+		},
+	}
+	mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+	mockProxy := &mockUpstreamProxy{}
+	log := logger.New()
+
+	handler := New(mockCache, mockEmbed, mockProxy, log, &Config{SimilarityThreshold: 0.95, ExplainHeader: true, AdminToken: "secret"})
+
+	// Explanations show other clients' queries, so the admin token is required
+	for _, token := range []string{"", "guess"} {
+		req := createTestRequest(t, []models.Message{{Role: "user", Content: "What is the capital of France?"}})
+		req.Header.Set(ExplainHeader, "1")
+		if token != "" {
+			req.Header.Set(AdminTokenHeader, token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized || strings.Contains(rr.Body.String(), "Germany") {
+			t.Errorf("token %q: expected 401 without neighbors, got %d %s", token, rr.Code, rr.Body.String())
+		}
+	}
+	if mockProxy.called {
+		t.Error("expected rejected explain requests not to be forwarded upstream")
+	}
+
+	req := createTestRequest(t, []models.Message{
+		{Role: "user", Content: "What is the capital of France?"},
+	})
+	req.Header.Set(ExplainHeader, "1")
+	req.Header.Set(AdminTokenHeader, "secret")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if mockProxy.called {
+		t.Error("expected upstream not to be called in explain mode")
+	}
+
+	var exp Explanation
+	if err := json.Unmarshal(rr.Body.Bytes(), &exp); err != nil {
+		t.Fatalf("invalid explanation: %v", err)
+	}
+	if exp.QueryText != "What is the capital of France?" || exp.QueryHash == "" {
+		t.Errorf("unexpected query fields: %+v", exp)
+	}
+	if exp.Decision != DecisionSemanticHit {
+		t.Errorf("expected decision %s, got %s", DecisionSemanticHit, exp.Decision)
+	}
+	if len(exp.Neighbors) != 2 || !exp.Neighbors[0].AboveThreshold || exp.Neighbors[1].AboveThreshold {
+		t.Errorf("unexpected neighbors: %+v", exp.Neighbors)
+	}
+	if exp.Neighbors[1].QueryText != "What's the capital of Germany?" {
+		t.Errorf("expected neighbor query text, got %q", exp.Neighbors[1].QueryText)
+	}
+
+	// Without the option the header is ignored
+	mockProxy.response = createMockLLMResponse("answer")
+	handler = New(mockCache, mockEmbed, mockProxy, log, &Config{SimilarityThreshold: 0.99})
+	req = createTestRequest(t, []models.Message{
+		{Role: "user", Content: "What is the capital of France?"},
+	})
+	req.Header.Set(ExplainHeader, "1")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if !mockProxy.called {
+		t.Error("expected X-Cache-Explain to be ignored when explain mode is disabled")
+	}
+}
+
+// TestIntegration_ExplainEndpoint tests /debug/explain, including degraded lookups.
+func TestIntegration_ExplainEndpoint(t *testing.T) {
+	mockCache := &mockCacheService{
+		exactMatchEntry: &cache.CacheEntry{ID: "cache:exact", QueryText: "Hello", HitCount: 3},
+		similarErr:      errors.New("index unavailable"),
+	}
+	mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+	mockProxy := &mockUpstreamProxy{}
+	log := logger.New()
+
+	handler := New(mockCache, mockEmbed, mockProxy, log, nil)
+	explain := handler.ExplainHandler()
+
+	body, _ := json.Marshal(models.ChatCompletionRequest{
+		Model:    "gpt-4",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+	rr := httptest.NewRecorder()
+	explain.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/debug/explain?k=3", bytes.NewReader(body)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var exp Explanation
+	if err := json.Unmarshal(rr.Body.Bytes(), &exp); err != nil {
+		t.Fatalf("invalid explanation: %v", err)
+	}
+	if exp.Decision != DecisionExactHit || exp.ExactMatch == nil || exp.ExactMatch.HitCount != 3 {
+		t.Errorf("expected exact hit with entry details, got %+v", exp)
+	}
+	if len(exp.Errors) != 1 || !strings.Contains(exp.Errors[0], "index unavailable") {
+		t.Errorf("expected search error to be reported, got %v", exp.Errors)
+	}
+	if mockProxy.called {
+		t.Error("expected upstream not to be called")
+	}
+
+	rr = httptest.NewRecorder()
+	explain.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/debug/explain?k=0", bytes.NewReader(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid k, got %d", rr.Code)
+	}
+}
+
+// TestIntegration_ShadowMode tests that semantic hits are forwarded upstream
+// in shadow mode and the cached answer is compared with the fresh one.
+func TestIntegration_ShadowMode(t *testing.T) {
+	ResetStats()
+	defer ResetStats()
+
+	cachedResponse, _ := io.ReadAll(createMockLLMResponse("Paris is the capital of France.").Body)
+	mockCache := &mockCacheService{
+		similarEntry: &cache.CacheEntry{
+			ID:          "cache:similar",
+			QueryText:   "What's the capital of France?",
+			LLMResponse: string(cachedResponse),
+		},
+		similarScore: 0.964,
+	}
+	mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+	mockProxy := &mockUpstreamProxy{
+		response: createMockLLMResponse("paris is the capital of france"),
+	}
+	log := logger.New()
+
+	handler := New(mockCache, mockEmbed, mockProxy, log, &Config{SimilarityThreshold: 0.9, ShadowMode: true})
+
+	req := createTestRequest(t, []models.Message{
+		{Role: "user", Content: "What is the capital of France?"},
+	})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if !mockProxy.called {
+		t.Fatal("expected upstream to be called in shadow mode")
+	}
+	if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != "MISS" {
+		t.Errorf("expected X-Cache-Status MISS, got %s", cacheStatus)
+	}
+	if !strings.Contains(rr.Body.String(), "paris is the capital of france") {
+		t.Errorf("expected the fresh answer to be served, got %s", rr.Body.String())
+	}
+
+	// Wait for the background comparison
+	var stats Stats
+	for i := 0; i < 50; i++ {
+		if stats = GetStats(); len(stats.Shadow) > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats.ShadowHits != 1 {
+		t.Errorf("expected 1 shadow hit, got %d", stats.ShadowHits)
+	}
+	if len(stats.Shadow) != 1 {
+		t.Fatalf("expected 1 shadow bucket, got %d", len(stats.Shadow))
+	}
+	bucket := stats.Shadow[0]
+	if bucket.MinSimilarity != 0.96 || bucket.Comparisons != 1 {
+		t.Errorf("unexpected bucket: %+v", bucket)
+	}
+	if bucket.ExactAgreements != 0 || bucket.NormalizedAgreements != 1 || bucket.SemanticRate != 1 {
+		t.Errorf("expected normalized agreement only, got %+v", bucket)
+	}
+}
+
+// mockFeedbackStore implements FeedbackStore for testing
+type mockFeedbackStore struct {
+	counts  map[string]cache.FeedbackCounts
+	deleted []string
+}
+
+func (m *mockFeedbackStore) RecordFeedback(ctx context.Context, id string, positive bool) (cache.FeedbackCounts, error) {
+	c := m.counts[id]
+	if positive {
+		c.Up++
+	} else {
+		c.Down++
+	}
+	m.counts[id] = c
+	return c, nil
+}
+
+func (m *mockFeedbackStore) Delete(ctx context.Context, ids ...string) (int64, error) {
+	m.deleted = append(m.deleted, ids...)
+	return int64(len(ids)), nil
+}
+
+// TestIntegration_Feedback tests that complaints about served hits are
+// recorded against the entry and delete it at the complaint limit.
+func TestIntegration_Feedback(t *testing.T) {
+	cachedResponse, _ := io.ReadAll(createMockLLMResponse("Paris").Body)
+	mockCache := &mockCacheService{
+		exactMatchEntry: &cache.CacheEntry{ID: "cache:exact", LLMResponse: string(cachedResponse)},
+	}
+	store := &mockFeedbackStore{counts: map[string]cache.FeedbackCounts{}}
+	log := logger.New()
+
+	handler := New(mockCache, &mockEmbeddingService{}, &mockUpstreamProxy{}, log, &Config{
+		SimilarityThreshold:      0.9,
+		FeedbackWindow:           time.Minute,
+		FeedbackMaxComplaints:    2,
+		FeedbackThresholdPenalty: 0.02,
+	})
+	feedback := handler.FeedbackHandler(store)
+
+	rate := func(requestID, rating string) (*httptest.ResponseRecorder, FeedbackResponse) {
+		body := `{"request_id": "` + requestID + `", "rating": "` + rating + `"}`
+		rr := httptest.NewRecorder()
+		feedback(rr, httptest.NewRequest(http.MethodPost, "/feedback", strings.NewReader(body)))
+		var resp FeedbackResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+	serveHit := func() string {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: "Capital of France?"}}))
+		return rr.Header().Get("X-Request-ID")
+	}
+
+	if rr, _ := rate("unknown", "down"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown request ID, got %d", rr.Code)
+	}
+	if rr, _ := rate(serveHit(), "meh"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid rating, got %d", rr.Code)
+	}
+
+	requestID := serveHit()
+	rr, resp := rate(requestID, "down")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if resp.EntryID != "cache:exact" || resp.Down != 1 || resp.Deleted {
+		t.Errorf("unexpected response after first complaint: %+v", resp)
+	}
+	if resp.EffectiveThreshold < 0.919 || resp.EffectiveThreshold > 0.921 {
+		t.Errorf("expected effective threshold 0.92, got %v", resp.EffectiveThreshold)
+	}
+	if rr, _ := rate(requestID, "down"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected a request ID to be rated only once, got %d", rr.Code)
+	}
+
+	_, resp = rate(serveHit(), "down")
+	if !resp.Deleted || len(store.deleted) != 1 || store.deleted[0] != "cache:exact" {
+		t.Errorf("expected entry deleted at the complaint limit, got %+v (deleted %v)", resp, store.deleted)
+	}
+}
+
+// TestIntegration_FeedbackDemotesSemanticHit tests that net complaints raise
+// an entry's threshold above an otherwise matching similarity.
+func TestIntegration_FeedbackDemotesSemanticHit(t *testing.T) {
+	mockCache := &mockCacheService{
+		similarEntry: &cache.CacheEntry{
+			ID:           "cache:similar",
+			LLMResponse:  `{"cached": true}`,
+			FeedbackDown: 3,
+		},
+		similarScore: 0.95,
+	}
+	mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+	mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("fresh answer")}
+	log := logger.New()
+
+	handler := New(mockCache, mockEmbed, mockProxy, log, &Config{
+		SimilarityThreshold:      0.9,
+		FeedbackWindow:           time.Minute,
+		FeedbackThresholdPenalty: 0.02,
+	})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: "Capital of France?"}}))
+
+	if !mockProxy.called {
+		t.Error("expected demoted hit to be forwarded upstream")
+	}
+	if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != "MISS" {
+		t.Errorf("expected X-Cache-Status MISS, got %s", cacheStatus)
+	}
+}
+
+// TestIntegration_QueryNormalization tests that trivial variants share a hash
+// and embedding text while the original query is stored for display.
+func TestIntegration_QueryNormalization(t *testing.T) {
+	normalizer, err := models.NewNormalizer(models.NormalizeConfig{Steps: []string{
+		models.NormalizeNFKC, models.NormalizeCaseFold, models.NormalizePunctuation, models.NormalizeWhitespace,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logger.New()
+
+	var hashes []string
+	for _, query := range []string{"What is X?", "  what is x ?"} {
+		mockCache := &mockCacheService{}
+		mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+		mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("X is a letter.")}
+		handler := New(mockCache, mockEmbed, mockProxy, log, &Config{Normalizer: normalizer})
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: query}}))
+		time.Sleep(50 * time.Millisecond)
+
+		if mockEmbed.text != "what is x" {
+			t.Errorf("expected normalized text to be embedded, got %q", mockEmbed.text)
+		}
+		if len(mockCache.storedEntries) != 1 {
+			t.Fatalf("expected 1 stored entry, got %d", len(mockCache.storedEntries))
+		}
+		stored := mockCache.storedEntries[0]
+		if stored.QueryText != query {
+			t.Errorf("expected original query text %q to be stored, got %q", query, stored.QueryText)
+		}
+		if stored.QueryHash != mockCache.checkedHash {
+			t.Errorf("stored hash %s differs from lookup hash %s", stored.QueryHash, mockCache.checkedHash)
+		}
+		hashes = append(hashes, mockCache.checkedHash)
+	}
+	if hashes[0] != hashes[1] || hashes[0] != models.ComputeQueryHash("what is x") {
+		t.Errorf("expected variants to share the normalized hash, got %v", hashes)
+	}
+}
+
+// TestIntegration_PromptTemplates tests that templated prompts are keyed by
+// their variables and only their meaningful part is embedded.
+func TestIntegration_PromptTemplates(t *testing.T) {
+	set, err := templates.New([]templates.Template{
+		{ID: "doc-qa", Markers: []templates.Marker{{Name: "document", Start: "<document>", End: "</document>"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logger.New()
+
+	run := func(mockCache *mockCacheService, query string) (*httptest.ResponseRecorder, *mockEmbeddingService) {
+		mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+		mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("answer")}
+		handler := New(mockCache, mockEmbed, mockProxy, log, &Config{Templates: set})
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: query}}))
+		return rr, mockEmbed
+	}
+
+	docA := &mockCacheService{}
+	_, embed := run(docA, "<document>Report A</document> What is the total?")
+	docB := &mockCacheService{}
+	run(docB, "<document>Report B</document> What is the total?")
+
+	if strings.Contains(embed.text, "Report") {
+		t.Errorf("expected only the meaningful part to be embedded, got %q", embed.text)
+	}
+	if docA.checkedHash == docB.checkedHash {
+		t.Error("expected different documents to have different hashes")
+	}
+	if docA.searchFilter.TemplateKey == "" || docA.searchFilter.TemplateKey == docB.searchFilter.TemplateKey {
+		t.Errorf("expected per-document template keys, got %q and %q", docA.searchFilter.TemplateKey, docB.searchFilter.TemplateKey)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(docA.storedEntries) != 1 || docA.storedEntries[0].TemplateKey != docA.searchFilter.TemplateKey {
+		t.Errorf("expected the stored entry to carry the template key")
+	}
+
+	// An untemplated prompt must not be answered from a templated entry
+	untemplated := &mockCacheService{
+		similarEntry: &cache.CacheEntry{ID: "cache:templated", LLMResponse: "{}", TemplateKey: docA.searchFilter.TemplateKey},
+		similarScore: 0.99,
+	}
+	rr, _ := run(untemplated, "What is the total?")
+	if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != "MISS" {
+		t.Errorf("expected X-Cache-Status MISS, got %s", cacheStatus)
+	}
+}
+
+// TestIntegration_PartitionedSearch tests that partition attributes scope the
+// hash and pre-filter vector search, and are stored on new entries.
+func TestIntegration_PartitionedSearch(t *testing.T) {
+	log := logger.New()
+	run := func(tenant string) *mockCacheService {
+		mockCache := &mockCacheService{}
+		mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+		mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("answer")}
+		handler := New(mockCache, mockEmbed, mockProxy, log, &Config{
+			PartitionBy:  []string{cache.PartitionTenant, cache.PartitionModel},
+			SearchMaxAge: time.Hour,
+		})
+		req := createTestRequest(t, []models.Message{{Role: "user", Content: "What is our refund policy?"}})
+		if tenant != "" {
+			req.Header.Set(DefaultTenantHeader, tenant)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		time.Sleep(50 * time.Millisecond)
+		return mockCache
+	}
+
+	acme, globex, none := run("acme"), run("globex"), run("")
+	if acme.checkedHash == globex.checkedHash {
+		t.Error("expected tenants to have different hashes")
+	}
+	if acme.searchFilter.Tenant != "acme" || none.searchFilter.Tenant != "default" {
+		t.Errorf("unexpected tenant filters %q and %q", acme.searchFilter.Tenant, none.searchFilter.Tenant)
+	}
+	if acme.searchFilter.Model == "" || acme.searchFilter.Language != "" {
+		t.Errorf("expected only the configured partitions in the filter, got %+v", acme.searchFilter)
+	}
+	if minAge := time.Now().Add(-time.Hour).Unix(); acme.searchFilter.MinCreatedAt < minAge-5 || acme.searchFilter.MinCreatedAt > minAge+5 {
+		t.Errorf("expected min created_at near %d, got %d", minAge, acme.searchFilter.MinCreatedAt)
+	}
+	if len(acme.storedEntries) != 1 {
+		t.Fatalf("expected 1 stored entry, got %d", len(acme.storedEntries))
+	}
+	if e := acme.storedEntries[0]; e.Tenant != "acme" || e.Language != "en" || e.SystemHash == "" {
+		t.Errorf("expected request attributes on the stored entry, got %+v", e)
+	}
+}
+
+// TestIntegration_LexicalGuard tests that a close semantic match with a
+// different number is not served.
+func TestIntegration_LexicalGuard(t *testing.T) {
+	ResetStats()
+	defer ResetStats()
+
+	guard, err := lexical.New([]string{lexical.CheckNumbers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockCache := &mockCacheService{
+		similarEntry: &cache.CacheEntry{ID: "cache:2023", QueryText: "What was revenue in 2023?", LLMResponse: "{}"},
+		similarScore: 0.98,
+	}
+	mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+	mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("fresh")}
+	handler := New(mockCache, mockEmbed, mockProxy, logger.New(), &Config{SimilarityThreshold: 0.9, LexicalGuard: guard})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: "What was revenue in 2024?"}}))
+
+	if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != "MISS" {
+		t.Errorf("expected X-Cache-Status MISS, got %s", cacheStatus)
+	}
+	if rejects := GetStats().LexicalRejects; rejects != 1 {
+		t.Errorf("expected 1 lexical reject, got %d", rejects)
+	}
+}
+
+// TestIntegration_HitVerifier verifies gray-zone hits are served only when the
+// verifier agrees, while hits above the high threshold skip it.
+func TestIntegration_HitVerifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		similarity float64
+		verdict    bool
+		wantStatus string
+		wantCalls  int64
+	}{
+		{"above high threshold", 0.99, false, "HIT", 0},
+		{"gray zone accepted", 0.93, true, "HIT", 1},
+		{"gray zone rejected", 0.93, false, "MISS", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResetStats()
+			defer ResetStats()
+
+			mockCache := &mockCacheService{
+				similarEntry: &cache.CacheEntry{ID: "cache:capital", QueryText: "What is the capital of France?", LLMResponse: `{"id":"cached-capital","choices":[{"message":{"content":"Paris"}}]}`},
+				similarScore: tt.similarity,
+			}
+			mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+			mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("fresh")}
+			stub := &verify.Stub{Default: tt.verdict}
+			handler := New(mockCache, mockEmbed, mockProxy, logger.New(), &Config{
+				SimilarityThreshold: 0.9,
+				Verifier:            stub,
+				VerifyHighThreshold: 0.97,
+			})
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: "France's capital city?"}}))
+
+			if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != tt.wantStatus {
+				t.Errorf("expected X-Cache-Status %s, got %s", tt.wantStatus, cacheStatus)
+			}
+			if stub.Calls() != tt.wantCalls {
+				t.Errorf("expected %d verifier calls, got %d", tt.wantCalls, stub.Calls())
+			}
+			stats := GetStats()
+			if tt.wantCalls > 0 && tt.verdict && stats.VerifiedHits != 1 {
+				t.Errorf("expected 1 verified hit, got %d", stats.VerifiedHits)
+			}
+			if tt.wantCalls > 0 && !tt.verdict && stats.VerifierRejects != 1 {
+				t.Errorf("expected 1 verifier reject, got %d", stats.VerifierRejects)
+			}
+		})
+	}
+}
+
+// mockPreviousIndex implements PreviousIndex for testing
+type mockPreviousIndex struct {
+	active    bool
+	entry     *cache.CacheEntry
+	score     float64
+	embedding []float32
+}
+
+func (m *mockPreviousIndex) Active() bool { return m.active }
+
+func (m *mockPreviousIndex) SearchSimilar(ctx context.Context, embedding []float32, threshold float64, filter cache.SearchFilter) (*cache.CacheEntry, float64, error) {
+	m.embedding = embedding
+	return m.entry, m.score, nil
+}
+
+// TestIntegration_PreviousEmbeddingSpace verifies entries not yet migrated are
+// served from the previous index, searched with the previous model, until the
+// migration finishes.
+func TestIntegration_PreviousEmbeddingSpace(t *testing.T) {
+	for _, active := range []bool{true, false} {
+		mockCache := &mockCacheService{}
+		mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+		previousEmbed := &mockEmbeddingService{embedding: []float32{0.5, 0.5}}
+		previousIndex := &mockPreviousIndex{
+			active: active,
+			entry:  &cache.CacheEntry{ID: "cache:old", QueryText: "What is Go?", LLMResponse: `{"id":"cached-old","choices":[{"message":{"content":"A language"}}]}`},
+			score:  0.97,
+		}
+		mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("fresh")}
+		handler := New(mockCache, mockEmbed, mockProxy, logger.New(), &Config{
+			SimilarityThreshold: 0.9,
+			PreviousSpace:       &PreviousSpace{Index: previousIndex, Embedding: previousEmbed},
+		})
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: "what is go"}}))
+
+		want := "MISS"
+		if active {
+			want = "HIT"
+		}
+		if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != want {
+			t.Errorf("active=%v: expected X-Cache-Status %s, got %s", active, want, cacheStatus)
+		}
+		if active && (!previousEmbed.called || len(previousIndex.embedding) != 2) {
+			t.Error("expected the previous index to be searched with the previous model's embedding")
+		}
+		if !active && previousEmbed.called {
+			t.Error("expected no previous space lookup after the migration finished")
+		}
+	}
+}
+
+// mockHealth reports fixed Redis and index health.
+type mockHealth struct {
+	healthy  bool
+	indexErr error
+}
+
+func (m *mockHealth) IsHealthy(ctx context.Context) bool { return m.healthy }
+
+func (m *mockHealth) CheckIndex(ctx context.Context) error { return m.indexErr }
+
+// TestIntegration_HealthIndexCheck verifies /health and /ready report a cache
+// index that no longer matches the expected schema, and that only /ready
+// fails while the gateway passes requests through.
+func TestIntegration_HealthIndexCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		health    *mockHealth
+		wantReady int
+		wantBody  string
+		wantIndex string
+	}{
+		{"healthy", &mockHealth{healthy: true}, http.StatusOK, "healthy", "ok"},
+		{"index mismatch", &mockHealth{healthy: true, indexErr: errors.New("index cache_idx has 768 dimensions, not 1536")}, http.StatusServiceUnavailable, "degraded", "index cache_idx has 768 dimensions, not 1536"},
+		{"redis down", &mockHealth{indexErr: errors.New("FT.INFO failed")}, http.StatusServiceUnavailable, "degraded", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, endpoint := range []struct {
+				path     string
+				handler  http.HandlerFunc
+				wantCode int
+			}{
+				{"/health", HealthHandler(tt.health, tt.health), http.StatusOK},
+				{"/ready", ReadyHandler(tt.health, tt.health), tt.wantReady},
+			} {
+				rr := httptest.NewRecorder()
+				endpoint.handler(rr, httptest.NewRequest(http.MethodGet, endpoint.path, nil))
+
+				var body struct {
+					Status string `json:"status"`
+					Index  string `json:"index"`
+				}
+				if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if rr.Code != endpoint.wantCode || body.Status != tt.wantBody || body.Index != tt.wantIndex {
+					t.Errorf("%s: got %d %s with index %q, want %d %s with index %q", endpoint.path, rr.Code, body.Status, body.Index, endpoint.wantCode, tt.wantBody, tt.wantIndex)
+				}
+			}
+		})
+	}
+}
+
+// TestIntegration_HealthWithoutRedis verifies /health omits Redis for cache
+// stores that do not use it.
+func TestIntegration_HealthWithoutRedis(t *testing.T) {
+	rr := httptest.NewRecorder()
+	HealthHandler(nil, &mockHealth{})(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var body map[string]string
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rr.Code != http.StatusOK || body["status"] != "healthy" {
+		t.Errorf("got %d with status %q, want 200 healthy", rr.Code, body["status"])
+	}
+	if _, ok := body["redis"]; ok {
+		t.Errorf("expected no redis field, got %q", body["redis"])
+	}
+}
+
+// TestIntegration_RedisUnavailable verifies requests pass straight through to
+// upstream, without an embedding, while the Redis circuit breaker is open.
+func TestIntegration_RedisUnavailable(t *testing.T) {
+	ResetStats()
+	mockCache := &mockCacheService{exactMatchErr: cache.ErrUnavailable}
+	mockEmbed := &mockEmbeddingService{embedding: generateTestEmbedding()}
+	mockProxy := &mockUpstreamProxy{response: createMockLLMResponse("upstream")}
+	handler := New(mockCache, mockEmbed, mockProxy, logger.New(), nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: "Is Redis up?"}}))
+
+	if rr.Code != http.StatusOK || !mockProxy.called {
+		t.Fatalf("expected the request to be passed through, got %d", rr.Code)
+	}
+	if cacheStatus := rr.Header().Get("X-Cache-Status"); cacheStatus != "MISS" {
+		t.Errorf("expected X-Cache-Status MISS, got %s", cacheStatus)
+	}
+	if mockEmbed.called {
+		t.Error("expected no embedding while the cache is unavailable")
+	}
+	if GetStats().CacheBypasses != 1 {
+		t.Errorf("CacheBypasses = %d, want 1", GetStats().CacheBypasses)
+	}
+}
+
+// TestIntegration_CacheTier verifies X-Cache-Tier and the per-tier hit
+// counters, with entries of unknown tier counted as Redis hits, and that
+// only Redis hits are touched.
+func TestIntegration_CacheTier(t *testing.T) {
+	for _, tt := range []struct {
+		tier     string
+		wantTier string
+	}{
+		{cache.TierL1, cache.TierL1},
+		{cache.TierL2, cache.TierL2},
+		{"", cache.TierL2},
+	} {
+		ResetStats()
+		mockCache := &mockCacheService{
+			exactMatchEntry: &cache.CacheEntry{
+				ID:          "cache:tier",
+				LLMResponse: `{"id":"cached-tier","choices":[{"message":{"content":"cached"}}]}`,
+				CreatedAt:   time.Now().Unix(),
+				Tier:        tt.tier,
+			},
+			touched: make(chan *cache.CacheEntry, 1),
+		}
+		handler := New(mockCache, &mockEmbeddingService{}, &mockUpstreamProxy{}, logger.New(), nil)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, createTestRequest(t, []models.Message{{Role: "user", Content: "Which tier?"}}))
+
+		if got := rr.Header().Get("X-Cache-Tier"); got != tt.wantTier {
+			t.Errorf("tier %q: expected X-Cache-Tier %s, got %s", tt.tier, tt.wantTier, got)
+		}
+		stats := GetStats()
+		wantL1 := int64(0)
+		if tt.wantTier == cache.TierL1 {
+			wantL1 = 1
+		}
+		if stats.L1Hits != wantL1 || stats.L1Hits+stats.L2Hits != 1 {
+			t.Errorf("tier %q: L1Hits = %d, L2Hits = %d", tt.tier, stats.L1Hits, stats.L2Hits)
+		}
+
+		wait := time.Second
+		if tt.wantTier == cache.TierL1 {
+			wait = 50 * time.Millisecond
+		}
+		select {
+		case <-mockCache.touched:
+			if tt.wantTier == cache.TierL1 {
+				t.Errorf("tier %q: expected an L1 hit not to be touched", tt.tier)
+			}
+		case <-time.After(wait):
+			if tt.wantTier != cache.TierL1 {
+				t.Errorf("tier %q: expected the hit to be touched", tt.tier)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/embedding"
+	"semantic-cache-gateway/internal/logger"
+)
+
+// PreviousIndex searches the entries an embedding migration has not moved
+// to the current embedding space yet. cache.Migration implements it.
+type PreviousIndex interface {
+	Active() bool
+	SearchSimilar(ctx context.Context, embedding []float32, threshold float64, filter cache.SearchFilter) (*cache.CacheEntry, float64, error)
+}
+
+// PreviousSpace serves semantic hits from the previous embedding space while
+// its entries are migrated.
+type PreviousSpace struct {
+	Index PreviousIndex
+	// Embedding embeds queries with the previous model. Nil reuses the
+	// current embedding, for migrations that only tag entries.
+	Embedding embedding.EmbeddingService
+}
+
+// active reports whether the previous space still holds unmigrated entries.
+func (p *PreviousSpace) active() bool {
+	return p != nil && p.Index != nil && p.Index.Active()
+}
+
+// searchPreviousSpace looks for a semantic hit among entries not migrated
+// yet. Failures are logged and reported as no match.
+func (h *CacheHandler) searchPreviousSpace(
+	ctx context.Context,
+	query *cacheQuery,
+	current []float32,
+	log *logger.Logger,
+) (*cache.CacheEntry, float64) {
+	vec, err := h.previousVector(ctx, query, current)
+	if err != nil {
+		log.Error("previous space embedding failed", "error", err.Error())
+		return nil, 0
+	}
+
+	entry, similarity, err := h.previous.Index.SearchSimilar(ctx, vec, h.threshold, query.filter())
+	if err != nil {
+		log.Error("previous space search failed", "error", err.Error())
+		return nil, 0
+	}
+	if entry != nil {
+		log.Info("semantic match in previous embedding space", "cache_key", entry.ID, "similarity", similarity)
+	}
+	return entry, similarity
+}
+
+// previousVector embeds the query for the previous space.
+func (h *CacheHandler) previousVector(ctx context.Context, query *cacheQuery, current []float32) ([]float32, error) {
+	if h.previous.Embedding == nil {
+		return current, nil
+	}
+	return h.previous.Embedding.Generate(ctx, query.Lookup)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/models"
+	"semantic-cache-gateway/internal/templates"
+)
+
+// DefaultTenantHeader is the request header that names the tenant.
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// Attribute values for requests that do not provide one, so every entry can
+// be matched by a partition filter.
+const (
+	defaultTenant    = "default"
+	unknownAttribute = "unknown"
+)
+
+// cacheQuery is a request's query as the cache sees it.
+type cacheQuery struct {
+	// Text is the original user text, stored for display.
+	Text string
+	// Lookup is the meaningful part of Text after normalization. It is hashed and embedded.
+	Lookup string
+	Hash   string
+	// TemplateID and TemplateKey are set when the query matched a prompt template.
+	TemplateID  string
+	TemplateKey string
+
+	// Attributes of the request, stored on new entries.
+	Model      string
+	Tenant     string
+	SystemHash string
+	Language   string
+
+	// partition holds the attributes the cache is partitioned by.
+	partition cache.SearchFilter
+	// minCreatedAt excludes older entries from vector search when non-zero.
+	minCreatedAt int64
+}
+
+// filter restricts vector search to entries the query may be answered from.
+func (q *cacheQuery) filter() cache.SearchFilter {
+	f := q.partition
+	f.TemplateKey = q.TemplateKey
+	f.MinCreatedAt = q.minCreatedAt
+	return f
+}
+
+// matches reports whether entry was stored for the same template, variables
+// and partition. Entries from templated prompts never answer untemplated
+// ones, and vice versa.
+func (q *cacheQuery) matches(entry *cache.CacheEntry) bool {
+	p := q.partition
+	return entry.TemplateKey == q.TemplateKey &&
+		(p.Model == "" || entry.Model == p.Model) &&
+		(p.Tenant == "" || entry.Tenant == p.Tenant) &&
+		(p.SystemHash == "" || entry.SystemHash == p.SystemHash) &&
+		(p.Language == "" || entry.Language == p.Language)
+}
+
+// newQuery builds the cache view of a request. The hash covers the template
+// key and the partition attributes, so those must match exactly.
+func (h *CacheHandler) newQuery(r *http.Request, chatReq *models.ChatCompletionRequest, queryText string) *cacheQuery {
+	systemSum := sha256.Sum256([]byte(models.ExtractSystemText(chatReq)))
+	q := &cacheQuery{
+		Text:       queryText,
+		Model:      orDefault(chatReq.Model, unknownAttribute),
+		Tenant:     orDefault(r.Header.Get(h.tenantHeader), defaultTenant),
+		SystemHash: hex.EncodeToString(systemSum[:]),
+		Language:   orDefault(models.DetectLanguage(queryText), unknownAttribute),
+	}
+	if h.searchMaxAge > 0 {
+		q.minCreatedAt = time.Now().Add(-h.searchMaxAge).Unix()
+	}
+
+	var match *templates.Match
+	q.Lookup, match = LookupText(h.normalizer, h.templates, queryText)
+	var scope []string
+	if match != nil {
+		q.TemplateID, q.TemplateKey = match.TemplateID, match.Key()
+		scope = append(scope, q.TemplateKey)
+	}
+
+	for _, name := range h.partitionBy {
+		switch name {
+		case cache.PartitionModel:
+			q.partition.Model = q.Model
+			scope = append(scope, name+"="+q.Model)
+		case cache.PartitionTenant:
+			q.partition.Tenant = q.Tenant
+			scope = append(scope, name+"="+q.Tenant)
+		case cache.PartitionSystemPrompt:
+			q.partition.SystemHash = q.SystemHash
+			scope = append(scope, name+"="+q.SystemHash)
+		case cache.PartitionLanguage:
+			q.partition.Language = q.Language
+			scope = append(scope, name+"="+q.Language)
+		}
+	}
+
+	if len(scope) == 0 {
+		q.Hash = models.ComputeQueryHash(q.Lookup)
+	} else {
+		q.Hash = models.ComputeQueryHash(strings.Join(scope, "\x00") + "\x00" + q.Lookup)
+	}
+	return q
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// LookupText returns the text that is hashed and embedded for queryText: the
+// meaningful part of a templated prompt, normalized. The template match is
+// returned when one applied. Tools that embed queries outside the gateway use
+// it so their vectors line up with the gateway's.
+func LookupText(normalizer *models.Normalizer, promptTemplates *templates.Set, queryText string) (string, *templates.Match) {
+	match, ok := promptTemplates.Match(queryText)
+	if !ok {
+		return normalizer.Query(queryText), nil
+	}
+	lookup := normalizer.Query(match.Text)
+	if strings.TrimSpace(lookup) == "" {
+		// The template has no free text; the key alone decides a match
+		lookup = match.TemplateID
+	}
+	return lookup, match
+}
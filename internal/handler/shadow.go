@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/embedding"
+	"semantic-cache-gateway/internal/logger"
+	"semantic-cache-gateway/internal/models"
+)
+
+// DefaultShadowAgreementThreshold is the answer similarity at which a cached
+// and a fresh answer are considered to agree.
+const DefaultShadowAgreementThreshold = 0.9
+
+// shadowCompareTimeout bounds the embedding calls made to compare answers.
+const shadowCompareTimeout = 30 * time.Second
+
+// ShadowBucket aggregates shadow comparisons for semantic hits whose query
+// similarity fell in [MinSimilarity, MinSimilarity+0.01).
+type ShadowBucket struct {
+	MinSimilarity        float64 `json:"min_similarity"`
+	Comparisons          int64   `json:"comparisons"`
+	ExactAgreements      int64   `json:"exact_agreements"`
+	NormalizedAgreements int64   `json:"normalized_agreements"`
+	// SemanticAgreements counts answers whose embedding similarity reaches the
+	// agreement threshold; Embedded counts comparisons where both answers
+	// could be embedded.
+	SemanticAgreements   int64   `json:"semantic_agreements"`
+	Embedded             int64   `json:"embedded"`
+	ExactRate            float64 `json:"exact_rate"`
+	NormalizedRate       float64 `json:"normalized_rate"`
+	SemanticRate         float64 `json:"semantic_rate"`
+	MeanAnswerSimilarity float64 `json:"mean_answer_similarity"`
+	answerSimilaritySum  float64
+}
+
+// ShadowComparison is the outcome of comparing a cached answer to a fresh one.
+type ShadowComparison struct {
+	QuerySimilarity  float64
+	Exact            bool
+	Normalized       bool
+	Embedded         bool
+	AnswerSimilarity float64
+	Agrees           bool
+}
+
+// shadowStats holds per-bucket shadow comparison counters.
+var shadowStats = struct {
+	sync.Mutex
+	buckets map[int]*ShadowBucket
+}{buckets: make(map[int]*ShadowBucket)}
+
+// RecordShadowComparison adds a comparison to its similarity bucket.
+func RecordShadowComparison(c ShadowComparison) {
+	key := int(math.Floor(c.QuerySimilarity * 100))
+
+	shadowStats.Lock()
+	defer shadowStats.Unlock()
+
+	b := shadowStats.buckets[key]
+	if b == nil {
+		b = &ShadowBucket{MinSimilarity: float64(key) / 100}
+		shadowStats.buckets[key] = b
+	}
+	b.Comparisons++
+	if c.Exact {
+		b.ExactAgreements++
+	}
+	if c.Normalized {
+		b.NormalizedAgreements++
+	}
+	if c.Embedded {
+		b.Embedded++
+		b.answerSimilaritySum += c.AnswerSimilarity
+		if c.Agrees {
+			b.SemanticAgreements++
+		}
+	}
+}
+
+// shadowSnapshot returns the buckets with rates filled in, highest similarity first.
+func shadowSnapshot() []ShadowBucket {
+	shadowStats.Lock()
+	defer shadowStats.Unlock()
+
+	out := make([]ShadowBucket, 0, len(shadowStats.buckets))
+	for _, b := range shadowStats.buckets {
+		s := *b
+		s.ExactRate = float64(s.ExactAgreements) / float64(s.Comparisons)
+		s.NormalizedRate = float64(s.NormalizedAgreements) / float64(s.Comparisons)
+		if s.Embedded > 0 {
+			s.SemanticRate = float64(s.SemanticAgreements) / float64(s.Embedded)
+			s.MeanAnswerSimilarity = s.answerSimilaritySum / float64(s.Embedded)
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].MinSimilarity > out[j].MinSimilarity })
+	return out
+}
+
+func resetShadowStats() {
+	shadowStats.Lock()
+	shadowStats.buckets = make(map[int]*ShadowBucket)
+	shadowStats.Unlock()
+}
+
+// compareShadow compares a semantic hit that was not served with the fresh
+// upstream answer and records the result. It runs in the background.
+func (h *CacheHandler) compareShadow(entry *cache.CacheEntry, querySimilarity float64, freshBody []byte, log *logger.Logger) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shadowCompareTimeout)
+		defer cancel()
+
+		c := compareAnswers(ctx, h.embedding, answerText([]byte(entry.LLMResponse)), answerText(freshBody), h.shadowAgreement)
+		c.QuerySimilarity = querySimilarity
+		RecordShadowComparison(c)
+		log.Info("shadow comparison recorded",
+			"cache_key", entry.ID,
+			"similarity", querySimilarity,
+			"exact", c.Exact,
+			"normalized", c.Normalized,
+			"answer_similarity", c.AnswerSimilarity,
+			"agrees", c.Agrees,
+		)
+	}()
+}
+
+// compareAnswers compares two answers exactly, after normalization, and by
+// embedding similarity. Identical normalized answers skip the embedding calls.
+func compareAnswers(ctx context.Context, embedder embedding.EmbeddingService, cached, fresh string, agreement float64) ShadowComparison {
+	c := ShadowComparison{
+		Exact:      cached == fresh,
+		Normalized: normalizeAnswer(cached) == normalizeAnswer(fresh),
+	}
+	if c.Normalized {
+		c.Embedded, c.AnswerSimilarity, c.Agrees = true, 1, true
+		return c
+	}
+
+	a, err := embedder.Generate(ctx, cached)
+	if err != nil {
+		return c
+	}
+	b, err := embedder.Generate(ctx, fresh)
+	if err != nil || len(a) != len(b) {
+		return c
+	}
+	c.Embedded = true
+	c.AnswerSimilarity = embedding.CosineSimilarity(a, b)
+	c.Agrees = c.AnswerSimilarity >= agreement
+	return c
+}
+
+// answerText extracts the first choice's content, falling back to the raw body.
+func answerText(body []byte) string {
+	resp, err := models.ParseChatCompletionResponse(body)
+	if err != nil || len(resp.Choices) == 0 {
+		return string(body)
+	}
+	return resp.Choices[0].Message.Content
+}
+
+// normalizeAnswer lowercases text, drops punctuation and collapses whitespace.
+func normalizeAnswer(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
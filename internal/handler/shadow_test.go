@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// answerEmbedder returns fixed vectors per answer for comparison tests.
+type answerEmbedder map[string][]float32
+
+func (e answerEmbedder) Generate(ctx context.Context, text string) ([]float32, error) {
+	if v, ok := e[text]; ok {
+		return v, nil
+	}
+	return nil, errors.New("embedding unavailable")
+}
+
+// TestCompareAnswers verifies exact, normalized and semantic agreement.
+func TestCompareAnswers(t *testing.T) {
+	embedder := answerEmbedder{
+		"The answer is 4.":         {1, 0},
+		"Two plus two equals four": {0.95, 0.31},
+		"I don't know.":            {0, 1},
+	}
+	tests := []struct {
+		name          string
+		cached, fresh string
+		want          ShadowComparison
+	}{
+		{
+			name: "identical", cached: "The answer is 4.", fresh: "The answer is 4.",
+			want: ShadowComparison{Exact: true, Normalized: true, Embedded: true, AnswerSimilarity: 1, Agrees: true},
+		},
+		{
+			name: "normalized", cached: "The answer is 4.", fresh: "the  answer is 4",
+			want: ShadowComparison{Normalized: true, Embedded: true, AnswerSimilarity: 1, Agrees: true},
+		},
+		{
+			name: "paraphrase", cached: "The answer is 4.", fresh: "Two plus two equals four",
+			want: ShadowComparison{Embedded: true, Agrees: true},
+		},
+		{
+			name: "disagree", cached: "The answer is 4.", fresh: "I don't know.",
+			want: ShadowComparison{Embedded: true},
+		},
+		{
+			name: "embedding failure", cached: "The answer is 4.", fresh: "unknown",
+			want: ShadowComparison{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareAnswers(context.Background(), embedder, tt.cached, tt.fresh, 0.9)
+			if got.Exact != tt.want.Exact || got.Normalized != tt.want.Normalized ||
+				got.Embedded != tt.want.Embedded || got.Agrees != tt.want.Agrees {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestShadowBuckets verifies comparisons are grouped by similarity with rates.
+func TestShadowBuckets(t *testing.T) {
+	resetShadowStats()
+	defer resetShadowStats()
+
+	RecordShadowComparison(ShadowComparison{QuerySimilarity: 0.951, Exact: true, Normalized: true, Embedded: true, AnswerSimilarity: 1, Agrees: true})
+	RecordShadowComparison(ShadowComparison{QuerySimilarity: 0.959, Embedded: true, AnswerSimilarity: 0.5})
+	RecordShadowComparison(ShadowComparison{QuerySimilarity: 0.97})
+
+	buckets := shadowSnapshot()
+	if len(buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(buckets))
+	}
+	if buckets[0].MinSimilarity != 0.97 || buckets[1].MinSimilarity != 0.95 {
+		t.Errorf("expected buckets ordered by similarity, got %v and %v", buckets[0].MinSimilarity, buckets[1].MinSimilarity)
+	}
+	b := buckets[1]
+	if b.Comparisons != 2 || b.ExactRate != 0.5 || b.SemanticRate != 0.5 || b.MeanAnswerSimilarity != 0.75 {
+		t.Errorf("unexpected bucket: %+v", b)
+	}
+	if buckets[0].Embedded != 0 || buckets[0].SemanticRate != 0 {
+		t.Errorf("expected no semantic rate without embeddings, got %+v", buckets[0])
+	}
+}
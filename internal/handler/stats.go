@@ -7,17 +7,30 @@ import (
 	"net/http"
 	"sync/atomic"
 	"time"
+
+	"semantic-cache-gateway/internal/cache"
 )
 
 // Stats tracks gateway metrics.
 type Stats struct {
-	TotalRequests    int64     `json:"total_requests"`
-	CacheHits        int64     `json:"cache_hits"`
-	CacheMisses      int64     `json:"cache_misses"`
-	Errors           int64     `json:"errors"`
-	TotalLatencyMs   int64     `json:"total_latency_ms"`
-	StartTime        time.Time `json:"start_time"`
-	CostPerRequest   float64   `json:"cost_per_request"`
+	TotalRequests   int64     `json:"total_requests"`
+	CacheHits       int64     `json:"cache_hits"`
+	L1Hits          int64     `json:"l1_hits"`
+	L2Hits          int64     `json:"l2_hits"`
+	StaleHits       int64     `json:"stale_hits"`
+	CacheMisses     int64     `json:"cache_misses"`
+	Errors          int64     `json:"errors"`
+	Evictions       int64     `json:"evictions"`
+	ShadowHits      int64     `json:"shadow_hits"`
+	LexicalRejects  int64     `json:"lexical_rejects"`
+	VerifiedHits    int64     `json:"verified_hits"`
+	VerifierRejects int64     `json:"verifier_rejects"`
+	CacheBypasses   int64     `json:"cache_bypasses"`
+	TotalLatencyMs  int64     `json:"total_latency_ms"`
+	StartTime       time.Time `json:"start_time"`
+	CostPerRequest  float64   `json:"cost_per_request"`
+	// Shadow holds answer agreement per query-similarity bucket in shadow mode.
+	Shadow []ShadowBucket `json:"shadow,omitempty"`
 }
 
 // Global stats instance
@@ -33,6 +46,22 @@ func RecordHit(latencyMs int64) {
 	atomic.AddInt64(&globalStats.TotalLatencyMs, latencyMs)
 }
 
+// RecordTierHit attributes a hit to the cache tier that served it.
+func RecordTierHit(tier string) {
+	switch tier {
+	case cache.TierL1:
+		atomic.AddInt64(&globalStats.L1Hits, 1)
+	case cache.TierL2:
+		atomic.AddInt64(&globalStats.L2Hits, 1)
+	}
+}
+
+// RecordStaleHit records a hit served from a stale entry.
+func RecordStaleHit(latencyMs int64) {
+	RecordHit(latencyMs)
+	atomic.AddInt64(&globalStats.StaleHits, 1)
+}
+
 // RecordMiss records a cache miss.
 func RecordMiss(latencyMs int64) {
 	atomic.AddInt64(&globalStats.TotalRequests, 1)
@@ -46,26 +75,77 @@ func RecordError() {
 	atomic.AddInt64(&globalStats.Errors, 1)
 }
 
+// RecordEvictions records entries removed by the cache size limits.
+func RecordEvictions(count int) {
+	atomic.AddInt64(&globalStats.Evictions, int64(count))
+}
+
+// RecordShadowHit records a semantic hit that shadow mode did not serve.
+func RecordShadowHit() {
+	atomic.AddInt64(&globalStats.ShadowHits, 1)
+}
+
+// RecordLexicalReject records a semantic hit rejected by the lexical guard.
+func RecordLexicalReject() {
+	atomic.AddInt64(&globalStats.LexicalRejects, 1)
+}
+
+// RecordVerifiedHit records a gray-zone semantic hit the verifier accepted.
+func RecordVerifiedHit() {
+	atomic.AddInt64(&globalStats.VerifiedHits, 1)
+}
+
+// RecordVerifierReject records a gray-zone semantic hit the verifier rejected
+// or could not check.
+func RecordVerifierReject() {
+	atomic.AddInt64(&globalStats.VerifierRejects, 1)
+}
+
+// RecordBypass records a request passed through because Redis was unavailable.
+func RecordBypass() {
+	atomic.AddInt64(&globalStats.CacheBypasses, 1)
+}
+
 // ResetStats resets all stats to zero.
 func ResetStats() {
 	atomic.StoreInt64(&globalStats.TotalRequests, 0)
 	atomic.StoreInt64(&globalStats.CacheHits, 0)
+	atomic.StoreInt64(&globalStats.L1Hits, 0)
+	atomic.StoreInt64(&globalStats.L2Hits, 0)
+	atomic.StoreInt64(&globalStats.StaleHits, 0)
 	atomic.StoreInt64(&globalStats.CacheMisses, 0)
 	atomic.StoreInt64(&globalStats.Errors, 0)
+	atomic.StoreInt64(&globalStats.Evictions, 0)
+	atomic.StoreInt64(&globalStats.ShadowHits, 0)
+	atomic.StoreInt64(&globalStats.LexicalRejects, 0)
+	atomic.StoreInt64(&globalStats.VerifiedHits, 0)
+	atomic.StoreInt64(&globalStats.VerifierRejects, 0)
+	atomic.StoreInt64(&globalStats.CacheBypasses, 0)
 	atomic.StoreInt64(&globalStats.TotalLatencyMs, 0)
+	resetShadowStats()
 	globalStats.StartTime = time.Now()
 }
 
 // GetStats returns current stats.
 func GetStats() Stats {
 	return Stats{
-		TotalRequests:  atomic.LoadInt64(&globalStats.TotalRequests),
-		CacheHits:      atomic.LoadInt64(&globalStats.CacheHits),
-		CacheMisses:    atomic.LoadInt64(&globalStats.CacheMisses),
-		Errors:         atomic.LoadInt64(&globalStats.Errors),
-		TotalLatencyMs: atomic.LoadInt64(&globalStats.TotalLatencyMs),
-		StartTime:      globalStats.StartTime,
-		CostPerRequest: globalStats.CostPerRequest,
+		TotalRequests:   atomic.LoadInt64(&globalStats.TotalRequests),
+		CacheHits:       atomic.LoadInt64(&globalStats.CacheHits),
+		L1Hits:          atomic.LoadInt64(&globalStats.L1Hits),
+		L2Hits:          atomic.LoadInt64(&globalStats.L2Hits),
+		StaleHits:       atomic.LoadInt64(&globalStats.StaleHits),
+		CacheMisses:     atomic.LoadInt64(&globalStats.CacheMisses),
+		Errors:          atomic.LoadInt64(&globalStats.Errors),
+		Evictions:       atomic.LoadInt64(&globalStats.Evictions),
+		ShadowHits:      atomic.LoadInt64(&globalStats.ShadowHits),
+		LexicalRejects:  atomic.LoadInt64(&globalStats.LexicalRejects),
+		VerifiedHits:    atomic.LoadInt64(&globalStats.VerifiedHits),
+		VerifierRejects: atomic.LoadInt64(&globalStats.VerifierRejects),
+		CacheBypasses:   atomic.LoadInt64(&globalStats.CacheBypasses),
+		TotalLatencyMs:  atomic.LoadInt64(&globalStats.TotalLatencyMs),
+		StartTime:       globalStats.StartTime,
+		CostPerRequest:  globalStats.CostPerRequest,
+		Shadow:          shadowSnapshot(),
 	}
 }
 
@@ -112,7 +192,9 @@ func StatsDashboard(w http.ResponseWriter, r *http.Request) {
 	tmpl.Execute(w, data)
 }
 
-var tmpl = template.Must(template.New("dashboard").Parse(`
+var tmpl = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"pct": func(rate float64) float64 { return rate * 100 },
+}).Parse(`
 <!DOCTYPE html>
 <html>
 <head>
@@ -179,6 +261,9 @@ var tmpl = template.Must(template.New("dashboard").Parse(`
             margin-top: 20px;
             overflow: hidden;
         }
+        .shadow-table { width: 100%; border-collapse: collapse; }
+        .shadow-table th { color: #888; font-weight: normal; font-size: 0.85em; text-transform: uppercase; }
+        .shadow-table th, .shadow-table td { padding: 6px 8px; border-bottom: 1px solid rgba(255,255,255,0.1); }
         .bar-fill {
             height: 100%;
             background: linear-gradient(90deg, #00ff88, #00d9ff);
@@ -222,6 +307,11 @@ var tmpl = template.Must(template.New("dashboard").Parse(`
                 <div class="card-label">Cache Hits</div>
             </div>
             
+            <div class="card">
+                <div class="card-value" style="color: #888;">{{.L1Hits}} / {{.L2Hits}}</div>
+                <div class="card-label">L1 (Memory) / L2 (Redis) Hits</div>
+            </div>
+            
             <div class="card">
                 <div class="card-value" style="color: #ffd93d;">{{.CacheMisses}}</div>
                 <div class="card-label">Cache Misses</div>
@@ -232,11 +322,49 @@ var tmpl = template.Must(template.New("dashboard").Parse(`
                 <div class="card-label">Errors</div>
             </div>
             
+            <div class="card">
+                <div class="card-value" style="color: #888;">{{.Evictions}}</div>
+                <div class="card-label">Evictions</div>
+            </div>
+            
+            <div class="card">
+                <div class="card-value" style="color: #888;">{{.LexicalRejects}}</div>
+                <div class="card-label">Lexical Rejects</div>
+            </div>
+            
+            <div class="card">
+                <div class="card-value" style="color: #888;">{{.VerifiedHits}} / {{.VerifierRejects}}</div>
+                <div class="card-label">Verified / Rejected by Verifier</div>
+            </div>
+            
+            <div class="card">
+                <div class="card-value" style="color: #888;">{{.CacheBypasses}}</div>
+                <div class="card-label">Passed Through (Redis Down)</div>
+            </div>
+            
             <div class="card">
                 <div class="card-value" style="color: #888; font-size: 1.2em;">{{.Uptime}}</div>
                 <div class="card-label">Uptime</div>
             </div>
         </div>
+        {{if .Shadow}}
+        <div class="card">
+            <div class="card-label" style="margin-bottom: 16px;">Shadow Mode Agreement ({{.ShadowHits}} semantic hits not served)</div>
+            <table class="shadow-table">
+                <tr><th>Similarity</th><th>Compared</th><th>Exact</th><th>Normalized</th><th>Semantic</th><th>Mean Answer Sim</th></tr>
+                {{range .Shadow}}
+                <tr>
+                    <td>&ge; {{printf "%.2f" .MinSimilarity}}</td>
+                    <td>{{.Comparisons}}</td>
+                    <td>{{printf "%.0f" (pct .ExactRate)}}%</td>
+                    <td>{{printf "%.0f" (pct .NormalizedRate)}}%</td>
+                    <td>{{printf "%.0f" (pct .SemanticRate)}}%</td>
+                    <td>{{printf "%.3f" .MeanAnswerSimilarity}}</td>
+                </tr>
+                {{end}}
+            </table>
+        </div>
+        {{end}}
         
         <div class="footer">
             Auto-refreshes every 5 seconds • 
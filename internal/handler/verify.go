@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"semantic-cache-gateway/internal/cache"
+	"semantic-cache-gateway/internal/logger"
+	"semantic-cache-gateway/internal/verify"
+)
+
+// DefaultVerifyHighThreshold is the similarity above which semantic hits are
+// served without asking the verifier.
+const DefaultVerifyHighThreshold = 0.98
+
+// DefaultVerifyTimeout bounds a single verifier call.
+const DefaultVerifyTimeout = 2 * time.Second
+
+// needsVerification reports whether a semantic hit is in the gray zone
+// between the similarity threshold and the high threshold.
+func (h *CacheHandler) needsVerification(similarity float64) bool {
+	return h.verifier != nil && similarity <= h.verifyHighThreshold
+}
+
+// verifyHit asks the verifier whether entry answers the query. A verifier
+// error counts as a rejection, so the request falls through to upstream.
+func (h *CacheHandler) verifyHit(
+	ctx context.Context,
+	r *http.Request,
+	query *cacheQuery,
+	entry *cache.CacheEntry,
+	similarity float64,
+	log *logger.Logger,
+) bool {
+	ctx, cancel := context.WithTimeout(ctx, h.verifyTimeout)
+	defer cancel()
+
+	start := time.Now()
+	ok, err := h.verifier.Verify(ctx, verify.Candidate{
+		Query:      query.Text,
+		Cached:     entry.QueryText,
+		Similarity: similarity,
+		Header:     r.Header,
+	})
+	latency := time.Since(start).Seconds() * 1000
+
+	if err != nil {
+		log.Error("hit verification failed", "cache_key", entry.ID, "error", err.Error(), "verify_latency_ms", latency)
+		RecordVerifierReject()
+		return false
+	}
+	if !ok {
+		log.Info("semantic hit rejected by verifier", "cache_key", entry.ID, "similarity", similarity, "verify_latency_ms", latency)
+		RecordVerifierReject()
+		return false
+	}
+	log.Info("semantic hit verified", "cache_key", entry.ID, "similarity", similarity, "verify_latency_ms", latency)
+	RecordVerifiedHit()
+	return true
+}
@@ -0,0 +1,145 @@
+// Package lexical rejects semantic matches between prompts that embed close
+// together but differ in facts an embedding tends to blur, such as "2023"
+// and "2024", "Paris" and "Lyon", or a dropped "not".
+package lexical
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Checks that can be enabled on a Guard.
+const (
+	CheckNumbers  = "numbers"
+	CheckNegation = "negation"
+	CheckEntities = "entities"
+)
+
+// Checks lists the valid checks.
+var Checks = []string{CheckNumbers, CheckNegation, CheckEntities}
+
+var numberPattern = regexp.MustCompile(`\d+(?:[.,]\d+)*`)
+
+var negations = map[string]bool{
+	"not": true, "no": true, "never": true, "none": true, "nothing": true,
+	"neither": true, "nor": true, "without": true, "cannot": true,
+}
+
+// Guard compares two prompts lexically. A nil Guard accepts every pair.
+type Guard struct {
+	numbers, negation, entities bool
+}
+
+// New builds a Guard with the named checks.
+func New(checks []string) (*Guard, error) {
+	g := &Guard{}
+	for _, check := range checks {
+		switch strings.ToLower(strings.TrimSpace(check)) {
+		case CheckNumbers:
+			g.numbers = true
+		case CheckNegation:
+			g.negation = true
+		case CheckEntities:
+			g.entities = true
+		case "", "none":
+		default:
+			return nil, fmt.Errorf("unknown lexical check %q", check)
+		}
+	}
+	if !g.numbers && !g.negation && !g.entities {
+		return nil, nil
+	}
+	return g, nil
+}
+
+// Check reports whether a and b agree on every enabled check. When they do
+// not, it returns the name of the first failing check.
+func (g *Guard) Check(a, b string) (bool, string) {
+	if g == nil {
+		return true, ""
+	}
+	if g.numbers && !sameSet(Numbers(a), Numbers(b)) {
+		return false, CheckNumbers
+	}
+	if g.negation && Negated(a) != Negated(b) {
+		return false, CheckNegation
+	}
+	if g.entities && !sameSet(Entities(a), Entities(b)) {
+		return false, CheckEntities
+	}
+	return true, ""
+}
+
+// Numbers returns the distinct numbers in text, sorted.
+func Numbers(text string) []string {
+	return distinct(numberPattern.FindAllString(text, -1))
+}
+
+// Negated reports whether text contains an odd number of negations, so
+// "is it not true" and "isn't it true" agree while "is it true" does not.
+func Negated(text string) bool {
+	count := 0
+	for _, word := range words(strings.ToLower(text)) {
+		if negations[word] || strings.HasSuffix(word, "n't") || strings.HasSuffix(word, "n’t") {
+			count++
+		}
+	}
+	return count%2 == 1
+}
+
+// Entities approximates named entities as capitalized words that do not start
+// a sentence, plus all-caps acronyms anywhere. The result is lowercased,
+// distinct and sorted.
+func Entities(text string) []string {
+	var found []string
+	sentenceStart := true
+	for _, word := range strings.Fields(text) {
+		trimmed := strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		trimmed = strings.TrimSuffix(strings.TrimSuffix(trimmed, "'s"), "’s")
+		if trimmed != "" {
+			first := []rune(trimmed)[0]
+			acronym := len([]rune(trimmed)) > 1 && strings.ToUpper(trimmed) == trimmed && strings.ToLower(trimmed) != trimmed
+			if acronym || (unicode.IsUpper(first) && !sentenceStart) {
+				found = append(found, strings.ToLower(trimmed))
+			}
+			sentenceStart = false
+		}
+		if strings.ContainsAny(word[len(word)-1:], ".?!:") {
+			sentenceStart = true
+		}
+	}
+	return distinct(found)
+}
+
+// words splits text into lowercase words, keeping apostrophes inside words.
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+}
+
+func distinct(items []string) []string {
+	sort.Strings(items)
+	out := items[:0]
+	for i, item := range items {
+		if i == 0 || item != items[i-1] {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package lexical
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestGuardCheck checks close paraphrases pass and factual differences fail.
+func TestGuardCheck(t *testing.T) {
+	g, err := New(Checks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		a, b   string
+		ok     bool
+		reason string
+	}{
+		{"paraphrase", "What was the GDP of France in 2023?", "Tell me France's GDP for 2023", true, ""},
+		{"different year", "Revenue in 2023?", "Revenue in 2024?", false, CheckNumbers},
+		{"missing number", "Top 10 movies", "Top movies", false, CheckNumbers},
+		{"dropped negation", "Why is the sky not green?", "Why is the sky green?", false, CheckNegation},
+		{"contracted negation", "Why isn't the sky green?", "Why is the sky not green?", true, ""},
+		{"different city", "Weather in Paris today", "Weather in Lyon today", false, CheckEntities},
+		{"acronym", "What does NASA do?", "What does ESA do?", false, CheckEntities},
+		{"sentence start ignored", "How tall is Everest?", "Tell me how tall Everest is", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := g.Check(tt.a, tt.b)
+			if ok != tt.ok || reason != tt.reason {
+				t.Errorf("Check(%q, %q) = %v, %q; want %v, %q", tt.a, tt.b, ok, reason, tt.ok, tt.reason)
+			}
+		})
+	}
+}
+
+// TestNew verifies unknown checks are rejected and no checks means no guard.
+func TestNew(t *testing.T) {
+	if _, err := New([]string{"dates"}); err == nil {
+		t.Error("expected an error for an unknown check")
+	}
+	g, err := New(nil)
+	if err != nil || g != nil {
+		t.Errorf("expected a nil guard without checks, got %v, %v", g, err)
+	}
+	if ok, _ := g.Check("2023", "2024"); !ok {
+		t.Error("expected a nil guard to accept every pair")
+	}
+}
+
+// TestNumbers verifies numbers are extracted with separators kept.
+func TestNumbers(t *testing.T) {
+	got := Numbers("Compare 3.14 and 1,000 with 3.14 and 42")
+	want := []string{"1,000", "3.14", "42"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Numbers() = %v, want %v", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"io"
 	"log/slog"
 	"os"
 )
@@ -38,6 +39,13 @@ func NewWithLevel(level slog.Level) *Logger {
 	return &Logger{Logger: slog.New(handler)}
 }
 
+// NewWithOutput creates a JSON logger writing to w, e.g. stderr for CLI commands
+// that use stdout for data.
+func NewWithOutput(w io.Writer, level slog.Level) *Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	return &Logger{Logger: slog.New(handler)}
+}
+
 // With returns a new Logger with additional attributes.
 func (l *Logger) With(args ...any) *Logger {
 	return &Logger{Logger: l.Logger.With(args...)}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// ValidToken reports whether provided is the admin token. An empty token
+// matches nothing.
+func ValidToken(provided, token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// RequireToken rejects requests that do not carry the given bearer token.
+// An empty token disables the endpoint: every request is answered with 403.
+func RequireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeErrorResponse(w, http.StatusForbidden, "Admin endpoints are disabled; set ADMIN_TOKEN to enable them", "permission_error")
+		})
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ValidToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), token) {
+			writeErrorResponse(w, http.StatusUnauthorized, "Invalid admin token", "authentication_error")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,144 @@
+package mockllm
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// stopWords are dropped before hashing so that filler words do not dominate similarity.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "is": true, "are": true, "was": true, "were": true,
+	"be": true, "to": true, "of": true, "in": true, "on": true, "for": true, "and": true,
+	"or": true, "me": true, "please": true, "tell": true, "can": true, "you": true,
+	"could": true, "would": true, "what": true, "whats": true, "do": true, "does": true,
+	"i": true, "my": true, "it": true, "this": true, "that": true, "about": true,
+}
+
+// synonyms maps common paraphrase words onto a shared token.
+var synonyms = map[string]string{
+	"city":       "",
+	"explain":    "describe",
+	"define":     "describe",
+	"meaning":    "describe",
+	"big":        "large",
+	"huge":       "large",
+	"quick":      "fast",
+	"rapid":      "fast",
+	"buy":        "purchase",
+	"car":        "vehicle",
+	"automobile": "vehicle",
+	"film":       "movie",
+	"begin":      "start",
+	"commence":   "start",
+}
+
+// Embedder produces deterministic embeddings from bag-of-words feature
+// hashing. Texts that share content words get high cosine similarity, so
+// paraphrases behave like they would with a real embedding model.
+type Embedder struct {
+	dimensions int
+}
+
+// NewEmbedder creates an Embedder producing vectors of the given size.
+func NewEmbedder(dimensions int) *Embedder {
+	if dimensions <= 0 {
+		dimensions = 1536
+	}
+	return &Embedder{dimensions: dimensions}
+}
+
+// Dimensions returns the vector size.
+func (e *Embedder) Dimensions() int {
+	return e.dimensions
+}
+
+// Generate implements embedding.EmbeddingService.
+func (e *Embedder) Generate(ctx context.Context, text string) ([]float32, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("empty input text")
+	}
+	return e.Embed(text), nil
+}
+
+// Embed returns the unit-length embedding for text.
+func (e *Embedder) Embed(text string) []float32 {
+	vec := make([]float64, e.dimensions)
+	tokens := Tokenize(text)
+	for i, tok := range tokens {
+		e.add(vec, tok, 1.0)
+		if i > 0 {
+			e.add(vec, tokens[i-1]+" "+tok, 0.5)
+		}
+	}
+	// Keep the empty-content case distinguishable but stable
+	if len(tokens) == 0 {
+		e.add(vec, strings.ToLower(strings.TrimSpace(text)), 1.0)
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+
+	out := make([]float32, e.dimensions)
+	for i, v := range vec {
+		if norm > 0 {
+			out[i] = float32(v / norm)
+		}
+	}
+	return out
+}
+
+// add spreads a feature over a few hashed dimensions with pseudo-random signs.
+func (e *Embedder) add(vec []float64, feature string, weight float64) {
+	for probe := 0; probe < 3; probe++ {
+		h := fnv.New64a()
+		h.Write([]byte{byte(probe)})
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		idx := int(sum % uint64(len(vec)))
+		if sum>>63 == 1 {
+			vec[idx] -= weight
+		} else {
+			vec[idx] += weight
+		}
+	}
+}
+
+// Tokenize lowercases text, splits on non-alphanumerics, drops stop words and
+// applies light stemming and synonym folding.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	var tokens []string
+	for _, f := range fields {
+		f = strings.ReplaceAll(f, "'", "")
+		if f == "" || stopWords[f] {
+			continue
+		}
+		if s, ok := synonyms[f]; ok {
+			if s == "" {
+				continue
+			}
+			f = s
+		}
+		tokens = append(tokens, stem(f))
+	}
+	return tokens
+}
+
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
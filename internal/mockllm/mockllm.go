@@ -0,0 +1,309 @@
+// Package mockllm provides an offline stand-in for the OpenAI chat completion
+// and embedding APIs, for local development and load testing.
+package mockllm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"semantic-cache-gateway/internal/models"
+)
+
+// Config holds configuration for the mock server.
+type Config struct {
+	// Dimensions is the size of generated embeddings.
+	Dimensions int
+	// CompletionLatency and EmbeddingLatency are added before each response.
+	CompletionLatency time.Duration
+	EmbeddingLatency  time.Duration
+	// LatencyJitter adds a random delay in [0, LatencyJitter) to each response.
+	LatencyJitter time.Duration
+	// ErrorRate is the fraction of requests that fail with ErrorStatus.
+	ErrorRate   float64
+	ErrorStatus int
+	// StreamChunkDelay is the pause between SSE chunks of a streamed completion.
+	StreamChunkDelay time.Duration
+	// Seed makes jitter and error injection reproducible.
+	Seed int64
+}
+
+// ErrorHeader forces a request to fail with the given status code, e.g.
+// "X-Mock-Error: 429". It makes error handling testable deterministically.
+const ErrorHeader = "X-Mock-Error"
+
+// DefaultConfig returns a Config that mimics text-embedding-ada-002 with
+// upstream-like completion latency.
+func DefaultConfig() Config {
+	return Config{
+		Dimensions:        1536,
+		CompletionLatency: 300 * time.Millisecond,
+		EmbeddingLatency:  20 * time.Millisecond,
+		ErrorStatus:       http.StatusInternalServerError,
+		StreamChunkDelay:  10 * time.Millisecond,
+		Seed:              1,
+	}
+}
+
+// Server serves /v1/chat/completions and /v1/embeddings.
+type Server struct {
+	config   Config
+	embedder *Embedder
+	mux      *http.ServeMux
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+// New creates a mock server with the given configuration.
+func New(cfg Config) *Server {
+	if cfg.ErrorStatus == 0 {
+		cfg.ErrorStatus = http.StatusInternalServerError
+	}
+	s := &Server{
+		config:   cfg,
+		embedder: NewEmbedder(cfg.Dimensions),
+		mux:      http.NewServeMux(),
+		rng:      rand.New(rand.NewSource(cfg.Seed)),
+	}
+	s.mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("/v1/embeddings", s.handleEmbeddings)
+	s.mux.HandleFunc("/embeddings", s.handleEmbeddings)
+	s.mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+	})
+	return s
+}
+
+// Embedder returns the embedder used by the /v1/embeddings endpoint.
+func (s *Server) Embedder() *Embedder {
+	return s.embedder
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "invalid_request_error")
+		return
+	}
+	var req models.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error")
+		return
+	}
+	if s.injectFault(w, r, s.config.CompletionLatency) {
+		return
+	}
+
+	completion := completionFor(&req)
+	if req.Stream {
+		s.streamCompletion(w, r, completion)
+		return
+	}
+	writeJSON(w, http.StatusOK, completion)
+}
+
+// streamCompletion writes the completion as OpenAI-style server-sent events,
+// one chunk per word, terminated by "data: [DONE]".
+func (s *Server) streamCompletion(w http.ResponseWriter, r *http.Request, completion map[string]interface{}) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	choice := completion["choices"].([]map[string]interface{})[0]
+	content := choice["message"].(map[string]string)["content"]
+	words := strings.SplitAfter(content, " ")
+
+	writeChunk := func(delta map[string]string, finishReason interface{}) {
+		chunk := map[string]interface{}{
+			"id":      completion["id"],
+			"object":  "chat.completion.chunk",
+			"created": completion["created"],
+			"model":   completion["model"],
+			"choices": []map[string]interface{}{
+				{"index": 0, "delta": delta, "finish_reason": finishReason},
+			},
+		}
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	writeChunk(map[string]string{"role": "assistant"}, nil)
+	for _, word := range words {
+		if !sleep(r, s.config.StreamChunkDelay) {
+			return
+		}
+		writeChunk(map[string]string{"content": word}, nil)
+	}
+	writeChunk(map[string]string{}, "stop")
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "invalid_request_error")
+		return
+	}
+	var req struct {
+		Input json.RawMessage `json:"input"`
+		Model string          `json:"model"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", "invalid_request_error")
+		return
+	}
+	inputs, err := parseEmbeddingInput(req.Input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "invalid_request_error")
+		return
+	}
+	if s.injectFault(w, r, s.config.EmbeddingLatency) {
+		return
+	}
+
+	type item struct {
+		Object    string    `json:"object"`
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	}
+	data := make([]item, len(inputs))
+	for i, input := range inputs {
+		data[i] = item{Object: "embedding", Embedding: s.embedder.Embed(input), Index: i}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   data,
+		"model":  req.Model,
+	})
+}
+
+// injectFault applies the configured latency and error injection. It reports
+// whether the request has been answered (with an error) or abandoned.
+func (s *Server) injectFault(w http.ResponseWriter, r *http.Request, latency time.Duration) bool {
+	s.rngMu.Lock()
+	if s.config.LatencyJitter > 0 {
+		latency += time.Duration(s.rng.Int63n(int64(s.config.LatencyJitter)))
+	}
+	fail := s.config.ErrorRate > 0 && s.rng.Float64() < s.config.ErrorRate
+	s.rngMu.Unlock()
+
+	if !sleep(r, latency) {
+		return true
+	}
+
+	status := 0
+	if forced := r.Header.Get(ErrorHeader); forced != "" {
+		status, _ = strconv.Atoi(forced)
+	} else if fail {
+		status = s.config.ErrorStatus
+	}
+	if status == 0 {
+		return false
+	}
+
+	errType := "server_error"
+	if status == http.StatusTooManyRequests {
+		errType = "rate_limit_error"
+	}
+	writeError(w, status, "injected failure", errType)
+	return true
+}
+
+// completionFor builds a deterministic completion for a request. The answer
+// is derived from the user messages so identical prompts get identical answers.
+func completionFor(req *models.ChatCompletionRequest) map[string]interface{} {
+	query := models.ExtractQueryText(req)
+	sum := sha256.Sum256([]byte(query))
+	id := hex.EncodeToString(sum[:6])
+
+	model := req.Model
+	if model == "" {
+		model = "mock-gpt"
+	}
+	content := fmt.Sprintf("Mock answer %s to: %s", id, query)
+
+	return map[string]interface{}{
+		"id":      "chatcmpl-mock-" + id,
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": []map[string]interface{}{
+			{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": content},
+				"finish_reason": "stop",
+			},
+		},
+		"usage": map[string]int{
+			"prompt_tokens":     len(strings.Fields(query)),
+			"completion_tokens": len(strings.Fields(content)),
+			"total_tokens":      len(strings.Fields(query)) + len(strings.Fields(content)),
+		},
+	}
+}
+
+func parseEmbeddingInput(raw json.RawMessage) ([]string, error) {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		if single == "" {
+			return nil, fmt.Errorf("input must not be empty")
+		}
+		return []string{single}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err == nil && len(many) > 0 {
+		return many, nil
+	}
+	return nil, fmt.Errorf("input must be a string or an array of strings")
+}
+
+// sleep waits for d or until the client goes away. It reports whether the
+// request is still live.
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(d):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message, errType string) {
+	errResp := struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}{}
+	errResp.Error.Message = message
+	errResp.Error.Type = errType
+	writeJSON(w, statusCode, errResp)
+}
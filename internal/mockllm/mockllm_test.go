@@ -0,0 +1,202 @@
+// Package mockllm contains tests for the offline mock LLM server.
+package mockllm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"semantic-cache-gateway/internal/embedding"
+	"semantic-cache-gateway/internal/models"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+// TestEmbedder_ParaphrasesAreCloserThanUnrelated verifies the fake embeddings
+// are deterministic and semantically meaningful.
+func TestEmbedder_ParaphrasesAreCloserThanUnrelated(t *testing.T) {
+	e := NewEmbedder(256)
+
+	base := e.Embed("What is the capital of France?")
+	if again := e.Embed("What is the capital of France?"); cosine(base, again) < 0.9999 {
+		t.Fatal("embeddings must be deterministic")
+	}
+
+	paraphrase := cosine(base, e.Embed("Please tell me the capital city of France"))
+	unrelated := cosine(base, e.Embed("How do I reverse a linked list in Go?"))
+	different := cosine(base, e.Embed("What is the capital of Germany?"))
+
+	if paraphrase < 0.95 {
+		t.Errorf("expected paraphrase similarity >= 0.95, got %.3f", paraphrase)
+	}
+	if unrelated > 0.3 {
+		t.Errorf("expected unrelated similarity <= 0.3, got %.3f", unrelated)
+	}
+	if different >= paraphrase {
+		t.Errorf("expected a different question (%.3f) to score below a paraphrase (%.3f)", different, paraphrase)
+	}
+}
+
+// TestServer_EmbeddingsCompatibleWithClient verifies the embedding client can
+// talk to the mock server.
+func TestServer_EmbeddingsCompatibleWithClient(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Dimensions = 64
+	cfg.EmbeddingLatency = 0
+	server := httptest.NewServer(New(cfg))
+	defer server.Close()
+
+	svc := embedding.NewService(embedding.Config{
+		APIEndpoint: server.URL + "/v1/embeddings",
+		ModelName:   "mock-embedding",
+		Dimensions:  64,
+	})
+	vec, err := svc.Generate(context.Background(), "hello world")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(vec) != 64 {
+		t.Errorf("expected 64 dimensions, got %d", len(vec))
+	}
+}
+
+// TestServer_ChatCompletionIsDeterministic verifies identical prompts get identical answers.
+func TestServer_ChatCompletionIsDeterministic(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CompletionLatency = 0
+	srv := New(cfg)
+
+	complete := func(prompt string) models.ChatCompletionResponse {
+		body, _ := json.Marshal(models.ChatCompletionRequest{
+			Model:    "gpt-4",
+			Messages: []models.Message{{Role: "user", Content: prompt}},
+		})
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		var resp models.ChatCompletionResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return resp
+	}
+
+	a, b := complete("Why is the sky blue?"), complete("Why is the sky blue?")
+	if len(a.Choices) != 1 || a.Choices[0].FinishReason != "stop" {
+		t.Fatalf("unexpected completion: %+v", a)
+	}
+	if a.Choices[0].Message.Content != b.Choices[0].Message.Content {
+		t.Error("expected identical prompts to get identical answers")
+	}
+	if c := complete("Why is grass green?"); c.Choices[0].Message.Content == a.Choices[0].Message.Content {
+		t.Error("expected different prompts to get different answers")
+	}
+}
+
+// TestServer_StreamingCompletion verifies stream requests are answered with
+// SSE chunks that reassemble into the non-streamed answer.
+func TestServer_StreamingCompletion(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CompletionLatency = 0
+	cfg.StreamChunkDelay = 0
+	srv := New(cfg)
+
+	body, _ := json.Marshal(models.ChatCompletionRequest{
+		Model:    "gpt-4",
+		Messages: []models.Message{{Role: "user", Content: "Why is the sky blue?"}},
+		Stream:   true,
+	})
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body)))
+
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	var content strings.Builder
+	var done bool
+	for _, line := range strings.Split(rr.Body.String(), "\n") {
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+	}
+
+	if !done {
+		t.Error("expected stream to end with [DONE]")
+	}
+	want := completionFor(&models.ChatCompletionRequest{
+		Messages: []models.Message{{Role: "user", Content: "Why is the sky blue?"}},
+	})["choices"].([]map[string]interface{})[0]["message"].(map[string]string)["content"]
+	if content.String() != want {
+		t.Errorf("expected streamed content %q, got %q", want, content.String())
+	}
+}
+
+// TestServer_ErrorInjection verifies configured and header-forced failures.
+func TestServer_ErrorInjection(t *testing.T) {
+	body, _ := json.Marshal(models.ChatCompletionRequest{
+		Model:    "gpt-4",
+		Messages: []models.Message{{Role: "user", Content: "hello"}},
+	})
+	send := func(srv *Server, header string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+		if header != "" {
+			req.Header.Set(ErrorHeader, header)
+		}
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	cfg := DefaultConfig()
+	cfg.CompletionLatency = 0
+	if code := send(New(cfg), "429"); code != http.StatusTooManyRequests {
+		t.Errorf("expected forced 429, got %d", code)
+	}
+
+	cfg.ErrorRate = 1
+	cfg.ErrorStatus = http.StatusServiceUnavailable
+	if code := send(New(cfg), ""); code != http.StatusServiceUnavailable {
+		t.Errorf("expected injected 503, got %d", code)
+	}
+
+	cfg.ErrorRate = 0.5
+	srv := New(cfg)
+	failures := 0
+	for i := 0; i < 200; i++ {
+		if send(srv, "") != http.StatusOK {
+			failures++
+		}
+	}
+	if failures < 60 || failures > 140 {
+		t.Errorf("expected roughly half of requests to fail, got %d/200", failures)
+	}
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// scripts are checked in order; the script with the most letters wins.
+var scripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"latin", unicode.Latin},
+	{"cyrillic", unicode.Cyrillic},
+	{"greek", unicode.Greek},
+	{"arabic", unicode.Arabic},
+	{"hebrew", unicode.Hebrew},
+	{"devanagari", unicode.Devanagari},
+	{"thai", unicode.Thai},
+	{"hangul", unicode.Hangul},
+	{"japanese", unicode.Hiragana},
+	{"japanese", unicode.Katakana},
+	{"han", unicode.Han},
+}
+
+// scriptLanguages maps scripts written by essentially one language to it.
+var scriptLanguages = map[string]string{
+	"greek":      "el",
+	"hebrew":     "he",
+	"devanagari": "hi",
+	"thai":       "th",
+	"hangul":     "ko",
+	"japanese":   "ja",
+	"han":        "zh",
+}
+
+// markedLanguages lists, in order, languages told apart from the main
+// language of their script by letters only they use.
+var markedLanguages = map[string][]struct {
+	language string
+	letters  string
+}{
+	"cyrillic": {{"uk", "іїєґ"}, {"be", "ў"}, {"sr", "ђћџљњј"}},
+	"arabic":   {{"ur", "ٹڈڑںےھ"}, {"fa", "پچژگکی"}},
+}
+
+// mainLanguages is the language of a script whose text has none of the
+// letters of markedLanguages.
+var mainLanguages = map[string]string{
+	"cyrillic": "ru",
+	"arabic":   "ar",
+}
+
+// functionWords are frequent words of the Latin-script languages DetectLanguage
+// recognizes. Words shared by several languages count for each of them.
+var functionWords = map[string][]string{
+	"en": strings.Fields("the is are was what how why who which of and to in a an it for on with do does can i you my this that be not from"),
+	"fr": strings.Fields("le la les est sont quel quelle comment pourquoi qui de des du et un une en pour dans avec ce cette je vous mon pas au que sur"),
+	"de": strings.Fields("der die das ist sind was wie warum wer und ein eine zu mit für von ich du nicht den dem auf im es sie mein kann"),
+	"es": strings.Fields("el la los las es son qué que cómo como por porque quién cuál de del y un una en para con yo mi no se lo al está"),
+	"it": strings.Fields("il lo la gli le è sono che cosa come perché chi qual di del della e un una in per con io mio non si al"),
+	"pt": strings.Fields("o a os as é são que qual como por porque quem de do da e um uma em para com eu meu não se ao no na"),
+	"nl": strings.Fields("de het een is zijn wat hoe waarom wie en van in op met voor ik je mijn niet dat die dit er te"),
+}
+
+// letterHints are letters that count as one more function word of a language.
+var letterHints = map[rune]string{
+	'¿': "es", '¡': "es", 'ñ': "es",
+	'ß': "de", 'ä': "de", 'ö': "de",
+	'ã': "pt", 'õ': "pt",
+	'ĳ': "nl",
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language text is written
+// in, such as "en" or "ru", or "" when it cannot tell. Scripts used by one
+// language decide it outright. Cyrillic and Arabic text is told apart by
+// letters particular to Ukrainian, Belarusian, Serbian, Urdu and Persian.
+// Latin text is scored by its function words and recognized as English,
+// French, German, Spanish, Italian, Portuguese or Dutch; other Latin-script
+// languages, and text too short to score, give "".
+func DetectLanguage(text string) string {
+	script := dominantScript(text)
+	if language, ok := scriptLanguages[script]; ok {
+		return language
+	}
+	if main, ok := mainLanguages[script]; ok {
+		for _, marked := range markedLanguages[script] {
+			if strings.ContainsAny(text, marked.letters) {
+				return marked.language
+			}
+		}
+		return main
+	}
+	if script == "latin" {
+		return latinLanguage(text)
+	}
+	return ""
+}
+
+// latinLanguage returns the language whose function words occur most often in
+// text, or "" when none occur or two languages tie.
+func latinLanguage(text string) string {
+	text = strings.ToLower(text)
+	scores := make(map[string]int)
+	for _, r := range text {
+		if language, ok := letterHints[r]; ok {
+			scores[language]++
+		}
+	}
+	words := make(map[string]int)
+	for _, w := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		words[w]++
+	}
+	for language, list := range functionWords {
+		for _, w := range list {
+			scores[language] += words[w]
+		}
+	}
+
+	best, bestScore, tied := "", 0, false
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = language, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+	if tied {
+		return ""
+	}
+	return best
+}
+
+// dominantScript returns the writing system most letters of text belong to,
+// such as "latin" or "cyrillic". Text with any kana is "japanese". It
+// returns "" when text has no letters.
+func dominantScript(text string) string {
+	counts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.name]++
+				break
+			}
+		}
+	}
+	if counts["japanese"] > 0 {
+		return "japanese"
+	}
+
+	best, bestCount := "", 0
+	for _, s := range scripts {
+		if counts[s.name] > bestCount {
+			best, bestCount = s.name, counts[s.name]
+		}
+	}
+	return best
+}
@@ -0,0 +1,55 @@
+package models
+
+import "testing"
+
+// TestDetectLanguage checks languages are told apart by script, by letters
+// particular to one language and by Latin-script function words.
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"What is the capital of France?", "en"},
+		{"Quelle est la capitale de la France ?", "fr"},
+		{"Was ist die Hauptstadt von Frankreich?", "de"},
+		{"¿Cuál es la capital de Francia?", "es"},
+		{"Qual è la capitale della Francia?", "it"},
+		{"Qual é a capital da França?", "pt"},
+		{"Wat is de hoofdstad van Frankrijk?", "nl"},
+		{"Какая столица Франции?", "ru"},
+		{"Яка столиця Франції?", "uk"},
+		{"پایتخت فرانسه کجاست؟", "fa"},
+		{"ما هي عاصمة فرنسا؟", "ar"},
+		{"Ποια είναι η πρωτεύουσα της Γαλλίας;", "el"},
+		{"法国的首都是什么？", "zh"},
+		{"フランスの首都は何ですか", "ja"},
+		{"프랑스의 수도는 어디입니까?", "ko"},
+		{"Explain quantum computing", ""},
+		{"12345 ?!", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.input); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestDominantScript checks the script with the most letters is reported.
+func TestDominantScript(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"What is the capital of France?", "latin"},
+		{"Какая столица Франции?", "cyrillic"},
+		{"法国的首都是什么？", "han"},
+		{"フランスの首都は何ですか", "japanese"},
+		{"Explain 量子 computing", "latin"},
+		{"12345 ?!", ""},
+	}
+	for _, tt := range tests {
+		if got := dominantScript(tt.input); got != tt.want {
+			t.Errorf("dominantScript(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
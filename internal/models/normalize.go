@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization steps, applied in this order regardless of how they are listed.
+const (
+	NormalizeNFKC        = "nfkc"
+	NormalizeCaseFold    = "casefold"
+	NormalizeTemplates   = "templates"
+	NormalizePunctuation = "punctuation"
+	NormalizeStopWords   = "stopwords"
+	NormalizeWhitespace  = "whitespace"
+)
+
+// DefaultNormalizeSteps are the steps that never change a query's meaning.
+// Case folding and punctuation are opt-in: "C#" and "c" differ in code, and
+// "MAX" and "max" may name different things.
+var DefaultNormalizeSteps = []string{NormalizeNFKC, NormalizeWhitespace}
+
+// keptSymbols are punctuation characters that carry meaning on a word's ends,
+// as in "C#", "-5", "50%" and "#1", and are never trimmed.
+const keptSymbols = "#%+-"
+
+// DefaultStopWords is used by the stopwords step when no list is configured.
+var DefaultStopWords = []string{"a", "an", "the", "please", "can", "you", "could", "would", "tell", "me"}
+
+// templateVariable matches unfilled placeholders such as {{name}} and ${name}.
+var templateVariable = regexp.MustCompile(`\{\{[^{}]*\}\}|\$\{[^{}]*\}`)
+
+// NormalizeConfig selects the normalization steps.
+type NormalizeConfig struct {
+	Steps []string
+	// StopWords are removed by the stopwords step. Defaults to DefaultStopWords.
+	StopWords []string
+}
+
+// Normalizer rewrites query text so trivial variants share a hash.
+// A nil Normalizer returns text unchanged.
+type Normalizer struct {
+	nfkc, caseFold, templates, punctuation, stopWords, whitespace bool
+
+	stopWordSet map[string]struct{}
+}
+
+// NewNormalizer validates the configured steps and builds a Normalizer.
+func NewNormalizer(cfg NormalizeConfig) (*Normalizer, error) {
+	n := &Normalizer{}
+	for _, step := range cfg.Steps {
+		switch strings.ToLower(strings.TrimSpace(step)) {
+		case NormalizeNFKC:
+			n.nfkc = true
+		case NormalizeCaseFold:
+			n.caseFold = true
+		case NormalizeTemplates:
+			n.templates = true
+		case NormalizePunctuation:
+			n.punctuation = true
+		case NormalizeStopWords:
+			n.stopWords = true
+		case NormalizeWhitespace:
+			n.whitespace = true
+		case "", "none":
+		default:
+			return nil, fmt.Errorf("unknown normalization step %q", step)
+		}
+	}
+
+	if n.stopWords {
+		words := cfg.StopWords
+		if len(words) == 0 {
+			words = DefaultStopWords
+		}
+		n.stopWordSet = make(map[string]struct{}, len(words))
+		for _, w := range words {
+			n.stopWordSet[strings.ToLower(strings.TrimSpace(w))] = struct{}{}
+		}
+	}
+	return n, nil
+}
+
+// Normalize applies the configured steps to text.
+func (n *Normalizer) Normalize(text string) string {
+	if n == nil {
+		return text
+	}
+	if n.nfkc {
+		text = norm.NFKC.String(text)
+	}
+	if n.caseFold {
+		// Folding maps lowercase Cherokee to uppercase, so lower the result
+		// again to keep folded text stable under a second pass.
+		text = strings.ToLower(cases.Fold().String(text))
+	}
+	if n.templates {
+		text = templateVariable.ReplaceAllString(text, " ")
+	}
+	if n.punctuation {
+		text = trimPunctuation(text)
+	}
+	if n.stopWords {
+		words := strings.Fields(text)
+		kept := words[:0]
+		for _, w := range words {
+			if _, stop := n.stopWordSet[strings.ToLower(w)]; !stop {
+				kept = append(kept, w)
+			}
+		}
+		text = strings.Join(kept, " ")
+	}
+	if n.whitespace {
+		text = strings.Join(strings.Fields(text), " ")
+	}
+	return text
+}
+
+// Query returns the text used to hash and embed a query. Queries that
+// normalize to nothing, such as a lone "?", keep their original text.
+func (n *Normalizer) Query(text string) string {
+	if normalized := n.Normalize(text); normalized != "" {
+		return normalized
+	}
+	return text
+}
+
+// trimPunctuation strips punctuation from the start and end of each word, so
+// "X?" and "X ?" match while "3.14" and "don't" keep their inner characters
+// and keptSymbols stay on either end.
+func trimPunctuation(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			b.WriteString(strings.TrimFunc(text[start:end], trimmable))
+			start = -1
+		}
+	}
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			flush(i)
+			b.WriteRune(r)
+		} else if start < 0 {
+			start = i
+		}
+	}
+	flush(len(text))
+	return b.String()
+}
+
+// trimmable reports whether trimPunctuation removes r from a word's ends.
+func trimmable(r rune) bool {
+	return unicode.IsPunct(r) && !strings.ContainsRune(keptSymbols, r)
+}
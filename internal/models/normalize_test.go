@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// lenientSteps adds the opt-in steps that keep a query's meaning in prose.
+var lenientSteps = []string{NormalizeNFKC, NormalizeCaseFold, NormalizePunctuation, NormalizeWhitespace}
+
+// TestNormalizer checks each step on trivial query variants.
+func TestNormalizer(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []string
+		input string
+		want  string
+	}{
+		{"default steps", DefaultNormalizeSteps, "  What is   X? ", "What is X?"},
+		{"nfkc width and ligatures", DefaultNormalizeSteps, "ＡＢＣ ﬁle", "ABC file"},
+		{"all meaning-preserving steps", lenientSteps, "  What is   X? ", "what is x"},
+		{"spaced punctuation", lenientSteps, "what is x ?", "what is x"},
+		{"inner punctuation kept", lenientSteps, "Is pi 3.14? Don't round.", "is pi 3.14 don't round"},
+		{"symbols kept", lenientSteps, "What is C#? -5 squared, 50%, #1 (+2).", "what is c# -5 squared 50% #1 +2"},
+		{"case fold", []string{NormalizeCaseFold}, "STRASSE Straße", "strasse strasse"},
+		{"stop words", []string{NormalizeCaseFold, NormalizeStopWords, NormalizeWhitespace}, "Can you tell me the capital of France", "capital of france"},
+		{"templates", []string{NormalizeTemplates, NormalizeWhitespace}, "Summarize {{document}} for ${user}", "Summarize for"},
+		{"no steps", nil, "  What is X? ", "  What is X? "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewNormalizer(NormalizeConfig{Steps: tt.steps})
+			if err != nil {
+				t.Fatalf("NewNormalizer: %v", err)
+			}
+			if got := n.Normalize(tt.input); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNormalizer_DistinctQueries verifies queries that differ only in a
+// meaningful symbol do not normalize to the same text.
+func TestNormalizer_DistinctQueries(t *testing.T) {
+	pairs := [][2]string{
+		{"What is C#?", "What is C?"},
+		{"-5 squared", "5 squared"},
+		{"50%", "50"},
+		{"#1", "1"},
+		{"C++", "C"},
+	}
+	for _, steps := range [][]string{DefaultNormalizeSteps, lenientSteps} {
+		n, err := NewNormalizer(NormalizeConfig{Steps: steps})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range pairs {
+			if a, b := n.Normalize(p[0]), n.Normalize(p[1]); a == b {
+				t.Errorf("steps %v: %q and %q both normalize to %q", steps, p[0], p[1], a)
+			}
+		}
+	}
+}
+
+// TestNewNormalizer_UnknownStep verifies misspelled steps are rejected.
+func TestNewNormalizer_UnknownStep(t *testing.T) {
+	if _, err := NewNormalizer(NormalizeConfig{Steps: []string{"lowercase"}}); err == nil {
+		t.Error("expected an error for an unknown step")
+	}
+}
+
+// TestNormalizer_Idempotent verifies normalizing twice gives the same text,
+// so stored hashes stay stable if normalized text is normalized again.
+func TestNormalizer_Idempotent(t *testing.T) {
+	n, err := NewNormalizer(NormalizeConfig{Steps: lenientSteps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rapid.Check(t, func(t *rapid.T) {
+		text := rapid.String().Draw(t, "text")
+		once := n.Normalize(text)
+		if twice := n.Normalize(once); twice != once {
+			t.Fatalf("not idempotent: %q -> %q -> %q", text, once, twice)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"strings"
 )
 
@@ -12,9 +13,14 @@ type Message struct {
 }
 
 type ChatCompletionRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream,omitempty"`
+	Model       string            `json:"model"`
+	Messages    []Message         `json:"messages"`
+	Stream      bool              `json:"stream,omitempty"`
+	Temperature *float64          `json:"temperature,omitempty"`
+	N           *int              `json:"n,omitempty"`
+	Tools       []json.RawMessage `json:"tools,omitempty"`
+	Functions   []json.RawMessage `json:"functions,omitempty"`
+	User        string            `json:"user,omitempty"`
 }
 
 // ExtractQueryText concatenates all user messages from the request into a single string.
@@ -31,6 +37,20 @@ func ExtractQueryText(req *ChatCompletionRequest) string {
 	return strings.Join(parts, " ")
 }
 
+// ExtractSystemText concatenates all system messages from the request.
+func ExtractSystemText(req *ChatCompletionRequest) string {
+	if req == nil {
+		return ""
+	}
+	var parts []string
+	for _, msg := range req.Messages {
+		if msg.Role == "system" || msg.Role == "developer" {
+			parts = append(parts, msg.Content)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
 // ComputeQueryHash returns a SHA-256 hash of the query text with "sha256:" prefix.
 func ComputeQueryHash(queryText string) string {
 	hash := sha256.Sum256([]byte(queryText))
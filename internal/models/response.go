@@ -0,0 +1,38 @@
+package models
+
+import "encoding/json"
+
+type ContentFilterResult struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity,omitempty"`
+}
+
+type ResponseMessage struct {
+	Role      string            `json:"role"`
+	Content   string            `json:"content"`
+	Refusal   string            `json:"refusal,omitempty"`
+	ToolCalls []json.RawMessage `json:"tool_calls,omitempty"`
+}
+
+type Choice struct {
+	Index                int                            `json:"index"`
+	Message              ResponseMessage                `json:"message"`
+	FinishReason         string                         `json:"finish_reason"`
+	ContentFilterResults map[string]ContentFilterResult `json:"content_filter_results,omitempty"`
+}
+
+type ChatCompletionResponse struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+}
+
+// ParseChatCompletionResponse decodes an upstream chat completion body.
+func ParseChatCompletionResponse(body []byte) (*ChatCompletionResponse, error) {
+	var resp ChatCompletionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,147 @@
+// Package policy decides whether an upstream response is safe to cache.
+package policy
+
+import (
+	"semantic-cache-gateway/internal/models"
+)
+
+// Reasons reported when a response is not cached.
+const (
+	ReasonStream          = "stream_request"
+	ReasonTemperature     = "temperature_above_max"
+	ReasonMultipleChoices = "n_above_max"
+	ReasonTools           = "tools_in_request"
+	ReasonUserField       = "user_field_present"
+	ReasonUnparseable     = "unparseable_response"
+	ReasonNoChoices       = "no_choices"
+	ReasonFinishReason    = "finish_reason_not_allowed"
+	ReasonToolCalls       = "tool_calls_in_response"
+	ReasonRefusal         = "refusal"
+	ReasonContentFiltered = "content_filtered"
+)
+
+// UpstreamDefaultTemperature is the temperature OpenAI-compatible APIs sample
+// at when a request does not set one.
+const UpstreamDefaultTemperature = 1.0
+
+// Config holds the cacheability rules.
+type Config struct {
+	// MaxTemperature is the highest requested temperature that is still cached.
+	// Requests that do not set a temperature are sampled at
+	// UpstreamDefaultTemperature and are judged by it.
+	MaxTemperature float64
+	// MaxN is the highest number of choices per request that is still cached.
+	MaxN int
+	// AllowTools permits caching requests that declare tools or functions.
+	AllowTools bool
+	// AllowUserField permits caching requests that carry an end-user identifier.
+	AllowUserField bool
+	// FinishReasons lists the finish_reason values a cached choice may have.
+	FinishReasons []string
+}
+
+// DefaultConfig returns the default cacheability rules.
+func DefaultConfig() Config {
+	return Config{
+		MaxTemperature: 0,
+		MaxN:           1,
+		AllowTools:     false,
+		AllowUserField: true,
+		FinishReasons:  []string{"stop"},
+	}
+}
+
+// Decision is the outcome of a cacheability check.
+type Decision struct {
+	Cacheable bool
+	Reason    string
+}
+
+// Policy evaluates requests and responses against a Config.
+type Policy struct {
+	config        Config
+	finishReasons map[string]bool
+}
+
+// New creates a Policy from the given configuration.
+func New(cfg Config) *Policy {
+	if cfg.MaxN < 1 {
+		cfg.MaxN = 1
+	}
+	if len(cfg.FinishReasons) == 0 {
+		cfg.FinishReasons = []string{"stop"}
+	}
+	reasons := make(map[string]bool, len(cfg.FinishReasons))
+	for _, r := range cfg.FinishReasons {
+		reasons[r] = true
+	}
+	return &Policy{config: cfg, finishReasons: reasons}
+}
+
+// CheckRequest reports whether a request may have its response cached.
+// It can be evaluated before the upstream call.
+func (p *Policy) CheckRequest(req *models.ChatCompletionRequest) Decision {
+	if req == nil {
+		return Decision{Cacheable: true}
+	}
+	if req.Stream {
+		return Decision{Reason: ReasonStream}
+	}
+	temperature := UpstreamDefaultTemperature
+	if req.Temperature != nil {
+		temperature = *req.Temperature
+	}
+	if temperature > p.config.MaxTemperature {
+		return Decision{Reason: ReasonTemperature}
+	}
+	if req.N != nil && *req.N > p.config.MaxN {
+		return Decision{Reason: ReasonMultipleChoices}
+	}
+	if !p.config.AllowTools && (len(req.Tools) > 0 || len(req.Functions) > 0) {
+		return Decision{Reason: ReasonTools}
+	}
+	if !p.config.AllowUserField && req.User != "" {
+		return Decision{Reason: ReasonUserField}
+	}
+	return Decision{Cacheable: true}
+}
+
+// CheckResponse reports whether an upstream response body is complete and
+// deterministic enough to be cached.
+func (p *Policy) CheckResponse(body []byte) Decision {
+	resp, err := models.ParseChatCompletionResponse(body)
+	if err != nil {
+		return Decision{Reason: ReasonUnparseable}
+	}
+	if len(resp.Choices) == 0 {
+		return Decision{Reason: ReasonNoChoices}
+	}
+	for _, choice := range resp.Choices {
+		if choice.FinishReason == "content_filter" {
+			return Decision{Reason: ReasonContentFiltered}
+		}
+		for _, result := range choice.ContentFilterResults {
+			if result.Filtered {
+				return Decision{Reason: ReasonContentFiltered}
+			}
+		}
+		if choice.Message.Refusal != "" {
+			return Decision{Reason: ReasonRefusal}
+		}
+		if len(choice.Message.ToolCalls) > 0 && !p.config.AllowTools {
+			return Decision{Reason: ReasonToolCalls}
+		}
+		if !p.finishReasons[choice.FinishReason] {
+			return Decision{Reason: ReasonFinishReason}
+		}
+	}
+	return Decision{Cacheable: true}
+}
+
+// Evaluate applies both the request and response checks.
+func (p *Policy) Evaluate(req *models.ChatCompletionRequest, body []byte) Decision {
+	if d := p.CheckRequest(req); !d.Cacheable {
+		return d
+	}
+	return p.CheckResponse(body)
+}
@@ -0,0 +1,159 @@
+// Package policy contains tests for the cacheability policy.
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"semantic-cache-gateway/internal/models"
+)
+
+func floatPtr(f float64) *float64 { return &f }
+func intPtr(n int) *int           { return &n }
+
+// TestPolicy_CheckRequest verifies the request-side cacheability rules.
+func TestPolicy_CheckRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		req    *models.ChatCompletionRequest
+		reason string
+	}{
+		{
+			name: "plain request is cacheable",
+			cfg:  DefaultConfig(),
+			req:  &models.ChatCompletionRequest{Model: "gpt-4", Temperature: floatPtr(0)},
+		},
+		{
+			name:   "temperature unset",
+			cfg:    DefaultConfig(),
+			req:    &models.ChatCompletionRequest{Model: "gpt-4"},
+			reason: ReasonTemperature,
+		},
+		{
+			name: "temperature unset under the upstream default",
+			cfg:  Config{MaxTemperature: UpstreamDefaultTemperature},
+			req:  &models.ChatCompletionRequest{Model: "gpt-4"},
+		},
+		{
+			name:   "streaming request",
+			cfg:    DefaultConfig(),
+			req:    &models.ChatCompletionRequest{Stream: true},
+			reason: ReasonStream,
+		},
+		{
+			name:   "temperature above max",
+			cfg:    DefaultConfig(),
+			req:    &models.ChatCompletionRequest{Temperature: floatPtr(1.5)},
+			reason: ReasonTemperature,
+		},
+		{
+			name:   "default temperature",
+			cfg:    DefaultConfig(),
+			req:    &models.ChatCompletionRequest{Temperature: floatPtr(1.0)},
+			reason: ReasonTemperature,
+		},
+		{
+			name: "temperature at max",
+			cfg:  Config{MaxTemperature: 0.5},
+			req:  &models.ChatCompletionRequest{Temperature: floatPtr(0.5)},
+		},
+		{
+			name:   "multiple choices",
+			cfg:    DefaultConfig(),
+			req:    &models.ChatCompletionRequest{N: intPtr(3), Temperature: floatPtr(0)},
+			reason: ReasonMultipleChoices,
+		},
+		{
+			name:   "tools declared",
+			cfg:    DefaultConfig(),
+			req:    &models.ChatCompletionRequest{Temperature: floatPtr(0), Tools: []json.RawMessage{json.RawMessage(`{"type":"function"}`)}},
+			reason: ReasonTools,
+		},
+		{
+			name: "tools allowed",
+			cfg:  Config{MaxTemperature: 1, MaxN: 1, AllowTools: true},
+			req:  &models.ChatCompletionRequest{Tools: []json.RawMessage{json.RawMessage(`{"type":"function"}`)}},
+		},
+		{
+			name:   "user field rejected",
+			cfg:    Config{MaxTemperature: 1, MaxN: 1, AllowUserField: false},
+			req:    &models.ChatCompletionRequest{User: "user-42"},
+			reason: ReasonUserField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New(tt.cfg).CheckRequest(tt.req)
+			if d.Cacheable != (tt.reason == "") {
+				t.Fatalf("expected cacheable=%v, got %v (reason %q)", tt.reason == "", d.Cacheable, d.Reason)
+			}
+			if d.Reason != tt.reason {
+				t.Errorf("expected reason %q, got %q", tt.reason, d.Reason)
+			}
+		})
+	}
+}
+
+// TestPolicy_CheckResponse verifies the response-side cacheability rules.
+func TestPolicy_CheckResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{
+			name: "complete response",
+			body: `{"choices":[{"message":{"role":"assistant","content":"Paris"},"finish_reason":"stop"}]}`,
+		},
+		{
+			name:   "truncated response",
+			body:   `{"choices":[{"message":{"role":"assistant","content":"Par"},"finish_reason":"length"}]}`,
+			reason: ReasonFinishReason,
+		},
+		{
+			name:   "content filter finish reason",
+			body:   `{"choices":[{"message":{"role":"assistant","content":""},"finish_reason":"content_filter"}]}`,
+			reason: ReasonContentFiltered,
+		},
+		{
+			name:   "content filter results flagged",
+			body:   `{"choices":[{"message":{"content":"x"},"finish_reason":"stop","content_filter_results":{"hate":{"filtered":true,"severity":"high"}}}]}`,
+			reason: ReasonContentFiltered,
+		},
+		{
+			name:   "refusal",
+			body:   `{"choices":[{"message":{"content":"","refusal":"I can't help with that."},"finish_reason":"stop"}]}`,
+			reason: ReasonRefusal,
+		},
+		{
+			name:   "tool calls",
+			body:   `{"choices":[{"message":{"tool_calls":[{"id":"call_1","type":"function"}]},"finish_reason":"tool_calls"}]}`,
+			reason: ReasonToolCalls,
+		},
+		{
+			name:   "no choices",
+			body:   `{"choices":[]}`,
+			reason: ReasonNoChoices,
+		},
+		{
+			name:   "not json",
+			body:   `data: {"choices":[]}`,
+			reason: ReasonUnparseable,
+		},
+	}
+
+	p := New(DefaultConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := p.CheckResponse([]byte(tt.body))
+			if d.Cacheable != (tt.reason == "") {
+				t.Fatalf("expected cacheable=%v, got %v (reason %q)", tt.reason == "", d.Cacheable, d.Reason)
+			}
+			if d.Reason != tt.reason {
+				t.Errorf("expected reason %q, got %q", tt.reason, d.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Package templates splits prompts built from fixed templates into a template
+// ID, variables and the meaningful text.
+//
+// A prompt that embeds a document in shared boilerplate is mostly boilerplate,
+// so prompts about different documents look alike to the embedding model.
+// Matching a template lets the cache hash and embed only the meaningful part
+// and require the variables to be identical.
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// QueryGroup is the regex group holding the meaningful part of a prompt.
+const QueryGroup = "query"
+
+// Template describes one prompt template. Exactly one of Pattern and
+// Markers is set.
+//
+// Pattern is a regular expression that must match the whole prompt. Its named
+// groups are variables, except a group named "query", which is the meaningful
+// part. Markers cut variables out of the prompt by their delimiters. Either
+// way, without a "query" group the meaningful part is the prompt with the
+// variables removed.
+type Template struct {
+	ID      string   `json:"id"`
+	Pattern string   `json:"pattern,omitempty"`
+	Markers []Marker `json:"markers,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Marker delimits a variable, such as a document between <document> and </document>.
+type Marker struct {
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Match is a prompt split by a template.
+type Match struct {
+	TemplateID string
+	Variables  map[string]string
+	// Text is the meaningful part of the prompt.
+	Text string
+}
+
+// Key identifies the template and its variable values. Prompts can only
+// share a cached answer when their keys are equal.
+func (m *Match) Key() string {
+	names := make([]string, 0, len(m.Variables))
+	for name := range m.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	h.Write([]byte(m.TemplateID))
+	for _, name := range names {
+		fmt.Fprintf(h, "\x00%s\x00%d:%s", name, len(m.Variables[name]), m.Variables[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Set is an ordered list of templates. The first matching template wins.
+// A nil Set matches nothing.
+type Set struct {
+	templates []Template
+}
+
+// New validates and compiles templates.
+func New(templates []Template) (*Set, error) {
+	seen := make(map[string]bool, len(templates))
+	compiled := make([]Template, len(templates))
+	for i, t := range templates {
+		if t.ID == "" {
+			return nil, fmt.Errorf("template %d: id is required", i)
+		}
+		if seen[t.ID] {
+			return nil, fmt.Errorf("template %q: duplicate id", t.ID)
+		}
+		seen[t.ID] = true
+
+		switch {
+		case t.Pattern != "" && len(t.Markers) > 0:
+			return nil, fmt.Errorf("template %q: set pattern or markers, not both", t.ID)
+		case t.Pattern != "":
+			re, err := regexp.Compile(t.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("template %q: %w", t.ID, err)
+			}
+			t.re = re
+		case len(t.Markers) > 0:
+			for _, m := range t.Markers {
+				if m.Name == "" || m.Start == "" || m.End == "" {
+					return nil, fmt.Errorf("template %q: markers need name, start and end", t.ID)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("template %q: pattern or markers is required", t.ID)
+		}
+		compiled[i] = t
+	}
+	return &Set{templates: compiled}, nil
+}
+
+// Parse reads a JSON array of templates.
+func Parse(r io.Reader) (*Set, error) {
+	var templates []Template
+	if err := json.NewDecoder(r).Decode(&templates); err != nil {
+		return nil, fmt.Errorf("invalid templates file: %w", err)
+	}
+	if len(templates) == 0 {
+		return nil, errors.New("templates file has no templates")
+	}
+	return New(templates)
+}
+
+// Load reads templates from a JSON file.
+func Load(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Len returns the number of templates.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.templates)
+}
+
+// Match splits text with the first template that matches it.
+func (s *Set) Match(text string) (*Match, bool) {
+	if s == nil {
+		return nil, false
+	}
+	for i := range s.templates {
+		t := &s.templates[i]
+		var m *Match
+		if t.re != nil {
+			m = t.matchPattern(text)
+		} else {
+			m = t.matchMarkers(text)
+		}
+		if m != nil {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func (t *Template) matchPattern(text string) *Match {
+	loc := t.re.FindStringSubmatchIndex(text)
+	if loc == nil || loc[0] != 0 || loc[1] != len(text) {
+		return nil
+	}
+
+	m := &Match{TemplateID: t.ID, Variables: make(map[string]string)}
+	var rest strings.Builder
+	last, hasQuery := 0, false
+	for i, name := range t.re.SubexpNames() {
+		start, end := loc[2*i], loc[2*i+1]
+		if name == "" || start < 0 {
+			continue
+		}
+		if name == QueryGroup {
+			m.Text, hasQuery = text[start:end], true
+			continue
+		}
+		m.Variables[name] = text[start:end]
+		if start >= last {
+			rest.WriteString(text[last:start])
+			rest.WriteByte(' ')
+			last = end
+		}
+	}
+	if !hasQuery {
+		rest.WriteString(text[last:])
+		m.Text = rest.String()
+	}
+	return m
+}
+
+func (t *Template) matchMarkers(text string) *Match {
+	m := &Match{TemplateID: t.ID, Variables: make(map[string]string)}
+	rest := text
+	for _, marker := range t.Markers {
+		start := strings.Index(rest, marker.Start)
+		if start < 0 {
+			return nil
+		}
+		valueStart := start + len(marker.Start)
+		end := strings.Index(rest[valueStart:], marker.End)
+		if end < 0 {
+			return nil
+		}
+		m.Variables[marker.Name] = rest[valueStart : valueStart+end]
+		rest = rest[:start] + " " + rest[valueStart+end+len(marker.End):]
+	}
+	m.Text = rest
+	return m
+}
@@ -0,0 +1,121 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMatch checks pattern and marker templates split prompts as documented.
+func TestMatch(t *testing.T) {
+	set, err := New([]Template{
+		{ID: "qa", Pattern: `(?s)Answer using this document:\n(?P<document>.*)\nQuestion: (?P<query>.*)`},
+		{ID: "greet", Pattern: `Write a greeting for (?P<name>\w+) in a (?P<tone>\w+) tone\.`},
+		{ID: "summarize", Markers: []Marker{{Name: "document", Start: "<document>", End: "</document>"}}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		wantID   string
+		wantText string
+		wantVars map[string]string
+	}{
+		{
+			name:     "pattern with query group",
+			input:    "Answer using this document:\nThe sky is blue.\nQuestion: What color is the sky?",
+			wantID:   "qa",
+			wantText: "What color is the sky?",
+			wantVars: map[string]string{"document": "The sky is blue."},
+		},
+		{
+			name:     "pattern without query group",
+			input:    "Write a greeting for Ada in a formal tone.",
+			wantID:   "greet",
+			wantText: "Write a greeting for   in a   tone.",
+			wantVars: map[string]string{"name": "Ada", "tone": "formal"},
+		},
+		{
+			name:     "markers",
+			input:    "Summarize briefly: <document>Long text</document> Use bullet points.",
+			wantID:   "summarize",
+			wantText: "Summarize briefly:   Use bullet points.",
+			wantVars: map[string]string{"document": "Long text"},
+		},
+		{
+			name:  "no match",
+			input: "What is the capital of France?",
+		},
+		{
+			name:  "pattern must match the whole prompt",
+			input: "Please Write a greeting for Ada in a formal tone.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := set.Match(tt.input)
+			if tt.wantID == "" {
+				if ok {
+					t.Fatalf("expected no match, got %+v", m)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("expected a match")
+			}
+			if m.TemplateID != tt.wantID || m.Text != tt.wantText {
+				t.Errorf("got template %q text %q, want %q text %q", m.TemplateID, m.Text, tt.wantID, tt.wantText)
+			}
+			if len(m.Variables) != len(tt.wantVars) {
+				t.Fatalf("got variables %v, want %v", m.Variables, tt.wantVars)
+			}
+			for name, value := range tt.wantVars {
+				if m.Variables[name] != value {
+					t.Errorf("variable %s = %q, want %q", name, m.Variables[name], value)
+				}
+			}
+		})
+	}
+}
+
+// TestMatchKey verifies keys differ by template and variable values only.
+func TestMatchKey(t *testing.T) {
+	a := &Match{TemplateID: "qa", Variables: map[string]string{"document": "doc 1", "lang": "en"}, Text: "q1"}
+	sameVars := &Match{TemplateID: "qa", Variables: map[string]string{"lang": "en", "document": "doc 1"}, Text: "q2"}
+	otherDoc := &Match{TemplateID: "qa", Variables: map[string]string{"document": "doc 2", "lang": "en"}}
+	otherTemplate := &Match{TemplateID: "qa2", Variables: map[string]string{"document": "doc 1", "lang": "en"}}
+
+	if a.Key() != sameVars.Key() {
+		t.Error("expected the same key for the same template and variables")
+	}
+	if a.Key() == otherDoc.Key() || a.Key() == otherTemplate.Key() {
+		t.Error("expected different keys for different variables or templates")
+	}
+}
+
+// TestParse verifies invalid template files are rejected.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"valid", `[{"id": "a", "pattern": "x(?P<v>.*)"}, {"id": "b", "markers": [{"name": "d", "start": "<d>", "end": "</d>"}]}]`, false},
+		{"empty", `[]`, true},
+		{"missing id", `[{"pattern": "x"}]`, true},
+		{"duplicate id", `[{"id": "a", "pattern": "x"}, {"id": "a", "pattern": "y"}]`, true},
+		{"bad regex", `[{"id": "a", "pattern": "("}]`, true},
+		{"both kinds", `[{"id": "a", "pattern": "x", "markers": [{"name": "d", "start": "<", "end": ">"}]}]`, true},
+		{"incomplete marker", `[{"id": "a", "markers": [{"name": "d", "start": "<d>"}]}]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
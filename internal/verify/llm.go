@@ -0,0 +1,89 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"semantic-cache-gateway/internal/models"
+	"semantic-cache-gateway/internal/proxy"
+)
+
+// DefaultLLMModel is the chat model asked to judge equivalence.
+const DefaultLLMModel = "gpt-4o-mini"
+
+const judgePrompt = `Do these two requests ask for the same answer? Reply with only "yes" or "no".
+
+Request A: %s
+
+Request B: %s`
+
+// LLM asks a chat model through the upstream proxy whether two prompts are
+// equivalent. The client's Authorization header is forwarded, so it works
+// whether or not the gateway holds its own upstream key.
+type LLM struct {
+	proxy proxy.UpstreamProxy
+	model string
+}
+
+// NewLLM creates an LLM verifier. An empty model uses DefaultLLMModel.
+func NewLLM(upstream proxy.UpstreamProxy, model string) *LLM {
+	if model == "" {
+		model = DefaultLLMModel
+	}
+	return &LLM{proxy: upstream, model: model}
+}
+
+// Verify asks the model and accepts the hit when it answers yes.
+func (v *LLM) Verify(ctx context.Context, c Candidate) (bool, error) {
+	temperature := 0.0
+	maxTokens := 3
+	body, err := json.Marshal(struct {
+		models.ChatCompletionRequest
+		MaxTokens int `json:"max_tokens"`
+	}{
+		ChatCompletionRequest: models.ChatCompletionRequest{
+			Model:       v.model,
+			Messages:    []models.Message{{Role: "user", Content: fmt.Sprintf(judgePrompt, c.Query, c.Cached)}},
+			Temperature: &temperature,
+		},
+		MaxTokens: maxTokens,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth := c.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := v.proxy.Forward(ctx, req)
+	if err != nil {
+		return false, fmt.Errorf("verifier request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read verifier response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("verifier returned status %d", resp.StatusCode)
+	}
+
+	parsed, err := models.ParseChatCompletionResponse(respBody)
+	if err != nil || len(parsed.Choices) == 0 {
+		return false, fmt.Errorf("invalid verifier response")
+	}
+	answer := strings.ToLower(strings.TrimSpace(parsed.Choices[0].Message.Content))
+	return strings.HasPrefix(answer, "yes"), nil
+}
@@ -0,0 +1,82 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultRerankerMinScore is the cross-encoder score a pair needs to pass.
+const DefaultRerankerMinScore = 0.5
+
+// Reranker scores prompt pairs with a cross-encoder served behind a
+// TEI-compatible /rerank endpoint.
+type Reranker struct {
+	url      string
+	minScore float64
+	client   *http.Client
+}
+
+// NewReranker creates a Reranker for the service at baseURL.
+func NewReranker(baseURL string, minScore float64, timeout time.Duration) *Reranker {
+	return &Reranker{
+		url:      strings.TrimRight(baseURL, "/") + "/rerank",
+		minScore: minScore,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+type rerankRequest struct {
+	Query string   `json:"query"`
+	Texts []string `json:"texts"`
+}
+
+type rerankResult struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+// Verify accepts the hit when the cross-encoder scores the pair at least
+// minScore.
+func (v *Reranker) Verify(ctx context.Context, c Candidate) (bool, error) {
+	body, err := json.Marshal(rerankRequest{Query: c.Query, Texts: []string{c.Cached}})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("reranker request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read reranker response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("reranker returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var results []rerankResult
+	if err := json.Unmarshal(respBody, &results); err != nil {
+		return false, fmt.Errorf("invalid reranker response: %w", err)
+	}
+	for _, r := range results {
+		if r.Index == 0 {
+			return r.Score >= v.minScore, nil
+		}
+	}
+	return false, fmt.Errorf("reranker returned no score")
+}
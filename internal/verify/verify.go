@@ -0,0 +1,124 @@
+// Package verify double-checks borderline semantic hits.
+//
+// A single cosine threshold either lets wrong answers through or throws away
+// good paraphrases. With a verifier, scores above a high threshold are served
+// directly, and scores between the similarity threshold and the high
+// threshold are served only when a HitVerifier agrees the two prompts ask the
+// same thing.
+package verify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Verifier kinds accepted by the configuration.
+const (
+	KindNone     = "none"
+	KindLLM      = "llm"
+	KindReranker = "reranker"
+)
+
+// Candidate is a semantic hit awaiting verification.
+type Candidate struct {
+	// Query is the incoming prompt; Cached is the prompt the entry was stored for.
+	Query  string
+	Cached string
+	// Similarity is the embedding similarity of the two prompts.
+	Similarity float64
+	// Header carries the client's request headers for verifiers that call
+	// upstream with the client's credentials.
+	Header http.Header
+}
+
+// HitVerifier decides whether a cached answer may be served for a candidate.
+type HitVerifier interface {
+	Verify(ctx context.Context, c Candidate) (bool, error)
+}
+
+// Cache remembers verdicts per prompt pair so each pair is verified once.
+// Errors are not cached. The oldest verdict is dropped once size is reached.
+type Cache struct {
+	next HitVerifier
+	size int
+
+	mu       sync.Mutex
+	verdicts map[string]bool
+	order    []string
+}
+
+// NewCache wraps next with a verdict cache holding up to size pairs.
+func NewCache(next HitVerifier, size int) *Cache {
+	if size < 1 {
+		size = 1
+	}
+	return &Cache{next: next, size: size, verdicts: make(map[string]bool, size)}
+}
+
+// Verify returns the cached verdict for the pair or asks the wrapped verifier.
+func (c *Cache) Verify(ctx context.Context, cand Candidate) (bool, error) {
+	key := pairKey(cand.Query, cand.Cached)
+	c.mu.Lock()
+	verdict, ok := c.verdicts[key]
+	c.mu.Unlock()
+	if ok {
+		return verdict, nil
+	}
+
+	verdict, err := c.next.Verify(ctx, cand)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.verdicts[key]; !ok {
+		if len(c.order) >= c.size {
+			delete(c.verdicts, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.verdicts[key] = verdict
+	return verdict, nil
+}
+
+// pairKey identifies a prompt pair. The verdicts are symmetric, so the
+// pair is ordered before hashing.
+func pairKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	sum := sha256.Sum256([]byte(a + "\x00" + b))
+	return hex.EncodeToString(sum[:])
+}
+
+// Stub is a deterministic verifier for tests and offline runs. Pairs listed
+// in Equivalent get that verdict; all others get Default.
+type Stub struct {
+	Default    bool
+	Equivalent map[[2]string]bool
+
+	calls int64
+}
+
+// Verify returns the configured verdict for the pair.
+func (s *Stub) Verify(ctx context.Context, c Candidate) (bool, error) {
+	atomic.AddInt64(&s.calls, 1)
+	if v, ok := s.Equivalent[[2]string{c.Query, c.Cached}]; ok {
+		return v, nil
+	}
+	if v, ok := s.Equivalent[[2]string{c.Cached, c.Query}]; ok {
+		return v, nil
+	}
+	return s.Default, nil
+}
+
+// Calls returns how many times Verify was called.
+func (s *Stub) Calls() int64 {
+	return atomic.LoadInt64(&s.calls)
+}
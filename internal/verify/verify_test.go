@@ -0,0 +1,163 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStub checks listed pairs in either order and the default verdict.
+func TestStub(t *testing.T) {
+	s := &Stub{Equivalent: map[[2]string]bool{{"a", "b"}: true}}
+	ctx := context.Background()
+
+	tests := []struct {
+		query, cached string
+		want          bool
+	}{
+		{"a", "b", true},
+		{"b", "a", true},
+		{"a", "c", false},
+	}
+	for _, tt := range tests {
+		got, err := s.Verify(ctx, Candidate{Query: tt.query, Cached: tt.cached})
+		if err != nil || got != tt.want {
+			t.Errorf("Verify(%q, %q) = %v, %v; want %v", tt.query, tt.cached, got, err, tt.want)
+		}
+	}
+	if s.Calls() != 3 {
+		t.Errorf("Calls() = %d, want 3", s.Calls())
+	}
+}
+
+// errVerifier fails until ok is set.
+type errVerifier struct {
+	ok    bool
+	calls int
+}
+
+func (v *errVerifier) Verify(ctx context.Context, c Candidate) (bool, error) {
+	v.calls++
+	if !v.ok {
+		return false, errors.New("unavailable")
+	}
+	return true, nil
+}
+
+// TestCache verifies verdicts are reused per pair, errors are retried and the
+// oldest pair is evicted once the cache is full.
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	stub := &Stub{Default: true}
+	c := NewCache(stub, 2)
+
+	c.Verify(ctx, Candidate{Query: "a", Cached: "b"})
+	c.Verify(ctx, Candidate{Query: "b", Cached: "a"})
+	if stub.Calls() != 1 {
+		t.Errorf("pair verified %d times, want 1", stub.Calls())
+	}
+
+	c.Verify(ctx, Candidate{Query: "a", Cached: "c"})
+	c.Verify(ctx, Candidate{Query: "a", Cached: "d"})
+	c.Verify(ctx, Candidate{Query: "a", Cached: "b"})
+	if stub.Calls() != 4 {
+		t.Errorf("after eviction verified %d times, want 4", stub.Calls())
+	}
+
+	failing := &errVerifier{}
+	c = NewCache(failing, 2)
+	if _, err := c.Verify(ctx, Candidate{Query: "a", Cached: "b"}); err == nil {
+		t.Fatal("expected error")
+	}
+	failing.ok = true
+	if ok, err := c.Verify(ctx, Candidate{Query: "a", Cached: "b"}); !ok || err != nil {
+		t.Errorf("Verify after recovery = %v, %v; want true, nil", ok, err)
+	}
+	if failing.calls != 2 {
+		t.Errorf("errors were cached: %d calls, want 2", failing.calls)
+	}
+}
+
+// fakeProxy answers chat completions with a fixed reply.
+type fakeProxy struct {
+	status int
+	reply  string
+	req    *http.Request
+	body   []byte
+}
+
+func (p *fakeProxy) Forward(ctx context.Context, req *http.Request) (*http.Response, error) {
+	p.req = req
+	p.body, _ = io.ReadAll(req.Body)
+	body, _ := json.Marshal(map[string]any{
+		"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": p.reply}}},
+	})
+	return &http.Response{StatusCode: p.status, Body: io.NopCloser(strings.NewReader(string(body)))}, nil
+}
+
+// TestLLM checks the judge's answer is parsed and the client's credentials
+// are forwarded.
+func TestLLM(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		reply   string
+		want    bool
+		wantErr bool
+	}{
+		{"yes", http.StatusOK, "Yes.", true, false},
+		{"no", http.StatusOK, "no", false, false},
+		{"unclear", http.StatusOK, "maybe", false, false},
+		{"upstream error", http.StatusInternalServerError, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &fakeProxy{status: tt.status, reply: tt.reply}
+			header := http.Header{}
+			header.Set("Authorization", "Bearer client-key")
+
+			got, err := NewLLM(p, "").Verify(context.Background(), Candidate{Query: "capital of France", Cached: "France's capital", Header: header})
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("Verify() = %v, %v; want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+			if p.req.URL.Path != "/v1/chat/completions" || p.req.Header.Get("Authorization") != "Bearer client-key" {
+				t.Errorf("request = %s with auth %q", p.req.URL.Path, p.req.Header.Get("Authorization"))
+			}
+			if !strings.Contains(string(p.body), DefaultLLMModel) || !strings.Contains(string(p.body), "France's capital") {
+				t.Errorf("request body = %s", p.body)
+			}
+		})
+	}
+}
+
+// TestReranker checks the score is compared with the minimum.
+func TestReranker(t *testing.T) {
+	var score float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rerankRequest
+		if r.URL.Path != "/rerank" || json.NewDecoder(r.Body).Decode(&req) != nil || len(req.Texts) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode([]rerankResult{{Index: 0, Score: score}})
+	}))
+	defer server.Close()
+
+	v := NewReranker(server.URL+"/", 0.5, time.Second)
+	for _, tt := range []struct {
+		score float64
+		want  bool
+	}{{0.9, true}, {0.5, true}, {0.2, false}} {
+		score = tt.score
+		got, err := v.Verify(context.Background(), Candidate{Query: "a", Cached: "b"})
+		if err != nil || got != tt.want {
+			t.Errorf("score %v: Verify() = %v, %v; want %v", tt.score, got, err, tt.want)
+		}
+	}
+}
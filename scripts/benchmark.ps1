@@ -21,20 +21,20 @@ $headers = @{
 # Test queries - includes exact duplicates and semantic variations
 $queries = @(
     # Group 1: Capital questions (semantic similarity)
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"What is the capital of France?"}]}',
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"What is the capital of France?"}]}',  # Exact duplicate
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"Tell me the capital city of France"}]}',  # Semantic match
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"France capital?"}]}',  # Semantic match
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"What is the capital of France?"}]}',
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"What is the capital of France?"}]}',  # Exact duplicate
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"Tell me the capital city of France"}]}',  # Semantic match
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"France capital?"}]}',  # Semantic match
     
     # Group 2: Weather questions
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"How does weather forecasting work?"}]}',
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"How does weather forecasting work?"}]}',  # Exact duplicate
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"Explain weather prediction methods"}]}',  # Semantic match
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"How does weather forecasting work?"}]}',
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"How does weather forecasting work?"}]}',  # Exact duplicate
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"Explain weather prediction methods"}]}',  # Semantic match
     
     # Group 3: Programming questions
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"What is a REST API?"}]}',
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"What is a REST API?"}]}',  # Exact duplicate
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"Explain REST APIs"}]}'  # Semantic match
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"What is a REST API?"}]}',
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"What is a REST API?"}]}',  # Exact duplicate
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"Explain REST APIs"}]}'  # Semantic match
 )
 
 Write-Host "============================================" -ForegroundColor Cyan
@@ -117,9 +117,9 @@ Write-Host "--------------------------------------------"
 
 # Only test unique queries for direct comparison
 $uniqueQueries = @(
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"What is the capital of Germany?"}]}',
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"How does photosynthesis work?"}]}',
-    '{"model":"gpt-3.5-turbo","messages":[{"role":"user","content":"What is machine learning?"}]}'
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"What is the capital of Germany?"}]}',
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"How does photosynthesis work?"}]}',
+    '{"model":"gpt-3.5-turbo","temperature":0,"messages":[{"role":"user","content":"What is machine learning?"}]}'
 )
 
 foreach ($query in $uniqueQueries) {
@@ -67,6 +67,7 @@ foreach ($i in 0..($UniqueQueries - 1)) {
     foreach ($j in 1..$RepeatPerQuery) {
         $body = @{
             model = "gpt-3.5-turbo"
+            temperature = 0
             messages = @(@{role = "user"; content = $query})
         } | ConvertTo-Json
         